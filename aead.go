@@ -0,0 +1,69 @@
+package recio
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+var ErrDecryptionFailed = errors.New("record failed to decrypt")
+
+// WithAEAD encrypts the payload of every record with aead. The writer seals
+// each payload, after compressing it if WithCodec is in use, under a fresh
+// nonce obtained from nonceFunc and stores the nonce in front of the
+// ciphertext. The reader opens every record before returning it; a record
+// that has been tampered with fails authentication and the read returns
+// ErrDecryptionFailed instead of the payload. The schema version byte is
+// not encrypted. Writer and reader must use the same key. The option can't
+// be combined with WithProtobufFraming, which has no room for the nonce.
+//
+// A nonce must never be used twice with the same key, across all streams
+// written with it. If nonceFunc is nil, nonces are drawn from crypto/rand,
+// which is safe for up to 2^32 records per key with the 12 byte nonces of
+// AES-GCM. A nonceFunc must return nonces of aead.NonceSize() bytes.
+func WithAEAD(aead cipher.AEAD, nonceFunc func() []byte) Option {
+	return func(o *options) {
+		o.aead = aead
+		o.nonceFunc = nonceFunc
+	}
+}
+
+// seal encrypts p, returning the nonce followed by the ciphertext.
+func (w *Writer) seal(p []byte) ([]byte, error) {
+	size := w.opts.aead.NonceSize()
+	out := make([]byte, size, size+len(p)+w.opts.aead.Overhead())
+
+	if w.opts.nonceFunc == nil {
+		_, err := rand.Read(out)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		nonce := w.opts.nonceFunc()
+		if len(nonce) != size {
+			return nil, fmt.Errorf("nonce function returned %d bytes, the AEAD needs %d", len(nonce), size)
+		}
+		copy(out, nonce)
+	}
+	return w.opts.aead.Seal(out, out[:size], p, nil), nil
+}
+
+// open decrypts a payload written by seal.
+func (r *Reader) open(p []byte) ([]byte, error) {
+	size := r.opts.aead.NonceSize()
+	if len(p) < size {
+		return nil, fmt.Errorf("%w: record at offset %d is too short to hold a nonce", ErrDecryptionFailed, r.recordOffset)
+	}
+
+	plain, err := r.opts.aead.Open(nil, p[:size], p[size:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: record at offset %d: %v", ErrDecryptionFailed, r.recordOffset, err)
+	}
+
+	// make sure that empty records are returned as empty, not nil, slices
+	if plain == nil {
+		plain = []byte{}
+	}
+	return plain, nil
+}
@@ -0,0 +1,124 @@
+package recio
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAEAD(t *testing.T) cipher.AEAD {
+	block, err := aes.NewCipher(bytes.Repeat([]byte{0x42}, 32))
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	return aead
+}
+
+func TestAEAD(t *testing.T) {
+	aead := newTestAEAD(t)
+
+	var counter uint64
+	counterNonce := func() []byte {
+		counter++
+		return binary.BigEndian.AppendUint64(make([]byte, 4), counter)
+	}
+
+	for _, opts := range [][]Option{
+		{WithAEAD(aead, nil)},
+		{WithAEAD(aead, counterNonce)},
+		{WithAEAD(aead, nil), WithCodec(GzipCodec{}), WithHeader(), WithChecksum()},
+		{WithAEAD(aead, nil), WithSchemaVersion(2)},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, opts...)
+		for i := 0; i < 5; i++ {
+			_, err := w.WriteString(fmt.Sprintf("secret %d", i))
+			require.NoError(t, err)
+		}
+		_, err := w.Write(nil)
+		require.NoError(t, err)
+		require.NotContains(t, buf.String(), "secret")
+
+		r := NewReader(&buf, opts...)
+		for i := 0; i < 5; i++ {
+			rec, err := r.ReadRecord()
+			require.NoError(t, err)
+			require.Contains(t, string(rec), fmt.Sprintf("secret %d", i))
+		}
+		rec, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.NotNil(t, rec)
+
+		_, err = r.ReadRecord()
+		require.ErrorIs(t, err, io.EOF)
+	}
+}
+
+func TestAEADUniqueNonces(t *testing.T) {
+	aead := newTestAEAD(t)
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithAEAD(aead, nil))
+	for i := 0; i < 100; i++ {
+		_, err := w.WriteString("same payload")
+		require.NoError(t, err)
+	}
+
+	seen := map[string]bool{}
+	r := NewReader(&buf)
+	for i := 0; i < 100; i++ {
+		rec, err := r.ReadRecord()
+		require.NoError(t, err)
+		nonce := string(rec[:aead.NonceSize()])
+		require.False(t, seen[nonce])
+		seen[nonce] = true
+	}
+}
+
+func TestAEADTampered(t *testing.T) {
+	aead := newTestAEAD(t)
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithAEAD(aead, nil))
+	_, err := w.WriteString("do not touch")
+	require.NoError(t, err)
+	_, err = w.WriteString("next")
+	require.NoError(t, err)
+
+	data := buf.Bytes()
+	data[4+aead.NonceSize()+2] ^= 0x01
+
+	r := NewReader(bytes.NewReader(data), WithAEAD(aead, nil))
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, ErrDecryptionFailed)
+
+	// the stream is still in sync
+	rec, err := r.ReadRecord()
+	require.NoError(t, err)
+	require.Equal(t, "next", string(rec))
+}
+
+func TestAEADBadNonce(t *testing.T) {
+	w := NewWriter(io.Discard, WithAEAD(newTestAEAD(t), func() []byte { return []byte{1, 2, 3} }))
+	_, err := w.WriteString("x")
+	require.Error(t, err)
+}
+
+func TestAEADProtobufFraming(t *testing.T) {
+	// records are never written in the clear
+	var buf bytes.Buffer
+	opts := []Option{WithProtobufFraming(), WithAEAD(newTestAEAD(t), nil)}
+	_, err := NewWriter(&buf, opts...).Write([]byte("secret-password"))
+	require.ErrorIs(t, err, ErrInvalidOptions)
+	require.ErrorContains(t, err, "WithAEAD")
+	require.Zero(t, buf.Len())
+
+	_, err = NewReader(bytes.NewReader([]byte{2, 'h', 'i'}), opts...).ReadRecord()
+	require.ErrorIs(t, err, ErrInvalidOptions)
+}
@@ -0,0 +1,48 @@
+package recio
+
+import (
+	"io"
+)
+
+// WithAlignment pads every frame with zero bytes so that the next frame
+// starts at an offset that is a multiple of n, counted from the start of
+// the stream. This makes streams friendlier to memory mapping at the cost
+// of up to n-1 bytes per record. The padding follows the frame and is not
+// included in its length prefix or checksums; the stream header is padded
+// too. Writer and reader must use the same alignment, which the stream
+// header does not record. Values of n below 2 mean no alignment.
+func WithAlignment(n int) Option {
+	return func(o *options) {
+		o.alignment = n
+	}
+}
+
+// appendPadding pads dst, which is written at offset start in the stream,
+// so that it ends at an alignment boundary.
+func (w *Writer) appendPadding(dst []byte, start int64) []byte {
+	n := int64(w.opts.alignment)
+	if n < 2 {
+		return dst
+	}
+
+	end := start + int64(len(dst))
+	for pad := (n - end%n) % n; pad > 0; pad-- {
+		dst = append(dst, 0)
+	}
+	return dst
+}
+
+// skipPadding skips the padding in front of the next frame.
+func (r *Reader) skipPadding() error {
+	n := int64(r.opts.alignment)
+	pad := (n - r.count.n%n) % n
+	if pad == 0 {
+		return nil
+	}
+
+	skipped, err := io.CopyN(io.Discard, r.reader, pad)
+	if err == io.EOF && skipped > 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlignment(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithAlignment(16)},
+		{WithAlignment(64), WithHeader(), WithChecksum(), WithPrevFrameCRC()},
+		{WithAlignment(8), WithVarintLength(), WithReadBuffer(32)},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, append(opts, WithIndex())...)
+		var records [][]byte
+		for i := 0; i < 20; i++ {
+			rec := bytes.Repeat([]byte{byte(i)}, i*3)
+			records = append(records, rec)
+			_, err := w.Write(rec)
+			require.NoError(t, err)
+		}
+		_, err := w.WriteBatch(records[:3])
+		require.NoError(t, err)
+		records = append(records, records[:3]...)
+
+		o := newOptions(opts)
+		for _, offset := range w.Offsets() {
+			require.Zero(t, offset%int64(o.alignment), "offset %d", offset)
+		}
+		require.Zero(t, buf.Len()%o.alignment)
+
+		r := NewReader(bytes.NewReader(buf.Bytes()), opts...)
+		for _, rec := range records {
+			got, err := r.ReadRecord()
+			require.NoError(t, err)
+			require.Equal(t, rec, got)
+		}
+		_, err = r.ReadRecord()
+		require.ErrorIs(t, err, io.EOF)
+
+		// random access lands on the aligned offsets
+		if !o.prevFrameCRC {
+			s := NewSeekableReader(bytes.NewReader(buf.Bytes()), w.Offsets(), opts...)
+			got, err := s.ReadAt(7)
+			require.NoError(t, err)
+			require.Equal(t, records[7], got)
+		}
+	}
+}
+
+func TestAlignmentMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithAlignment(16))
+	for i := 0; i < 3; i++ {
+		_, err := w.WriteString(fmt.Sprintf("record %d", i))
+		require.NoError(t, err)
+	}
+
+	// a reader that doesn't know about the padding reads it as an empty
+	// record at best
+	r := NewReader(bytes.NewReader(buf.Bytes()))
+	_, err := r.ReadRecord()
+	require.NoError(t, err)
+	rec, err := r.ReadRecord()
+	require.NoError(t, err)
+	require.Empty(t, rec)
+}
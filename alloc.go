@@ -0,0 +1,82 @@
+package recio
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// Allocator provides the buffers that a Reader returns from its allocating
+// read methods, such as ReadVersioned and NextWithDigest. Callers hand
+// records back to the allocator with Put once they are done with them.
+type Allocator interface {
+	// Get returns a slice of length size.
+	Get(size int) []byte
+	// Put returns a slice obtained from Get to the allocator.
+	Put(b []byte)
+}
+
+// HeapAllocator allocates every buffer on the heap and leaves reclaiming
+// them to the garbage collector. It is the default Allocator.
+type HeapAllocator struct{}
+
+// Get returns a newly allocated slice of length size.
+func (HeapAllocator) Get(size int) []byte {
+	return make([]byte, size)
+}
+
+// Put does nothing.
+func (HeapAllocator) Put([]byte) {}
+
+// PoolAllocator recycles buffers through a set of sync.Pools, one for each
+// power of two size class. It is safe for concurrent use.
+type PoolAllocator struct {
+	pools [bits.UintSize + 1]sync.Pool
+}
+
+// NewPoolAllocator returns an empty PoolAllocator.
+func NewPoolAllocator() *PoolAllocator {
+	return &PoolAllocator{}
+}
+
+// Get returns a slice of length size whose capacity is size rounded up to
+// the next power of two.
+func (a *PoolAllocator) Get(size int) []byte {
+	class := bits.Len(uint(size - 1))
+	if size == 0 {
+		class = 0
+	}
+
+	if b, ok := a.pools[class].Get().(*[]byte); ok {
+		return (*b)[:size]
+	}
+	return make([]byte, size, 1<<class)
+}
+
+// Put makes b available to later calls to Get.
+func (a *PoolAllocator) Put(b []byte) {
+	if cap(b) == 0 {
+		return
+	}
+
+	// file the buffer under the largest class it can hold in full
+	class := bits.Len(uint(cap(b))) - 1
+	b = b[: 0 : 1<<class]
+	a.pools[class].Put(&b)
+}
+
+// recordPool holds the buffers handed out by ReadRecordFromPool.
+var recordPool = NewPoolAllocator()
+
+// ReadRecordFromPool reads the next record, like ReadRecord, into a buffer
+// drawn from a pool shared by all readers, and returns a function that puts
+// the buffer back. The caller must call the function once it is done with the
+// record and must not use the record afterwards. Buffers are pooled in
+// power of two size classes, so a steady stream of records is read without
+// allocating buffers for them.
+func (r *Reader) ReadRecordFromPool() ([]byte, func(), error) {
+	rec, err := r.readRecordFrom(recordPool)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rec, func() { recordPool.Put(rec) }, nil
+}
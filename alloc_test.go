@@ -0,0 +1,146 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+type trackingAllocator struct {
+	HeapAllocator
+	gets int
+	puts int
+}
+
+func (a *trackingAllocator) Get(size int) []byte {
+	a.gets++
+	return a.HeapAllocator.Get(size)
+}
+
+func (a *trackingAllocator) Put(b []byte) {
+	a.puts++
+}
+
+func TestCustomAllocator(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for i := 0; i < 50; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	alloc := &trackingAllocator{}
+	r := NewReader(bytes.NewReader(buf.Bytes()), WithAllocator(alloc))
+	for i := 0; ; i++ {
+		payload, _, err := r.NextWithDigest()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(payload))
+		alloc.Put(payload)
+	}
+
+	require.Equal(t, 50, alloc.gets)
+	require.Equal(t, alloc.gets, alloc.puts)
+}
+
+func TestPoolAllocator(t *testing.T) {
+	a := NewPoolAllocator()
+
+	b := a.Get(100)
+	require.Len(t, b, 100)
+	require.Equal(t, 128, cap(b))
+
+	require.Len(t, a.Get(0), 0)
+	require.Equal(t, 1, cap(a.Get(1)))
+
+	// buffers are filed under the largest size class they can hold
+	a.Put(make([]byte, 10, 200))
+	b = a.Get(128)
+	require.Len(t, b, 128)
+	require.Equal(t, 128, cap(b))
+}
+
+func TestReadRecordFromPool(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for i := 0; i < 10; i++ {
+		_, err := w.Write(bytes.Repeat([]byte{byte(i)}, 100))
+		require.NoError(t, err)
+	}
+
+	// sync.Pool makes no promises, and drops buffers at random when the
+	// race detector is on, but at least some released buffers must come
+	// back
+	r := NewReader(&buf)
+	seen := map[*byte]bool{}
+	reused := 0
+	for i := 0; i < 10; i++ {
+		rec, release, err := r.ReadRecordFromPool()
+		require.NoError(t, err)
+		require.Equal(t, bytes.Repeat([]byte{byte(i)}, 100), rec)
+		require.Equal(t, 128, cap(rec))
+
+		if seen[unsafe.SliceData(rec)] {
+			reused++
+		}
+		seen[unsafe.SliceData(rec)] = true
+		release()
+	}
+	require.NotZero(t, reused)
+
+	_, _, err := r.ReadRecordFromPool()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func BenchmarkReadRecordFromPool(b *testing.B) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for i := 0; i < 1000; i++ {
+		_, err := w.Write(make([]byte, 1000))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	data := buf.Bytes()
+
+	b.Run("ReadRecordFromPool", func(b *testing.B) {
+		src := bytes.NewReader(data)
+		r := NewReader(src)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, release, err := r.ReadRecordFromPool()
+			if err == io.EOF {
+				src.Reset(data)
+				r.Reset(src)
+				continue
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+			release()
+		}
+	})
+
+	b.Run("ReadRecord", func(b *testing.B) {
+		src := bytes.NewReader(data)
+		r := NewReader(src)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := r.ReadRecord()
+			if err == io.EOF {
+				src.Reset(data)
+				r.Reset(src)
+				continue
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
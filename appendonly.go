@@ -0,0 +1,107 @@
+package recio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var ErrTruncatedTail = errors.New("stream ends with an incomplete record")
+
+// TruncatedTailError is returned by OpenForAppend when a file ends with an
+// incomplete record. Offset is the end of the last complete record, where
+// the file should be truncated before appending to it.
+type TruncatedTailError struct {
+	Offset int64
+}
+
+func (e *TruncatedTailError) Error() string {
+	return fmt.Sprintf("%v: last complete record ends at offset %d", ErrTruncatedTail, e.Offset)
+}
+
+func (e *TruncatedTailError) Unwrap() error {
+	return ErrTruncatedTail
+}
+
+// WithAppendOnly makes sure the writer never overwrites existing records.
+// If the underlying writer implements io.Seeker, such as an *os.File opened
+// for reading and writing, NewWriter moves it to the end so new records are
+// appended after the ones already there, whatever position the file was
+// left at. UpdateRecordAt is refused with ErrNotUpdatable.
+//
+// The option cannot undo a truncation that has already happened, so files
+// must not be opened with os.O_TRUNC: the flag takes effect when the file is
+// opened and cannot be detected afterwards.
+func WithAppendOnly() Option {
+	return func(o *options) {
+		o.appendOnly = true
+	}
+}
+
+// seekToEnd moves an underlying io.Seeker to the end of the stream and
+// returns the new position, which is 0 if the writer is not an io.Seeker.
+func (w *Writer) seekToEnd() (int64, error) {
+	seeker, ok := w.writer.(io.Seeker)
+	if !ok {
+		return 0, nil
+	}
+	return seeker.Seek(0, io.SeekEnd)
+}
+
+// OpenForAppend validates the records in f, which must have been written
+// with the same options, and returns a Writer that appends records to it.
+// It returns a *TruncatedTailError, which matches ErrTruncatedTail, if the
+// file ends with an incomplete record, for example after a crash, or a
+// record begun by BeginRecord that was never committed, and any error found
+// reading the records before it. In both cases nothing is
+// written to f.
+//
+// The Writer continues the stream where it ends, including the state of
+// WithStreamGuard, WithMerkleChain and WithPrevFrameCRC, and uses
+// WithAppendOnly. With WithFileLock it locks f first.
+func OpenForAppend(f *os.File, opts ...Option) (w *Writer, err error) {
+	if newOptions(opts).fileLock {
+		err = lockFile(f)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err != nil {
+				unlockFile(f)
+			}
+		}()
+	}
+
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	r := NewReader(f, append(opts[:len(opts):len(opts)], WithReadBuffer(64*1024))...)
+	var good int64
+	for {
+		err := r.Skip()
+		if err == io.EOF {
+			good = r.count.n
+			break
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, ErrAbandonedRecord) {
+			return nil, &TruncatedTailError{Offset: good}
+		}
+		if err != nil {
+			return nil, err
+		}
+		good = r.count.n
+	}
+
+	w = NewWriter(f, append(opts[:len(opts):len(opts)], WithAppendOnly())...)
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	w.guard = uint8(r.index)
+	w.chain = r.chain
+	w.prevCRC = r.prevCRC
+	return w, nil
+}
@@ -0,0 +1,130 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendOnly(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "append.seq")
+
+	f, err := os.Create(filename)
+	require.NoError(t, err)
+	w := NewWriter(f)
+	for i := 0; i < 3; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Close())
+
+	// reopen positioned at the start of the file
+	f, err = os.OpenFile(filename, os.O_RDWR, 0)
+	require.NoError(t, err)
+	w = NewWriter(f, WithAppendOnly())
+	for i := 3; i < 6; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+	require.ErrorIs(t, w.UpdateRecordAt(0, []byte("RECORD 0")), ErrNotUpdatable)
+	require.NoError(t, f.Close())
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	r := NewReader(bytes.NewReader(data))
+	readBuffer := make([]byte, 100)
+	for i := 0; i < 6; i++ {
+		n, err := r.Read(readBuffer)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(readBuffer[:n]))
+	}
+	_, err = r.Read(readBuffer)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestOpenForAppend(t *testing.T) {
+	opts := []Option{WithStreamGuard(), WithPrevFrameCRC(), WithHeader()}
+
+	filename := filepath.Join(t.TempDir(), "open.seq")
+	f, err := os.Create(filename)
+	require.NoError(t, err)
+
+	// an empty file gets a header
+	w, err := OpenForAppend(f, opts...)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	f, err = os.OpenFile(filename, os.O_RDWR, 0)
+	require.NoError(t, err)
+	w, err = OpenForAppend(f, opts...)
+	require.NoError(t, err)
+	for i := 3; i < 6; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	// the appended records continue the guard and frame CRC chain
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	r := NewReader(bytes.NewReader(data), opts...)
+	for i := 0; i < 6; i++ {
+		rec, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(rec))
+	}
+	_, err = r.ReadRecord()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestOpenForAppendTruncatedTail(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithIndex())
+	for i := 0; i < 3; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+	last := w.Offsets()[2]
+
+	for _, size := range []int64{
+		last + 2, // within the length prefix
+		last + 6, // within the payload
+	} {
+		filename := filepath.Join(t.TempDir(), "truncated.seq")
+		require.NoError(t, os.WriteFile(filename, buf.Bytes()[:size], 0o644))
+
+		f, err := os.OpenFile(filename, os.O_RDWR, 0)
+		require.NoError(t, err)
+		_, err = OpenForAppend(f)
+		require.ErrorIs(t, err, ErrTruncatedTail)
+
+		var tail *TruncatedTailError
+		require.ErrorAs(t, err, &tail)
+		require.Equal(t, last, tail.Offset)
+
+		// after truncating the file can be appended to
+		require.NoError(t, f.Truncate(tail.Offset))
+		w, err := OpenForAppend(f)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("record 3"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		data, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		records, _, err := Decode(data)
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		require.Equal(t, "record 3", string(records[2]))
+	}
+}
@@ -0,0 +1,53 @@
+package recio
+
+import "io"
+
+// ArenaWriter frames records into a growing in-memory buffer so that a whole
+// stream can be built up front and published with a single write, for
+// instance when uploading to object storage.
+type ArenaWriter struct {
+	buf appendWriter
+	w   *Writer
+}
+
+// NewArenaWriter returns an empty ArenaWriter that frames records as a
+// Writer created with opts does. If sizeHint is positive the arena is
+// preallocated to hold sizeHint bytes.
+func NewArenaWriter(sizeHint int, opts ...Option) *ArenaWriter {
+	a := &ArenaWriter{}
+	if sizeHint > 0 {
+		a.buf.b = make([]byte, 0, sizeHint)
+	}
+	a.w = NewWriter(&a.buf, opts...)
+	return a
+}
+
+// Write appends p to the arena as a single record. Like Writer.Write it
+// returns ErrRecordTooLarge for records that don't fit in the length prefix.
+func (a *ArenaWriter) Write(p []byte) (int, error) {
+	return a.w.Write(p)
+}
+
+// Close appends the footers of WithCountFooter and WithFooterChecksum, which
+// complete the stream, after which nothing more can be written.
+func (a *ArenaWriter) Close() error {
+	return a.w.Close()
+}
+
+// Bytes returns the framed stream built so far. The slice aliases the arena
+// and is only valid until the next Write.
+func (a *ArenaWriter) Bytes() []byte {
+	a.w.Flush()
+	return a.buf.b
+}
+
+// Len returns the number of bytes in the arena.
+func (a *ArenaWriter) Len() int {
+	return len(a.Bytes())
+}
+
+// WriteTo writes the contents of the arena to w in a single call.
+func (a *ArenaWriter) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(a.Bytes())
+	return int64(n), err
+}
@@ -0,0 +1,72 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArenaWriter(t *testing.T) {
+	a := NewArenaWriter(1024)
+	require.Zero(t, a.Len())
+	require.Equal(t, 1024, cap(a.Bytes()))
+
+	for i := 0; i < 100; i++ {
+		n, err := a.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+		require.Equal(t, len(fmt.Sprintf("record %d", i)), n)
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	n, err := a.WriteTo(buf)
+	require.NoError(t, err)
+	require.Equal(t, int64(a.Len()), n)
+	require.Equal(t, a.Bytes(), buf.Bytes())
+
+	// the arena output is identical to what the streaming writer produces
+	expect := bytes.NewBuffer([]byte{})
+	w := NewWriter(expect)
+	for i := 0; i < 100; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+	require.Equal(t, expect.Bytes(), buf.Bytes())
+
+	r := NewReader(buf)
+	readBuffer := make([]byte, 100)
+	for i := 0; ; i++ {
+		n, err := r.Read(readBuffer)
+		if err == io.EOF {
+			require.Equal(t, 100, i)
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(readBuffer[:n]))
+	}
+}
+
+func TestArenaWriterOptions(t *testing.T) {
+	opts := []Option{WithStreamGuard(), WithChecksum(), WithCountFooter(), WithWriteBuffer(16)}
+
+	// options that carry state from record to record see the whole arena
+	// as one stream
+	a := NewArenaWriter(0, opts...)
+	expect := bytes.NewBuffer([]byte{})
+	w := NewWriter(expect, opts...)
+	for i := 0; i < 10; i++ {
+		_, err := a.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+		_, err = w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, a.Close())
+	require.NoError(t, w.Close())
+	require.Equal(t, expect.Bytes(), a.Bytes())
+
+	count, err := RecordCount(bytes.NewReader(a.Bytes()), int64(a.Len()))
+	require.NoError(t, err)
+	require.EqualValues(t, 10, count)
+}
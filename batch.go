@@ -0,0 +1,87 @@
+package recio
+
+// batchState is the writer state after a record of a batch.
+type batchState struct {
+	end     int
+	guard   uint8
+	chain   [merkleHashSize]byte
+	prevCRC uint32
+}
+
+// WriteBatch writes records with a single write to the underlying writer
+// and returns the number of payload bytes written.
+//
+// If the write fails part way through, the records that made it to the
+// underlying writer in full count as written: the returned byte count and
+// Stats cover only those, and the writer carries on after the last of them.
+// Whatever part of the next frame was written is left in the stream, as
+// with Write, unless the underlying writer can truncate it away, in which
+// case the whole batch is. If a record is too large or fails to compress
+// nothing is written.
+func (w *Writer) WriteBatch(records [][]byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	saved := batchState{guard: w.guard, chain: w.chain, prevCRC: w.prevCRC}
+	states := make([]batchState, 0, len(records))
+
+	w.frame = w.frame[:0]
+	for _, p := range records {
+		var err error
+		if w.opts.extendedHeader {
+			var payload []byte
+			payload, err = w.encodePayload(p)
+			if err == nil {
+				w.frame = appendExtFrame(w.frame, 0, 0, payload)
+				w.frame = w.appendPadding(w.frame, w.offset)
+			}
+		} else {
+			err = w.appendRecord(p)
+		}
+		if err != nil {
+			w.restoreBatch(saved)
+			return 0, err
+		}
+		states = append(states, batchState{end: len(w.frame), guard: w.guard, chain: w.chain, prevCRC: w.prevCRC})
+	}
+
+	n, err := w.writeFrame(w.frame)
+
+	written := len(records)
+	if err != nil {
+		written = 0
+		for written < len(states) && states[written].end <= n {
+			written++
+		}
+	}
+
+	if written == 0 {
+		w.restoreBatch(saved)
+	} else {
+		w.restoreBatch(states[written-1])
+	}
+
+	total := 0
+	start := 0
+	for i := 0; i < written; i++ {
+		rerr := w.recordWritten(records[i], states[i].end-start)
+		if rerr != nil && err == nil {
+			err = rerr
+		}
+		total += len(records[i])
+		start = states[i].end
+	}
+
+	if err != nil {
+		return total, w.flushOnError(err)
+	}
+	return total, nil
+}
+
+// restoreBatch sets the per-record writer state to s.
+func (w *Writer) restoreBatch(s batchState) {
+	w.guard = s.guard
+	w.chain = s.chain
+	w.prevCRC = s.prevCRC
+}
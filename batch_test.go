@@ -0,0 +1,154 @@
+package recio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteBatch(t *testing.T) {
+	records := [][]byte{
+		[]byte("first"),
+		{},
+		[]byte("third record"),
+		bytes.Repeat([]byte{0xab}, 300),
+	}
+
+	for _, opts := range [][]Option{
+		nil,
+		{WithChecksum()},
+		{WithMerkleChain()},
+		{WithPrevFrameCRC()},
+		{WithVarintLength()},
+		{WithExtendedHeader()},
+	} {
+		var buf bytes.Buffer
+		counter := &writeCounter{writer: &buf}
+		w := NewWriter(counter, opts...)
+
+		n, err := w.WriteBatch(records)
+		require.NoError(t, err)
+		require.Equal(t, 5+12+300, n)
+		require.Equal(t, 1, counter.writes)
+
+		// a batch followed by a plain write continues the stream
+		_, err = w.Write([]byte("after"))
+		require.NoError(t, err)
+		require.EqualValues(t, len(records)+1, w.Stats().RecordsWritten)
+
+		r := NewReader(&buf, opts...)
+		for _, want := range append(records, []byte("after")) {
+			got, err := r.ReadRecord()
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+		}
+		_, err = r.ReadRecord()
+		require.ErrorIs(t, err, io.EOF)
+	}
+}
+
+// shortWriter writes at most limit bytes of every write.
+type shortWriter struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (s *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > s.limit {
+		s.buf.Write(p[:s.limit])
+		return s.limit, io.ErrShortWrite
+	}
+	return s.buf.Write(p)
+}
+
+func TestWriteBatchPartial(t *testing.T) {
+	// room for two whole frames of 4 byte length prefix plus 5 byte payload
+	// and part of a third
+	sink := &shortWriter{limit: 2*9 + 3}
+	w := NewWriter(sink)
+
+	n, err := w.WriteBatch([][]byte{[]byte("aaaaa"), []byte("bbbbb"), []byte("ccccc")})
+	require.Error(t, err)
+	require.Equal(t, 10, n)
+	require.EqualValues(t, 2, w.Stats().RecordsWritten)
+
+	r := NewReader(&sink.buf)
+	for _, want := range []string{"aaaaa", "bbbbb"} {
+		got, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.Equal(t, want, string(got))
+	}
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+type failingCodec struct{}
+
+func (failingCodec) ID() uint8 { return 0x7f }
+
+func (failingCodec) Compress(p []byte) ([]byte, error) {
+	if len(p) == 0 {
+		return nil, errors.New("nothing to compress")
+	}
+	return p, nil
+}
+
+func (failingCodec) Decompress(p []byte) ([]byte, error) { return p, nil }
+
+func TestWriteBatchEncodeError(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithCodec(failingCodec{}), WithStreamGuard())
+
+	n, err := w.WriteBatch([][]byte{[]byte("ok"), {}})
+	require.Error(t, err)
+	require.Zero(t, n)
+	require.Zero(t, buf.Len())
+
+	// the guard was not advanced by the failed batch
+	_, err = w.Write([]byte("next"))
+	require.NoError(t, err)
+
+	r := NewReader(&buf, WithCodec(failingCodec{}), WithStreamGuard())
+	got, err := r.ReadRecord()
+	require.NoError(t, err)
+	require.Equal(t, "next", string(got))
+}
+
+func BenchmarkWriteBatch(b *testing.B) {
+	const batchSize = 64
+
+	records := make([][]byte, batchSize)
+	for i := range records {
+		records[i] = []byte(fmt.Sprintf("record %d with some payload", i))
+	}
+
+	b.Run("batch", func(b *testing.B) {
+		counter := &writeCounter{writer: io.Discard}
+		w := NewWriter(counter)
+		for i := 0; i < b.N; i++ {
+			_, err := w.WriteBatch(records)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(counter.writes)/float64(b.N), "writes/batch")
+	})
+
+	b.Run("individual", func(b *testing.B) {
+		counter := &writeCounter{writer: io.Discard}
+		w := NewWriter(counter)
+		for i := 0; i < b.N; i++ {
+			for _, p := range records {
+				_, err := w.Write(p)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.ReportMetric(float64(counter.writes)/float64(b.N), "writes/batch")
+	})
+}
@@ -0,0 +1,223 @@
+package recio
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+var (
+	ErrAbandonedRecord    = errors.New("record was begun but never committed")
+	ErrRecordInProgress   = errors.New("a record begun by BeginRecord has not been committed")
+	ErrNoRecordInProgress = errors.New("no record has been begun")
+	ErrBeginUnsupported   = errors.New("BeginRecord is not supported by the writer or its options")
+)
+
+// openRecord is a record begun by BeginRecord. It is the io.Writer that
+// its payload is written to.
+type openRecord struct {
+	w *Writer
+
+	// start is where the frame starts in the underlying writer and
+	// prefix where its length prefix does
+	start, prefix int64
+
+	// written counts the bytes of the frame written so far, n those of
+	// the payload
+	written int
+	n       int
+
+	checksum hash.Hash32
+}
+
+// BeginRecord starts a record whose payload is streamed to the returned
+// io.Writer, for payloads that are too large to hold in memory and whose
+// length is not known in advance, and CommitRecord ends it. No other record
+// can be written in between.
+//
+// Until the record is committed its length prefix holds a placeholder, so
+// if it never is, for example because the process crashes, readers return
+// ErrAbandonedRecord on reaching it instead of reading a corrupt record.
+// OpenForAppend reports an abandoned record at the end of a file as a
+// truncated tail. AbortRecord abandons a record on purpose.
+//
+// The length prefix is filled in by seeking back to it, so the underlying
+// writer must be an io.WriteSeeker and the prefix must have a fixed size.
+// Options that need all of the payload before the frame can be written are
+// not supported either. For WithVarintLength, WithCodec, WithAEAD,
+// WithChunking, WithExtendedHeader, WithPrevFrameCRC, WithCrashRing and
+// WithFooterChecksum, BeginRecord returns ErrBeginUnsupported.
+func (w *Writer) BeginRecord() (io.Writer, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	seeker, ok := w.dst.(io.Seeker)
+	o := &w.opts
+	if !ok || o.varintLength || o.transformsPayload() || o.chunkSize > 0 || o.extendedHeader ||
+		o.prevFrameCRC || w.ring != nil || w.footer != nil {
+		return nil, ErrBeginUnsupported
+	}
+
+	// buffered records have to be written out to know where the frame starts
+	err := w.Flush()
+	if err != nil {
+		return nil, err
+	}
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	// the placeholder is the checksum flag on its own, which is never a
+	// valid length prefix: it either has a checksum flag where none is
+	// expected or no room for the checksum
+	var bodyStart int
+	w.frame, bodyStart = w.appendFrameHead(w.frame[:0], 0)
+	prefix := bodyStart - o.lengthSize
+	var placeholder [8]byte
+	copy(w.frame[prefix:bodyStart], appendLength(placeholder[:0], o.byteOrder, o.lengthSize, o.checksumFlag()))
+
+	rec := &openRecord{w: w, start: start, prefix: start + int64(prefix)}
+	if o.checksum {
+		rec.checksum = crc32.New(castagnoli)
+		rec.checksum.Write(w.frame[bodyStart:])
+	}
+	if o.merkleChain {
+		w.beginChainHash()
+	}
+
+	rec.written, err = w.writeFrame(w.frame)
+	if err != nil {
+		return nil, w.flushOnError(err)
+	}
+	w.open = rec
+	w.err = ErrRecordInProgress
+	return rec, nil
+}
+
+func (rec *openRecord) Write(p []byte) (int, error) {
+	w := rec.w
+	if w.open != rec {
+		return 0, ErrNoRecordInProgress
+	}
+
+	n, err := w.writeFrame(p)
+	rec.written += n
+	rec.n += n
+	if rec.checksum != nil {
+		rec.checksum.Write(p[:n])
+	}
+	if w.opts.merkleChain {
+		w.hasher.Write(p[:n])
+	}
+	return n, err
+}
+
+// CommitRecord ends the record begun by BeginRecord, filling in its length
+// prefix. If it fails the stream ends in a partial frame, and every later
+// write fails as well.
+func (w *Writer) CommitRecord() error {
+	rec := w.open
+	if rec == nil {
+		return ErrNoRecordInProgress
+	}
+	w.open = nil
+	w.err = nil
+
+	err := w.commit(rec)
+	if err != nil {
+		w.err = err
+		return w.flushOnError(err)
+	}
+	return nil
+}
+
+// commit writes what follows the payload of rec and fills in its length
+// prefix.
+func (w *Writer) commit(rec *openRecord) error {
+	length := w.opts.frameLength(rec.n)
+	if length > w.opts.maxLength() {
+		return w.observeError(ErrRecordTooLarge)
+	}
+	if w.opts.checksum {
+		length |= w.opts.checksumFlag()
+	}
+
+	var chain [merkleHashSize]byte
+	w.frame = w.frame[:0]
+	if w.opts.merkleChain {
+		w.hasher.Sum(chain[:0])
+		w.frame = append(w.frame, chain[:]...)
+	}
+	if w.opts.checksum {
+		rec.checksum.Write(w.frame)
+		w.frame = binary.LittleEndian.AppendUint32(w.frame, rec.checksum.Sum32())
+	}
+	w.frame = w.appendPadding(w.frame, w.offset+int64(rec.written))
+
+	n, err := w.writeFrame(w.frame)
+	rec.written += n
+	if err != nil {
+		return err
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	// fill in the length prefix, straight to the underlying writer
+	seeker := w.dst.(io.Seeker)
+	_, err = seeker.Seek(rec.prefix, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	var prefix [8]byte
+	_, err = w.dst.Write(appendLength(prefix[:0], w.opts.byteOrder, w.opts.lengthSize, length))
+	if err != nil {
+		return err
+	}
+	_, err = seeker.Seek(rec.start+int64(rec.written), io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	w.guard++
+	w.chain = chain
+	return w.frameWritten(rec.n, rec.written)
+}
+
+// AbortRecord abandons the record begun by BeginRecord. If the underlying
+// writer has a Truncate method, like *os.File, the partial frame is
+// truncated away. Otherwise it is left in the stream, where readers return
+// ErrAbandonedRecord on reaching it; with WithSyncMarkers, Resync skips it.
+func (w *Writer) AbortRecord() error {
+	rec := w.open
+	if rec == nil {
+		return ErrNoRecordInProgress
+	}
+	w.open = nil
+	w.err = nil
+
+	err := w.Flush()
+	if err != nil {
+		return err
+	}
+
+	t, ok := w.dst.(truncater)
+	if !ok {
+		// the frame stays, so later frames start after it
+		w.offset += int64(rec.written)
+		w.guard++
+		return nil
+	}
+
+	err = t.Truncate(rec.start)
+	if err != nil {
+		return err
+	}
+	_, err = w.dst.(io.Seeker).Seek(rec.start, io.SeekStart)
+	return err
+}
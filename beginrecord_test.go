@@ -0,0 +1,170 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createTemp(t *testing.T) *os.File {
+	f, err := os.Create(filepath.Join(t.TempDir(), "data.seq"))
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestBeginRecord(t *testing.T) {
+	large := bytes.Repeat([]byte("0123456789abcdef"), 1<<16)
+
+	for _, opts := range [][]Option{
+		nil,
+		{WithChecksum(), WithMerkleChain(), WithStreamGuard(), WithSyncMarkers()},
+		{WithLengthFieldSize(8), WithAlignment(16), WithWriteBuffer(4096), WithSchemaVersion(2)},
+	} {
+		f := createTemp(t)
+		w := NewWriter(f, opts...)
+		_, err := w.WriteString("before")
+		require.NoError(t, err)
+
+		rw, err := w.BeginRecord()
+		require.NoError(t, err)
+		for p := large; len(p) > 0; {
+			n, err := rw.Write(p[:min(1000, len(p))])
+			require.NoError(t, err)
+			p = p[n:]
+		}
+		require.NoError(t, w.CommitRecord())
+
+		_, err = w.WriteString("after")
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		// the stream is the same as if the record had been written at once
+		var want bytes.Buffer
+		ww := NewWriter(&want, opts...)
+		for _, rec := range [][]byte{[]byte("before"), large, []byte("after")} {
+			_, err := ww.Write(rec)
+			require.NoError(t, err)
+		}
+		require.NoError(t, ww.Flush())
+
+		data, err := os.ReadFile(f.Name())
+		require.NoError(t, err)
+		require.Equal(t, want.Bytes(), data)
+		require.Equal(t, ww.Stats(), w.Stats())
+	}
+}
+
+func TestBeginRecordCrash(t *testing.T) {
+	f := createTemp(t)
+	w := NewWriter(f)
+	_, err := w.WriteString("before")
+	require.NoError(t, err)
+	rw, err := w.BeginRecord()
+	require.NoError(t, err)
+	_, err = rw.Write([]byte("never committed"))
+	require.NoError(t, err)
+
+	// readers stop at the record instead of trusting its length
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	r := NewReader(f)
+	_, err = r.ReadRecord()
+	require.NoError(t, err)
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, ErrAbandonedRecord)
+
+	// and OpenForAppend finds where to cut it off
+	_, err = OpenForAppend(f)
+	var tail *TruncatedTailError
+	require.ErrorAs(t, err, &tail)
+	require.NoError(t, f.Truncate(tail.Offset))
+
+	w, err = OpenForAppend(f)
+	require.NoError(t, err)
+	_, err = w.WriteString("next")
+	require.NoError(t, err)
+
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	require.Equal(t, []string{"before", "next"}, readAll(t, NewReader(f)))
+}
+
+func TestAbortRecord(t *testing.T) {
+	// the partial frame is truncated away
+	f := createTemp(t)
+	w := NewWriter(f, WithChecksum())
+	_, err := w.WriteString("before")
+	require.NoError(t, err)
+	rw, err := w.BeginRecord()
+	require.NoError(t, err)
+	_, err = rw.Write([]byte("abandoned"))
+	require.NoError(t, err)
+	require.NoError(t, w.AbortRecord())
+
+	_, err = rw.Write([]byte("too late"))
+	require.ErrorIs(t, err, ErrNoRecordInProgress)
+	_, err = w.WriteString("next")
+	require.NoError(t, err)
+
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	require.Equal(t, []string{"before", "next"}, readAll(t, NewReader(f, WithChecksum())))
+}
+
+func TestAbortRecordNotTruncatable(t *testing.T) {
+	// without Truncate the frame stays, and readers resync past it
+	f := createTemp(t)
+	w := NewWriter(struct{ io.WriteSeeker }{f}, WithSyncMarkers())
+	_, err := w.WriteString("before")
+	require.NoError(t, err)
+	rw, err := w.BeginRecord()
+	require.NoError(t, err)
+	_, err = rw.Write([]byte("abandoned"))
+	require.NoError(t, err)
+	require.NoError(t, w.AbortRecord())
+	_, err = w.WriteString("next")
+	require.NoError(t, err)
+
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	r := NewReader(f, WithSyncMarkers())
+	rec, err := r.ReadRecord()
+	require.NoError(t, err)
+	require.Equal(t, "before", string(rec))
+
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, ErrAbandonedRecord)
+	_, err = r.Resync()
+	require.NoError(t, err)
+	rec, err = r.ReadRecord()
+	require.NoError(t, err)
+	require.Equal(t, "next", string(rec))
+}
+
+func TestBeginRecordErrors(t *testing.T) {
+	_, err := NewWriter(&bytes.Buffer{}).BeginRecord()
+	require.ErrorIs(t, err, ErrBeginUnsupported)
+	_, err = NewWriter(createTemp(t), WithVarintLength()).BeginRecord()
+	require.ErrorIs(t, err, ErrBeginUnsupported)
+
+	w := NewWriter(createTemp(t))
+	require.ErrorIs(t, w.CommitRecord(), ErrNoRecordInProgress)
+	require.ErrorIs(t, w.AbortRecord(), ErrNoRecordInProgress)
+
+	// nothing else can be written while a record is open
+	_, err = w.BeginRecord()
+	require.NoError(t, err)
+	_, err = w.WriteString("record")
+	require.ErrorIs(t, err, ErrRecordInProgress)
+	_, err = w.BeginRecord()
+	require.ErrorIs(t, err, ErrRecordInProgress)
+	require.NoError(t, w.CommitRecord())
+
+	_, err = w.WriteString("record")
+	require.NoError(t, err)
+}
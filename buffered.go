@@ -0,0 +1,155 @@
+package recio
+
+import (
+	"encoding/binary"
+)
+
+// Buffered returns the number of bytes that have been read from the
+// underlying reader but not yet consumed. It is always 0 without
+// WithReadBuffer, save for what a read that timed out with WithReadTimeout
+// left to be read again.
+func (r *Reader) Buffered() int {
+	n := 0
+	if r.replay != nil {
+		n = r.replay.pending()
+	}
+	if r.br == nil {
+		return n
+	}
+	return n + r.br.Buffered()
+}
+
+// HasRecord reports whether the next record is in the read buffer in full,
+// so that reading it does not have to wait for the underlying reader. It
+// does not consume anything. It reports false without WithReadBuffer, and
+// when the buffered bytes do not frame a record, in which case reading fails.
+func (r *Reader) HasRecord() bool {
+	// a record that is read again after a timeout is not looked into
+	if r.br == nil || (r.replay != nil && r.replay.pending() > 0) {
+		return false
+	}
+
+	// Peek does not read from the underlying reader for what is buffered
+	b, _ := r.br.Peek(r.br.Buffered())
+
+	// the rest of a record that is being read comes first
+	pos := uint64(0)
+	if r.current != nil {
+		pos = uint64(r.current.payload.N) + r.opts.trailerSize()
+	}
+	if r.hasPending {
+		pos = r.pending + r.opts.trailerSize()
+		if !r.continued || r.opts.chunkSize == 0 {
+			return pos <= uint64(len(b))
+		}
+	}
+	if r.opts.header && !r.headerDone {
+		pos = uint64(headerSize)
+	}
+
+	markerSeen := r.markerSeen
+	for pos <= uint64(len(b)) {
+		end, continued, ok := r.opts.frameEnd(b, pos, r.count.n, markerSeen)
+		if !ok {
+			return false
+		}
+		if !continued {
+			return end <= uint64(len(b))
+		}
+		pos = end
+		markerSeen = false
+	}
+	return false
+}
+
+// trailerSize returns the number of bytes that follow the payload in a
+// frame.
+func (o *options) trailerSize() uint64 {
+	size := uint64(0)
+	if o.merkleChain {
+		size += merkleHashSize
+	}
+	if o.checksum {
+		size += checksumSize
+	}
+	return size
+}
+
+// frameEnd returns where the frame that starts at pos in b ends, and
+// whether it is followed by another chunk of the same record. The offset of
+// b in the stream is offset. It reports false if b ends before the length
+// prefix does.
+func (o *options) frameEnd(b []byte, pos uint64, offset int64, markerSeen bool) (uint64, bool, bool) {
+	if o.alignment > 1 && !markerSeen {
+		n := uint64(o.alignment)
+		pos += (n - (uint64(offset)+pos)%n) % n
+	}
+	if o.syncMarkers && !markerSeen {
+		pos += uint64(len(syncMarker))
+	}
+	if pos > uint64(len(b)) {
+		return 0, false, false
+	}
+
+	if o.extendedHeader {
+		length, n := binary.Uvarint(b[pos:])
+		if n <= 0 {
+			return 0, false, false
+		}
+		pos += uint64(n)
+		_, n = binary.Uvarint(b[pos:])
+		if n <= 0 {
+			return 0, false, false
+		}
+		if length > uint64(len(b)) {
+			return 0, false, false
+		}
+		return pos + uint64(n) + 1 + length, false, true
+	}
+
+	if o.streamGuard {
+		pos++
+	}
+	if pos > uint64(len(b)) {
+		return 0, false, false
+	}
+
+	var declared uint64
+	if o.varintLength {
+		var n int
+		declared, n = binary.Uvarint(b[pos:])
+		if n <= 0 {
+			return 0, false, false
+		}
+		pos += uint64(n)
+	} else {
+		size := uint64(o.lengthSize)
+		if !o.validLengthSize() || pos+size > uint64(len(b)) {
+			return 0, false, false
+		}
+		declared = decodeLength(o.byteOrder, b[pos:pos+size])
+		pos += size
+	}
+	length := declared &^ o.checksumFlag()
+	if length > uint64(len(b)) {
+		return 0, false, false
+	}
+	end := pos + length
+
+	if o.chunkSize == 0 {
+		return end, false, true
+	}
+
+	// the chunk flag follows the previous frame's CRC and the type tag
+	flag := pos
+	if o.prevFrameCRC {
+		flag += 4
+	}
+	if o.typeTag {
+		flag++
+	}
+	if flag >= uint64(len(b)) || flag >= end {
+		return 0, false, false
+	}
+	return end, b[flag] == chunkContinued, true
+}
@@ -0,0 +1,118 @@
+package recio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// pieceReader returns one piece per Read.
+type pieceReader struct {
+	pieces [][]byte
+}
+
+func (p *pieceReader) Read(b []byte) (int, error) {
+	if len(p.pieces) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, p.pieces[0])
+	p.pieces[0] = p.pieces[0][n:]
+	if len(p.pieces[0]) == 0 {
+		p.pieces = p.pieces[1:]
+	}
+	return n, nil
+}
+
+func TestHasRecord(t *testing.T) {
+	for i, opts := range [][]Option{
+		nil,
+		{WithHeader(), WithChecksum()},
+		{WithStreamGuard(), WithSyncMarkers()},
+		{WithVarintLength(), WithMerkleChain()},
+		{WithLengthFieldSize(2), WithByteOrder(binary.BigEndian)},
+		{WithAlignment(8), WithPrevFrameCRC()},
+		{WithChunking(4), WithTypeTag()},
+		{WithExtendedHeader()},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, opts...)
+		var ends []int
+		for _, rec := range []string{"first", "a second record", "third"} {
+			_, err := w.WriteString(rec)
+			require.NoError(t, err)
+			ends = append(ends, buf.Len())
+		}
+		data := buf.Bytes()
+
+		// the padding after the second frame is read along with the third
+		last := ends[1]
+		if newOptions(opts).alignment > 1 {
+			for data[last-1] == 0 {
+				last--
+			}
+		}
+
+		// split the stream anywhere in the second frame
+		for split := ends[0]; split < last; split++ {
+			src := &pieceReader{pieces: [][]byte{data[:split], data[split:]}}
+			r := NewReader(src, append(opts, WithReadBuffer(1024))...)
+
+			_, err := r.ReadRecord()
+			require.NoError(t, err)
+			// alignment padding is only consumed along with the next frame
+			require.Equal(t, split-int(r.count.n), r.Buffered())
+			require.False(t, r.HasRecord(), "options %d, split at %d", i, split)
+
+			rec, err := r.ReadRecord()
+			require.NoError(t, err)
+			require.Equal(t, "a second record", string(rec))
+			require.Equal(t, len(data)-int(r.count.n), r.Buffered())
+			require.True(t, r.HasRecord(), "split at %d", split)
+			require.True(t, r.HasRecord(), "split at %d", split)
+
+			_, err = r.PeekLength()
+			require.NoError(t, err)
+			require.True(t, r.HasRecord(), "split at %d", split)
+
+			rec, err = r.ReadRecord()
+			require.NoError(t, err)
+			require.Equal(t, "third", string(rec))
+			require.False(t, r.HasRecord())
+			require.Equal(t, len(data)-int(r.count.n), r.Buffered())
+		}
+	}
+}
+
+func TestHasRecordUnbuffered(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := NewWriter(&buf).WriteString("record")
+	require.NoError(t, err)
+
+	r := NewReader(&buf)
+	require.Zero(t, r.Buffered())
+	require.False(t, r.HasRecord())
+}
+
+func TestHasRecordNextReader(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithChecksum())
+	for _, rec := range []string{"first", "second"} {
+		_, err := w.WriteString(rec)
+		require.NoError(t, err)
+	}
+	data := buf.Bytes()
+
+	// the rest of the record being read comes before the next one
+	for _, buffered := range []int{len(data) - 1, len(data)} {
+		src := &pieceReader{pieces: [][]byte{data[:buffered], data[buffered:]}}
+		r := NewReader(src, WithChecksum(), WithReadBuffer(1024))
+		rr, err := r.NextReader()
+		require.NoError(t, err)
+		_, err = rr.Read(make([]byte, 2))
+		require.NoError(t, err)
+		require.Equal(t, buffered == len(data), r.HasRecord())
+	}
+}
@@ -0,0 +1,62 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// CBORWriter writes values of type T as CBOR encoded records.
+type CBORWriter[T any] struct {
+	writer  *Writer
+	buf     bytes.Buffer
+	encoder *cbor.Encoder
+}
+
+// CBORReader reads CBOR encoded records as values of type T.
+type CBORReader[T any] struct {
+	reader *Reader
+}
+
+// NewCBORWriter returns a CBORWriter writing records to w.
+func NewCBORWriter[T any](w io.Writer, opts ...Option) *CBORWriter[T] {
+	cw := &CBORWriter[T]{
+		writer: NewWriter(w, opts...),
+	}
+	cw.encoder = cbor.NewEncoder(&cw.buf)
+	return cw
+}
+
+// WriteValue encodes v as CBOR and writes it as a single record.
+func (w *CBORWriter[T]) WriteValue(v T) error {
+	w.buf.Reset()
+
+	err := w.encoder.Encode(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.writer.Write(w.buf.Bytes())
+	return err
+}
+
+// NewCBORReader returns a CBORReader reading records from r.
+func NewCBORReader[T any](r io.Reader, opts ...Option) *CBORReader[T] {
+	return &CBORReader[T]{
+		reader: NewReader(r, opts...),
+	}
+}
+
+// ReadValue reads the next record and decodes it as CBOR.
+func (r *CBORReader[T]) ReadValue() (T, error) {
+	var v T
+
+	body, err := r.reader.readBuffered()
+	if err != nil {
+		return v, err
+	}
+
+	err = cbor.Unmarshal(body, &v)
+	return v, err
+}
@@ -0,0 +1,52 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type sensorReading struct {
+	ID    int     `cbor:"id"`
+	Name  string  `cbor:"name"`
+	Value float64 `cbor:"value"`
+}
+
+func TestCBORRoundTrip(t *testing.T) {
+	readings := []sensorReading{
+		{ID: 1, Name: "temperature", Value: 21.5},
+		{ID: 2, Name: "humidity", Value: 40},
+		{ID: 3, Name: "pressure", Value: 1013.25},
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	w := NewCBORWriter[sensorReading](buf)
+	for _, reading := range readings {
+		require.NoError(t, w.WriteValue(reading))
+	}
+
+	r := NewCBORReader[sensorReading](bytes.NewReader(buf.Bytes()))
+	for _, expected := range readings {
+		reading, err := r.ReadValue()
+		require.NoError(t, err)
+		require.Equal(t, expected, reading)
+	}
+
+	_, err := r.ReadValue()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestCBORInterop(t *testing.T) {
+	// {"id": 7, "name": "x"} encoded by hand
+	raw := []byte{0xa2, 0x62, 'i', 'd', 0x07, 0x64, 'n', 'a', 'm', 'e', 0x61, 'x'}
+
+	buf := bytes.NewBuffer([]byte{})
+	_, err := NewWriter(buf).Write(raw)
+	require.NoError(t, err)
+
+	reading, err := NewCBORReader[sensorReading](buf).ReadValue()
+	require.NoError(t, err)
+	require.Equal(t, sensorReading{ID: 7, Name: "x"}, reading)
+}
@@ -0,0 +1,73 @@
+package recio
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// channelFlushInterval is how often WriteFromChannel flushes buffered
+// records while records keep coming.
+const channelFlushInterval = time.Second
+
+// WriteFromChannel writes every record received from ch until ch is closed,
+// and then flushes the writer and returns nil. Records are flushed at least
+// once a second, and whenever ch has no record ready, so they don't linger
+// in a buffer while producers are quiet. If ctx is done first, the records
+// written so far are flushed and ctx.Err() is returned; records still in ch
+// are left there. The first error writing or flushing a record stops the
+// loop and is returned.
+func (w *Writer) WriteFromChannel(ctx context.Context, ch <-chan []byte) error {
+	ticker := time.NewTicker(channelFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		var p []byte
+		var ok bool
+
+		// flush while waiting rather than between records that are ready
+		select {
+		case p, ok = <-ch:
+		default:
+			err := w.Flush()
+			if err != nil {
+				return err
+			}
+
+			select {
+			case p, ok = <-ch:
+			case <-ctx.Done():
+				return w.flushCancelled(ctx)
+			}
+		}
+		if !ok {
+			return w.Flush()
+		}
+
+		_, err := w.Write(p)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+			err := w.Flush()
+			if err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return w.flushCancelled(ctx)
+		default:
+		}
+	}
+}
+
+// flushCancelled flushes the writer once ctx is done and returns the context
+// error, along with the error flushing if there is one.
+func (w *Writer) flushCancelled(ctx context.Context) error {
+	err := w.Flush()
+	if err != nil {
+		return errors.Join(ctx.Err(), err)
+	}
+	return ctx.Err()
+}
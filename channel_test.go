@@ -0,0 +1,94 @@
+package recio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFromChannel(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriterSize(&buf, 4096)
+
+	ch := make(chan []byte)
+	go func() {
+		for i := 0; i < 100; i++ {
+			ch <- []byte(fmt.Sprintf("record %d", i))
+		}
+		close(ch)
+	}()
+
+	err := w.WriteFromChannel(context.Background(), ch)
+	require.NoError(t, err)
+
+	// everything has been flushed
+	count, err := CountRecords(&buf)
+	require.NoError(t, err)
+	require.Equal(t, 100, count)
+}
+
+func TestWriteFromChannelCancel(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriterSize(&buf, 4096)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch := make(chan []byte, 10)
+	for i := 0; i < 10; i++ {
+		ch <- []byte(fmt.Sprintf("record %d", i))
+	}
+	done := make(chan error)
+	go func() { done <- w.WriteFromChannel(ctx, ch) }()
+
+	// the channel stays open, so only the cancellation ends the loop
+	require.Eventually(t, func() bool { return len(ch) == 0 }, time.Second, time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+
+	// the records written before the cancellation have been flushed
+	count, err := CountRecords(&buf)
+	require.NoError(t, err)
+	require.Equal(t, 10, count)
+}
+
+type failAfterWriter struct {
+	writes int
+}
+
+var errInjected = errors.New("injected write error")
+
+func (f *failAfterWriter) Write(p []byte) (int, error) {
+	if f.writes == 0 {
+		return 0, errInjected
+	}
+	f.writes--
+	return len(p), nil
+}
+
+func TestWriteFromChannelWriteError(t *testing.T) {
+	w := NewWriter(&failAfterWriter{writes: 3})
+
+	ch := make(chan []byte, 10)
+	for i := 0; i < 10; i++ {
+		ch <- []byte("record")
+	}
+	close(ch)
+
+	err := w.WriteFromChannel(context.Background(), ch)
+	require.ErrorIs(t, err, errInjected)
+
+	// the loop stopped at the failed record
+	require.Len(t, ch, 6)
+	require.EqualValues(t, 3, w.Stats().RecordsWritten)
+}
+
+func TestWriteFromChannelEmpty(t *testing.T) {
+	ch := make(chan []byte)
+	close(ch)
+	require.NoError(t, NewWriter(io.Discard).WriteFromChannel(context.Background(), ch))
+}
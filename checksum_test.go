@@ -0,0 +1,138 @@
+package recio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksum(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithChecksum()},
+		{WithChecksum(), WithStreamGuard(), WithSchemaVersion(3)},
+		{WithChecksum(), WithMerkleChain(), WithPrevFrameCRC()},
+		{WithChecksum(), WithVarintLength(), WithLength64()},
+		{WithChecksum(), WithVarintLength(), WithLengthFieldSize(1)},
+	} {
+		buf := bytes.NewBuffer([]byte{})
+		w := NewWriter(buf, opts...)
+		for i := 0; i < 10; i++ {
+			_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+			require.NoError(t, err)
+		}
+
+		r := NewReader(bytes.NewReader(buf.Bytes()), opts...)
+		p := make([]byte, 100)
+		for i := 0; i < 10; i++ {
+			n, err := r.Read(p)
+			require.NoError(t, err)
+			if r.opts.hasSchemaVersion {
+				require.Equal(t, fmt.Sprintf("record %d", i), string(p[1:n]))
+			} else {
+				require.Equal(t, fmt.Sprintf("record %d", i), string(p[:n]))
+			}
+		}
+		_, err := r.Read(p)
+		require.Equal(t, io.EOF, err)
+	}
+}
+
+func TestChecksumLayout(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	_, err := NewWriter(buf, WithChecksum()).Write([]byte("hello"))
+	require.NoError(t, err)
+
+	data := buf.Bytes()
+	require.Len(t, data, 4+5+4)
+	require.Equal(t, uint32(1<<31|9), binary.LittleEndian.Uint32(data))
+	require.Equal(t, []byte("hello"), data[4:9])
+	require.Equal(t, crc32.Checksum([]byte("hello"), castagnoli), binary.LittleEndian.Uint32(data[9:]))
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithChecksum())
+	for _, rec := range []string{"first", "second", "third"} {
+		_, err := w.Write([]byte(rec))
+		require.NoError(t, err)
+	}
+
+	// flip a byte in the payload of the second record, which starts at 13
+	data := append([]byte{}, buf.Bytes()...)
+	data[13+4+2] ^= 0x01
+
+	r := NewReader(bytes.NewReader(data), WithChecksum())
+	p := make([]byte, 100)
+	_, err := r.Read(p)
+	require.NoError(t, err)
+
+	_, err = r.Read(p)
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+	require.Contains(t, err.Error(), "offset 13")
+
+	// the offset is kept track of across seeks
+	r = NewReader(bytes.NewReader(data), WithChecksum(), WithReadBuffer(64))
+	_, err = r.Seek(13, io.SeekStart)
+	require.NoError(t, err)
+	_, err = r.Read(p)
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+	require.Contains(t, err.Error(), "offset 13")
+}
+
+func TestChecksumOptionMismatch(t *testing.T) {
+	checksummed := bytes.NewBuffer([]byte{})
+	_, err := NewWriter(checksummed, WithChecksum()).Write([]byte("hello"))
+	require.NoError(t, err)
+
+	plain := bytes.NewBuffer([]byte{})
+	_, err = NewWriter(plain).Write([]byte("hello"))
+	require.NoError(t, err)
+
+	p := make([]byte, 100)
+	_, err = NewReader(bytes.NewReader(checksummed.Bytes())).Read(p)
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+
+	_, err = NewReader(bytes.NewReader(plain.Bytes()), WithChecksum()).Read(p)
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+func TestRecomputeChecksums(t *testing.T) {
+	opts := []Option{WithChecksum(), WithTypeTag()}
+	records := []string{"first", "", "third record"}
+
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, opts...)
+	for i, rec := range records {
+		_, err := w.WriteTyped(byte(i+1), []byte(rec))
+		require.NoError(t, err)
+	}
+
+	// a buggy writer got every checksum wrong, but the payloads are intact
+	data := append([]byte{}, buf.Bytes()...)
+	end := 0
+	for _, rec := range records {
+		end += FrameSize(len(rec), opts...)
+		data[end-1] ^= 0xff
+	}
+	_, err := Validate(bytes.NewReader(data), opts...)
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+
+	repaired := bytes.NewBuffer([]byte{})
+	dst := NewWriter(repaired, opts...)
+	require.NoError(t, RecomputeChecksums(dst, NewReader(bytes.NewReader(data), opts...)))
+	require.NoError(t, dst.Close())
+	require.Equal(t, buf.Bytes(), repaired.Bytes())
+
+	n, err := Validate(bytes.NewReader(repaired.Bytes()), opts...)
+	require.NoError(t, err)
+	require.Equal(t, len(records), n)
+
+	// records that are damaged in other ways are still refused
+	err = RecomputeChecksums(NewWriter(io.Discard), NewReader(bytes.NewReader(data[:len(data)-2]), opts...))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
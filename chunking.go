@@ -0,0 +1,182 @@
+package recio
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// chunkContinued marks a chunk that is followed by more chunks of the same
+// record.
+const chunkContinued = 1
+
+var (
+	ErrMissingChunkFlag = errors.New("record is too short to hold a chunk flag")
+	ErrInvalidChunkFlag = errors.New("invalid chunk flag")
+)
+
+// WithChunking makes the writer split records of more than maxChunk bytes
+// into chunks of at most maxChunk bytes, which are written as records of
+// their own, so that records larger than the length prefix allows can be
+// written. Every chunk carries a flag byte that is set on all but the last
+// chunk of a record. A reader created with the same option reassembles the
+// chunks, so Read, ReadRecord and every other read method return the whole
+// record, and Skip skips it. Options that work on records, such as
+// WithCodec and WithChecksum, apply to every chunk on its own, and so do
+// the indexes and counts kept by the reader.
+//
+// Writer and reader must both use it; with WithHeader, readers detect a
+// mismatch.
+func WithChunking(maxChunk int) Option {
+	return func(o *options) {
+		o.chunkSize = maxChunk
+	}
+}
+
+// readsWhole reports whether records have to be read in full before they
+// can be returned, because the stored payload differs from the record or
+// the record may be split into chunks.
+func (o *options) readsWhole() bool {
+	return o.transformsPayload() || o.chunkSize > 0
+}
+
+// writeChunked implements Write when WithChunking is in use. The chunks are
+// written with a single write, and if it fails the writer carries on as if
+// nothing had been written, as Write does.
+func (w *Writer) writeChunked(p []byte) (int, error) {
+	saved := batchState{guard: w.guard, chain: w.chain, prevCRC: w.prevCRC}
+
+	w.frame = w.frame[:0]
+	err := w.appendRecord(p)
+	if err != nil {
+		w.restoreBatch(saved)
+		return 0, err
+	}
+
+	_, err = w.writeFrame(w.frame)
+	if err != nil {
+		w.restoreBatch(saved)
+		return 0, w.flushOnError(err)
+	}
+	return len(p), w.recordWritten(p, len(w.frame))
+}
+
+// appendRecord appends the frames of the record p to w.frame, one frame
+// for every chunk, and advances the state kept from frame to frame.
+func (w *Writer) appendRecord(p []byte) error {
+	for {
+		chunk := p
+		w.continued = 0
+		if w.opts.chunkSize > 0 && len(p) > w.opts.chunkSize {
+			chunk = p[:w.opts.chunkSize]
+			w.continued = chunkContinued
+		}
+
+		payload, err := w.encodePayload(chunk)
+		if err != nil {
+			return err
+		}
+
+		var chain [merkleHashSize]byte
+		if w.opts.merkleChain {
+			chain = w.nextChainHash(payload)
+		}
+
+		start := len(w.frame)
+		w.frame = w.appendFrame(w.frame, payload, chain[:])
+		w.guard++
+		w.chain = chain
+		if w.opts.prevFrameCRC {
+			w.prevCRC = crc32.Checksum(w.frame[start:], castagnoli)
+		}
+		w.frame = w.appendPadding(w.frame, w.offset)
+
+		p = p[len(chunk):]
+		if w.continued == 0 {
+			return nil
+		}
+	}
+}
+
+// readChunkFlag reads the chunk flag of the current record.
+func (r *Reader) readChunkFlag(length uint64) (uint64, error) {
+	if length < 1 {
+		return 0, ErrMissingChunkFlag
+	}
+
+	flag, err := r.readByte()
+	if err == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return 0, err
+	}
+	if flag > chunkContinued {
+		return 0, fmt.Errorf("%w: 0x%02x", ErrInvalidChunkFlag, flag)
+	}
+	r.continued = flag == chunkContinued
+	return length - 1, nil
+}
+
+// readChunks reads the chunks of the next record and returns the record
+// reassembled in a buffer owned by the reader, which is only valid until
+// the next call.
+func (r *Reader) readChunks() ([]byte, error) {
+	if r.chunks == nil {
+		r.chunks = []byte{}
+	}
+	r.chunks = r.chunks[:0]
+
+	var offset int64
+	for first := true; ; first = false {
+		length, err := r.readLength()
+		if err == io.EOF && !first {
+			err = r.framingError(io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if first {
+			offset = r.recordOffset
+		}
+
+		chunk, err := r.readPayload(length)
+		if err != nil {
+			return nil, err
+		}
+
+		// every chunk repeats the schema version of the record
+		if !first && r.opts.hasSchemaVersion {
+			if len(chunk) < 1 {
+				return nil, r.framingError(ErrMissingSchemaVersion)
+			}
+			chunk = chunk[1:]
+		}
+		r.chunks = append(r.chunks, chunk...)
+
+		if !r.continued {
+			r.recordOffset = offset
+			return r.chunks, nil
+		}
+	}
+}
+
+// skipChunks skips the rest of a record whose first chunk has been skipped.
+func (r *Reader) skipChunks() error {
+	for r.continued {
+		length, err := r.readLength()
+		if err == io.EOF {
+			err = r.framingError(io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = r.discardPayload(length)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package recio
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunking(t *testing.T) {
+	const maxChunk = 100
+
+	payloads := [][]byte{
+		{},
+		make([]byte, 1),
+		make([]byte, maxChunk),
+		make([]byte, maxChunk+1),
+		make([]byte, 3*maxChunk),
+		make([]byte, 5*maxChunk+17),
+	}
+	for _, p := range payloads {
+		_, err := rand.Read(p)
+		require.NoError(t, err)
+	}
+
+	for _, opts := range [][]Option{
+		{WithChunking(maxChunk), WithLengthFieldSize(1)},
+		{WithChunking(maxChunk), WithHeader(), WithChecksum(), WithStreamGuard()},
+		{WithChunking(maxChunk), WithSchemaVersion(2), WithTypeTag(), WithMerkleChain()},
+		{WithChunking(maxChunk), WithCodec(GzipCodec{}), WithPrevFrameCRC()},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, opts...)
+		for _, p := range payloads {
+			n, err := w.Write(p)
+			require.NoError(t, err)
+			require.Equal(t, len(p), n)
+		}
+		_, err := w.WriteBatch(payloads)
+		require.NoError(t, err)
+		require.EqualValues(t, 2*len(payloads), w.Stats().RecordsWritten)
+
+		// every read method returns the records whole
+		r := NewReader(bytes.NewReader(buf.Bytes()), opts...)
+		for _, want := range payloads {
+			got, err := r.ReadRecord()
+			require.NoError(t, err)
+			if r.opts.hasSchemaVersion {
+				require.Equal(t, byte(2), got[0])
+				got = got[1:]
+			}
+			require.Equal(t, want, got)
+		}
+		for i, want := range payloads {
+			var got []byte
+			if i%2 == 0 {
+				got, err = r.Next()
+			} else {
+				rr, err := r.NextReader()
+				require.NoError(t, err)
+				got, err = io.ReadAll(rr)
+				require.NoError(t, err)
+			}
+			require.NoError(t, err)
+			if r.opts.hasSchemaVersion {
+				got = got[1:]
+			}
+			require.Equal(t, want, got)
+		}
+		_, err = r.ReadRecord()
+		require.Equal(t, io.EOF, err)
+
+		count, err := CountRecords(bytes.NewReader(buf.Bytes()), opts...)
+		require.NoError(t, err)
+		require.Equal(t, 2*len(payloads), count)
+	}
+}
+
+func TestChunkingRead(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithChunking(4))
+	_, err := w.WriteString("chunked record")
+	require.NoError(t, err)
+
+	r := NewReader(&buf, WithChunking(4))
+	p := make([]byte, 20)
+	n, err := r.Read(p)
+	require.NoError(t, err)
+	require.Equal(t, "chunked record", string(p[:n]))
+}
+
+func TestChunkingTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithChunking(4))
+	_, err := w.WriteString("chunked record")
+	require.NoError(t, err)
+
+	// cut the stream after the first two chunks
+	data := buf.Bytes()[:2*(4+1+4)]
+	_, err = NewReader(bytes.NewReader(data), WithChunking(4)).ReadRecord()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	err = NewReader(bytes.NewReader(data), WithChunking(4)).Skip()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestChunkingMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithChunking(4), WithHeader())
+	_, err := w.WriteString("chunked record")
+	require.NoError(t, err)
+
+	_, err = NewReader(bytes.NewReader(buf.Bytes()), WithHeader()).ReadRecord()
+	require.ErrorIs(t, err, ErrFramingMismatch)
+
+	// without a header, reading a plain stream with chunking trips over the
+	// first payload byte
+	var plain bytes.Buffer
+	_, err = NewWriter(&plain).WriteString("plain")
+	require.NoError(t, err)
+	_, err = NewReader(&plain, WithChunking(4)).ReadRecord()
+	require.ErrorIs(t, err, ErrInvalidChunkFlag)
+}
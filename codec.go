@@ -0,0 +1,174 @@
+package recio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// Codec transforms the payload of every record, typically to compress it.
+type Codec interface {
+	// ID identifies the codec in the stream header written when
+	// WithHeader is in use, so that readers can reject streams written
+	// with a different codec. 0 means no codec and IDs below 128 are
+	// reserved for codecs provided by this package.
+	ID() uint8
+	// Compress returns the encoded form of p.
+	Compress(p []byte) ([]byte, error)
+	// Decompress returns the payload that was encoded as p.
+	Decompress(p []byte) ([]byte, error)
+}
+
+var ErrCodecMismatch = errors.New("stream was written with a different codec")
+
+// WithCodec makes the writer pass the payload of every record through
+// c.Compress before framing it, and the reader pass what it reads through
+// c.Decompress before returning it. The schema version byte is not encoded.
+// Writer and reader must use the same codec; combine it with WithHeader to
+// have readers detect streams written with a different codec.
+//
+// With a codec, Read always skips records that don't fit the target buffer,
+// and NextReader reads the complete record before returning it.
+func WithCodec(c Codec) Option {
+	return func(o *options) {
+		o.codec = c
+	}
+}
+
+// codecID returns the ID of c, or 0 if there is no codec.
+func codecID(c Codec) uint8 {
+	if c == nil {
+		return 0
+	}
+	return c.ID()
+}
+
+// transform compresses and encrypts p as configured.
+func (w *Writer) transform(p []byte) ([]byte, error) {
+	payload := p
+	if w.opts.codec != nil {
+		var err error
+		payload, err = w.opts.codec.Compress(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if w.opts.aead != nil {
+		return w.seal(payload)
+	}
+	return payload, nil
+}
+
+// decode decrypts and decompresses the body of a record as configured.
+func (r *Reader) decode(body []byte) ([]byte, error) {
+	if !r.opts.transformsPayload() {
+		return body, nil
+	}
+
+	if !r.opts.hasSchemaVersion {
+		p, err := r.untransform(body)
+		if err != nil {
+			return nil, r.observeError(err)
+		}
+		return p, nil
+	}
+
+	if len(body) < 1 {
+		return nil, r.observeError(ErrMissingSchemaVersion)
+	}
+	payload, err := r.untransform(body[1:])
+	if err != nil {
+		return nil, r.observeError(err)
+	}
+	return append([]byte{body[0]}, payload...), nil
+}
+
+// untransform undoes what Writer.transform did to a payload.
+func (r *Reader) untransform(p []byte) ([]byte, error) {
+	if r.opts.aead != nil {
+		var err error
+		p, err = r.open(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.opts.codec != nil {
+		return r.opts.codec.Decompress(p)
+	}
+	return p, nil
+}
+
+// transformsPayload reports whether the stored payload differs from the
+// record, so that it has to be read in full before it can be returned.
+func (o *options) transformsPayload() bool {
+	return o.codec != nil || o.aead != nil
+}
+
+// readDecoded implements Read when WithCodec is in use.
+func (r *Reader) readDecoded(p []byte) (int, error) {
+	body, err := r.readBuffered()
+	if err != nil {
+		return 0, err
+	}
+
+	// the record has been read, so the index has moved on to the next one
+	if len(p) < len(body) {
+		n := 0
+		if r.opts.oversizePolicy == OversizeTruncate {
+			n = copy(p, body)
+		}
+		return n, r.observeError(&FramingError{Offset: r.recordOffset, Index: r.index - 1, Err: ErrTargetBufferTooSmall})
+	}
+	return copy(p, body), nil
+}
+
+// GzipCodec compresses records using gzip. Every record is compressed
+// separately, so it is only worthwhile for records of at least a few hundred
+// bytes.
+type GzipCodec struct {
+	// Level is the compression level as defined by compress/gzip. The zero
+	// value means gzip.DefaultCompression.
+	Level int
+}
+
+// ID returns 1.
+func (GzipCodec) ID() uint8 {
+	return 1
+}
+
+// Compress compresses p.
+func (g GzipCodec) Compress(p []byte) ([]byte, error) {
+	level := g.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = zw.Write(p)
+	if err != nil {
+		return nil, err
+	}
+
+	err = zw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses p.
+func (GzipCodec) Decompress(p []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(p))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return io.ReadAll(zr)
+}
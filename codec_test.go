@@ -0,0 +1,74 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzipCodec(t *testing.T) {
+	random := make([]byte, 10000)
+	rand.New(rand.NewSource(1)).Read(random)
+	compressible := bytes.Repeat([]byte(`{"name":"value"},`), 1000)
+	records := [][]byte{random, compressible, {}}
+
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithCodec(GzipCodec{}), WithHeader())
+	for _, rec := range records {
+		n, err := w.Write(rec)
+		require.NoError(t, err)
+		require.Equal(t, len(rec), n)
+	}
+	require.Less(t, buf.Len(), len(random)+len(compressible)/10)
+
+	r := NewReader(bytes.NewReader(buf.Bytes()), WithCodec(GzipCodec{}), WithHeader())
+	for _, rec := range records {
+		got, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.Equal(t, rec, got)
+	}
+	_, err := r.ReadRecord()
+	require.Equal(t, io.EOF, err)
+
+	// Read and NextReader return the decompressed payload too
+	r = NewReader(bytes.NewReader(buf.Bytes()), WithCodec(GzipCodec{}), WithHeader())
+	p := make([]byte, 100)
+	_, err = r.Read(p)
+	require.ErrorIs(t, err, ErrTargetBufferTooSmall)
+
+	rec, err := r.NextReader()
+	require.NoError(t, err)
+	got, err := io.ReadAll(rec)
+	require.NoError(t, err)
+	require.Equal(t, compressible, got)
+}
+
+func TestCodecSchemaVersion(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	_, err := NewWriter(buf, WithCodec(GzipCodec{}), WithSchemaVersion(7)).Write([]byte("versioned"))
+	require.NoError(t, err)
+
+	version, payload, err := NewReader(bytes.NewReader(buf.Bytes()), WithCodec(GzipCodec{}), WithSchemaVersion(7)).ReadVersioned()
+	require.NoError(t, err)
+	require.Equal(t, uint8(7), version)
+	require.Equal(t, "versioned", string(payload))
+}
+
+func TestCodecMismatch(t *testing.T) {
+	compressed := bytes.NewBuffer([]byte{})
+	_, err := NewWriter(compressed, WithCodec(GzipCodec{}), WithHeader()).Write([]byte("record"))
+	require.NoError(t, err)
+
+	plain := bytes.NewBuffer([]byte{})
+	_, err = NewWriter(plain, WithHeader()).Write([]byte("record"))
+	require.NoError(t, err)
+
+	_, err = NewReader(bytes.NewReader(compressed.Bytes()), WithHeader()).ReadRecord()
+	require.ErrorIs(t, err, ErrCodecMismatch)
+
+	_, err = NewReader(bytes.NewReader(plain.Bytes()), WithCodec(GzipCodec{}), WithHeader()).ReadRecord()
+	require.ErrorIs(t, err, ErrCodecMismatch)
+}
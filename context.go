@@ -0,0 +1,131 @@
+package recio
+
+import (
+	"context"
+	"time"
+)
+
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+type writeDeadliner interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// aLongTimeAgo is a deadline in the past, which makes blocked I/O on a
+// connection return at once.
+var aLongTimeAgo = time.Unix(1, 0)
+
+type ioResult struct {
+	n   int
+	err error
+}
+
+// ReadContext is like Read, but gives up when ctx is done and returns
+// ctx.Err(). The context error takes precedence: a read that fails after
+// the context is done returns the context error rather than io.EOF or
+// io.ErrUnexpectedEOF, even if closing the connection is what made it
+// fail.
+//
+// How a blocked read is aborted depends on the underlying reader. If it has
+// a SetReadDeadline method, as net.Conn and *os.File do, its deadline is
+// moved into the past so the read returns at once and is then cleared
+// again. Otherwise the read runs in a goroutine that is abandoned when the
+// context is done: it keeps running until the underlying read returns, and
+// neither the Reader nor p may be used again.
+//
+// In both cases a cancelled read may have consumed part of a record, so the
+// stream is generally not usable after a cancellation.
+func (r *Reader) ReadContext(ctx context.Context, p []byte) (int, error) {
+	err := ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	if d, ok := r.src.(readDeadliner); ok {
+		fired := make(chan struct{})
+		stop := context.AfterFunc(ctx, func() {
+			d.SetReadDeadline(aLongTimeAgo)
+			close(fired)
+		})
+
+		n, err := r.Read(p)
+		if !stop() {
+			<-fired
+			d.SetReadDeadline(time.Time{})
+		}
+		return n, preferContextErr(ctx, err)
+	}
+
+	done := make(chan ioResult, 1)
+	go func() {
+		n, err := r.Read(p)
+		done <- ioResult{n, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.n, preferContextErr(ctx, res.err)
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// WriteContext is like Write, but gives up when ctx is done and returns
+// ctx.Err(). A wait imposed by WithRateLimit is cut short as well.
+//
+// If the underlying writer has a SetWriteDeadline method, its deadline is
+// moved into the past to abort a blocked write and cleared afterwards.
+// Otherwise the write runs in a goroutine that is abandoned when the context
+// is done, and the Writer must not be used again. Either way part of the
+// frame may have been written.
+func (w *Writer) WriteContext(ctx context.Context, p []byte) (int, error) {
+	err := ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	if d, ok := w.dst.(writeDeadliner); ok {
+		fired := make(chan struct{})
+		stop := context.AfterFunc(ctx, func() {
+			d.SetWriteDeadline(aLongTimeAgo)
+			close(fired)
+		})
+
+		w.ctx = ctx
+		n, err := w.Write(p)
+		w.ctx = nil
+		if !stop() {
+			<-fired
+			d.SetWriteDeadline(time.Time{})
+		}
+		return n, preferContextErr(ctx, err)
+	}
+
+	done := make(chan ioResult, 1)
+	go func() {
+		n, err := w.Write(p)
+		done <- ioResult{n, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.n, preferContextErr(ctx, res.err)
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// preferContextErr returns the context error in place of err if the
+// operation failed once ctx was done.
+func preferContextErr(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	ctxErr := ctx.Err()
+	if ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package recio
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := NewReader(eofReader{})
+	_, err := r.ReadContext(ctx, make([]byte, 10))
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) {
+	return 0, io.EOF
+}
+
+func TestReadContextBlocked(t *testing.T) {
+	// io.Pipe can't set deadlines, net.Pipe can
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	for _, src := range []io.Reader{pr, server} {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		r := NewReader(src)
+		_, err := r.ReadContext(ctx, make([]byte, 10))
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+}
+
+func TestReadContextDeadlineCleared(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	r := NewReader(server)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := r.ReadContext(ctx, make([]byte, 10))
+	require.ErrorIs(t, err, context.Canceled)
+
+	// a read that completes leaves no deadline behind
+	go func() {
+		w := NewWriter(client)
+		w.Write([]byte("first"))
+		w.Write([]byte("second"))
+	}()
+
+	p := make([]byte, 10)
+	n, err := r.ReadContext(context.Background(), p)
+	require.NoError(t, err)
+	require.Equal(t, "first", string(p[:n]))
+
+	n, err = r.Read(p)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(p[:n]))
+}
+
+func TestReadContextPrefersContextError(t *testing.T) {
+	// the producer hangs up when the context is cancelled, half way into a
+	// payload, so the underlying reader sees EOF
+	client, server := net.Pipe()
+	defer server.Close()
+	pr, pw := io.Pipe()
+
+	for _, tc := range []struct {
+		src   io.Reader
+		write io.WriteCloser
+	}{
+		{server, client},
+		{pr, pw},
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+		context.AfterFunc(ctx, func() { tc.write.Close() })
+
+		go func() {
+			tc.write.Write([]byte{10, 0, 0, 0, 'p', 'a', 'r'})
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+		}()
+
+		r := NewReader(tc.src)
+		_, err := r.ReadContext(ctx, make([]byte, 10))
+		require.ErrorIs(t, err, context.Canceled)
+		require.False(t, errors.Is(err, io.EOF))
+		require.False(t, errors.Is(err, io.ErrUnexpectedEOF))
+	}
+}
+
+func TestWriteContextBlocked(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	for _, dst := range []io.Writer{pw, client} {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		w := NewWriter(dst)
+		_, err := w.WriteContext(ctx, []byte("nobody is reading"))
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+}
+
+func TestWriteContextRateLimit(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	go io.Copy(io.Discard, server)
+
+	w := NewWriter(client, WithRateLimit(1000))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// the first write empties the bucket, the second would wait a second
+	_, err := w.WriteContext(ctx, make([]byte, 996))
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = w.WriteContext(ctx, make([]byte, 996))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+}
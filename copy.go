@@ -0,0 +1,133 @@
+package recio
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrLengthAlreadyRead = errors.New("length prefix of the next record has already been read")
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// WriteTo copies the remaining records to dst as complete frames, exactly
+// as they are stored, rather than their payloads, so io.Copy(dst, r) copies
+// the rest of the stream unchanged. The stream header is copied too if it
+// has not been read yet, and once the stream ends so are the padding of
+// WithAlignment after the last record and the footers of WithCountFooter
+// and WithFooterChecksum. Records are verified on the way as the options
+// require and the copy stops at the first record that fails. WriteTo
+// returns the number of bytes written to dst. It fails with
+// ErrLengthAlreadyRead after PeekLength, since the length prefix of the
+// next record can't be copied any more.
+func (r *Reader) WriteTo(dst io.Writer) (int64, error) {
+	_, n, err := r.copyRecords(dst, -1)
+	if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// CopyRecords copies every record of src, a stream framed as opts describe,
+// to dst as complete frames, exactly as they are stored, and returns the
+// number of records and bytes copied. Unlike io.Copy it only ever copies
+// whole records: a record that fails verification or is cut short is not
+// copied, and the copy stops with its error. The stream header of src is
+// copied along with the first record, and the padding and footers that
+// follow the last record once src ends, as WriteTo does.
+func CopyRecords(dst io.Writer, src io.Reader, opts ...Option) (int, int64, error) {
+	records, n, err := CopyRecordsN(dst, src, -1, opts...)
+	if err == io.EOF {
+		err = nil
+	}
+	return records, n, err
+}
+
+// CopyRecordsN is like CopyRecords but copies at most maxRecords records,
+// or all of them if maxRecords is negative. Like io.CopyN it returns io.EOF
+// if src ends before maxRecords records have been copied.
+//
+// Without WithReadBuffer, or footer options that make the reader hold back
+// the end of the stream, src is read no further than the end of the last
+// record copied, so that the rest of it can be copied elsewhere.
+func CopyRecordsN(dst io.Writer, src io.Reader, maxRecords int, opts ...Option) (int, int64, error) {
+	r := NewReader(src, opts...)
+	return r.copyRecords(dst, maxRecords)
+}
+
+// copyRecords copies up to limit records, or all if limit is negative, to dst
+// as they are framed. It returns io.EOF if the stream ends first.
+func (r *Reader) copyRecords(dst io.Writer, limit int) (int, int64, error) {
+	if r.hasPending {
+		return 0, 0, ErrLengthAlreadyRead
+	}
+
+	out := &countingWriter{w: dst}
+	if r.tee == nil {
+		r.tee = &frameTee{r: r.count.r, raw: out}
+		r.count.r = r.tee
+		defer func() {
+			r.count.r = r.tee.r
+			r.tee = nil
+		}()
+	} else {
+		// keep mirroring to the tee reader's writer as well
+		raw := r.tee.raw
+		r.tee.raw = io.MultiWriter(raw, out)
+		defer func() { r.tee.raw = raw }()
+	}
+
+	records := 0
+	for limit < 0 || records < limit {
+		err := r.Skip()
+		if err == io.EOF {
+			err = r.copyTail(out)
+			if err == nil {
+				err = io.EOF
+			}
+		}
+		if err != nil {
+			return records, out.n, err
+		}
+		records++
+	}
+	return records, out.n, nil
+}
+
+// copyTail copies what follows the last record of a stream that has ended
+// to out: the padding read looking for another record, which the tee still
+// holds, and the footers, which have been verified by now.
+func (r *Reader) copyTail(out io.Writer) error {
+	err := r.tee.flush()
+	if err != nil || r.footer == nil {
+		return err
+	}
+	_, err = out.Write(r.footer.buf[r.footer.start:r.footer.end])
+	return err
+}
+
+// ReadFrom reads src until EOF and writes everything read as a single
+// record, since src carries no framing to split it into records by. It
+// returns the number of bytes read from src. This makes io.Copy(w, src)
+// write the whole of src as one record, which is held in memory.
+func (w *Writer) ReadFrom(src io.Reader) (int64, error) {
+	p, err := io.ReadAll(src)
+	if err != nil {
+		return int64(len(p)), err
+	}
+
+	_, err = w.Write(p)
+	if err != nil {
+		return int64(len(p)), err
+	}
+	return int64(len(p)), nil
+}
@@ -0,0 +1,213 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderWriteTo(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithHeader(), WithChecksum()},
+		{WithReadBuffer(32)},
+	} {
+		var stream bytes.Buffer
+		w := NewWriter(&stream, opts...)
+		for i := 0; i < 20; i++ {
+			_, err := w.WriteString(fmt.Sprintf("record %d", i))
+			require.NoError(t, err)
+		}
+
+		var copied bytes.Buffer
+		r := NewReader(bytes.NewReader(stream.Bytes()), opts...)
+		n, err := io.Copy(&copied, r)
+		require.NoError(t, err)
+		require.EqualValues(t, stream.Len(), n)
+		require.Equal(t, stream.Bytes(), copied.Bytes())
+	}
+}
+
+func TestReaderWriteToTail(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithAlignment(8)},
+		{WithCountFooter(), WithFooterChecksum()},
+		{WithAlignment(16), WithFooterChecksum(), WithReadBuffer(32)},
+	} {
+		var stream bytes.Buffer
+		w := NewWriter(&stream, opts...)
+		for i := 0; i < 5; i++ {
+			_, err := w.WriteString(fmt.Sprintf("record %d", i))
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+
+		// the padding after the last record and the footers are copied too
+		var copied bytes.Buffer
+		n, err := io.Copy(&copied, NewReader(bytes.NewReader(stream.Bytes()), opts...))
+		require.NoError(t, err)
+		require.EqualValues(t, stream.Len(), n)
+		require.Equal(t, stream.Bytes(), copied.Bytes())
+
+		if newOptions(opts).footerChecksum {
+			require.NoError(t, VerifyFooter(bytes.NewReader(copied.Bytes()), int64(copied.Len())))
+		}
+	}
+}
+
+func TestReaderWriteToRest(t *testing.T) {
+	var stream bytes.Buffer
+	w := NewWriter(&stream)
+	for i := 0; i < 5; i++ {
+		_, err := w.WriteString(fmt.Sprintf("record %d", i))
+		require.NoError(t, err)
+	}
+
+	r := NewReader(bytes.NewReader(stream.Bytes()))
+	_, err := r.ReadRecord()
+	require.NoError(t, err)
+
+	// only the records that have not been read are copied, and the reader
+	// is usable afterwards
+	var copied bytes.Buffer
+	_, err = io.Copy(&copied, r)
+	require.NoError(t, err)
+	require.Equal(t, stream.Bytes()[4+len("record 0"):], copied.Bytes())
+
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, io.EOF)
+
+	r = NewReader(bytes.NewReader(stream.Bytes()))
+	_, err = r.PeekLength()
+	require.NoError(t, err)
+	_, err = r.WriteTo(io.Discard)
+	require.ErrorIs(t, err, ErrLengthAlreadyRead)
+}
+
+func TestWriterReadFrom(t *testing.T) {
+	var stream bytes.Buffer
+	w := NewWriter(&stream)
+
+	// hide strings.Reader's WriteTo so io.Copy uses ReadFrom, which makes
+	// a single record out of many reads
+	src := iotest.OneByteReader(strings.NewReader(strings.Repeat("opaque input ", 1000)))
+	n, err := io.Copy(w, src)
+	require.NoError(t, err)
+	require.EqualValues(t, 13000, n)
+
+	r := NewReader(&stream)
+	rec, err := r.ReadRecord()
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("opaque input ", 1000), string(rec))
+
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestCopyRecords(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithHeader(), WithChecksum()},
+		{WithVarintLength(), WithStreamGuard()},
+	} {
+		var stream bytes.Buffer
+		w := NewWriter(&stream, opts...)
+		for i := 0; i < 10; i++ {
+			_, err := w.WriteString(fmt.Sprintf("record %d", i))
+			require.NoError(t, err)
+		}
+
+		var copied bytes.Buffer
+		records, n, err := CopyRecords(&copied, bytes.NewReader(stream.Bytes()), opts...)
+		require.NoError(t, err)
+		require.Equal(t, 10, records)
+		require.EqualValues(t, stream.Len(), n)
+		require.Equal(t, stream.Bytes(), copied.Bytes())
+	}
+}
+
+func TestCopyRecordsTail(t *testing.T) {
+	opts := []Option{WithAlignment(8), WithCountFooter(), WithFooterChecksum()}
+	var stream bytes.Buffer
+	w := NewWriter(&stream, opts...)
+	for i := 0; i < 3; i++ {
+		_, err := w.WriteString(fmt.Sprintf("record %d", i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	var copied bytes.Buffer
+	records, n, err := CopyRecords(&copied, bytes.NewReader(stream.Bytes()), opts...)
+	require.NoError(t, err)
+	require.Equal(t, 3, records)
+	require.EqualValues(t, stream.Len(), n)
+	require.Equal(t, stream.Bytes(), copied.Bytes())
+
+	// copying part of the stream leaves the tail behind
+	copied.Reset()
+	_, n, err = CopyRecordsN(&copied, bytes.NewReader(stream.Bytes()), 2, opts...)
+	require.NoError(t, err)
+	require.Less(t, n, int64(stream.Len()-StreamOverhead(opts...)))
+	require.Equal(t, stream.Bytes()[:n], copied.Bytes())
+}
+
+func TestCopyRecordsN(t *testing.T) {
+	var stream bytes.Buffer
+	w := NewWriter(&stream)
+	for i := 0; i < 10; i++ {
+		_, err := w.WriteString(fmt.Sprintf("record %d", i))
+		require.NoError(t, err)
+	}
+
+	// split the stream in two, the first part holding three records
+	src := bytes.NewReader(stream.Bytes())
+	var head, tail bytes.Buffer
+	records, n, err := CopyRecordsN(&head, src, 3)
+	require.NoError(t, err)
+	require.Equal(t, 3, records)
+	require.EqualValues(t, 3*(4+len("record 0")), n)
+
+	// src is left at the start of the fourth record
+	require.EqualValues(t, stream.Len()-int(n), src.Len())
+
+	records, _, err = CopyRecords(&tail, src)
+	require.NoError(t, err)
+	require.Equal(t, 7, records)
+	require.Equal(t, stream.Bytes(), append(head.Bytes(), tail.Bytes()...))
+
+	r := NewReader(&head)
+	for i := 0; i < 3; i++ {
+		got, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(got))
+	}
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, io.EOF)
+
+	// asking for more records than there are copies them all
+	records, _, err = CopyRecordsN(io.Discard, bytes.NewReader(stream.Bytes()), 20)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 10, records)
+}
+
+func TestCopyRecordsTruncated(t *testing.T) {
+	var stream bytes.Buffer
+	w := NewWriter(&stream)
+	for _, p := range []string{"first", "second"} {
+		_, err := w.WriteString(p)
+		require.NoError(t, err)
+	}
+
+	// the cut short second record is not copied
+	var copied bytes.Buffer
+	records, n, err := CopyRecords(&copied, bytes.NewReader(stream.Bytes()[:stream.Len()-2]))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, 1, records)
+	require.EqualValues(t, 4+len("first"), n)
+	require.Equal(t, stream.Bytes()[:n], copied.Bytes())
+}
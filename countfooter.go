@@ -0,0 +1,101 @@
+package recio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// countFooterSize is the size of the footer written by WithCountFooter: a
+// magic number followed by the number of records and of payload bytes.
+const countFooterSize = len(countFooterMagic) + 16
+
+var countFooterMagic = [8]byte{'r', 'e', 'c', 'i', 'o', 'c', 'n', 't'}
+
+var ErrMissingCountFooter = errors.New("stream does not end with a record count footer")
+
+// WithCountFooter makes Close on the writer end the stream with a footer
+// holding the number of records written and their total payload size, so
+// that RecordCount can tell how many records a file holds without reading
+// them. With WithFooterChecksum as well, the count footer comes first and
+// is covered by the checksum.
+//
+// A reader created with the same option does not return the footer as a
+// record, and returns ErrMissingCountFooter if the stream ends without one.
+// The counts are of what the writer wrote since it was created or reset,
+// which makes the option a poor fit for WithAppendOnly.
+func WithCountFooter() Option {
+	return func(o *options) {
+		o.countFooter = true
+	}
+}
+
+// CountFooter is what the footer written by WithCountFooter holds.
+type CountFooter struct {
+	Records      int64
+	PayloadBytes int64
+}
+
+// RecordCount returns the number of records in the size bytes of r, as
+// recorded in the footer written by WithCountFooter. It returns
+// ErrMissingCountFooter if there is no footer, for example because the
+// writer was never closed, in which case the records have to be counted by
+// reading them.
+func RecordCount(r io.ReaderAt, size int64) (int, error) {
+	footer, err := ReadCountFooter(r, size)
+	if err != nil {
+		return 0, err
+	}
+	return int(footer.Records), nil
+}
+
+// ReadCountFooter reads the footer written by WithCountFooter from the end
+// of the size bytes of r, looking past the footer of WithFooterChecksum if
+// there is one. It returns ErrMissingCountFooter if there is no footer.
+func ReadCountFooter(r io.ReaderAt, size int64) (CountFooter, error) {
+	end := size
+
+	// skip the checksum footer, which comes last
+	var magic [len(footerMagic)]byte
+	if size >= int64(footerSize) {
+		_, err := r.ReadAt(magic[:], size-int64(footerSize))
+		if err != nil && err != io.EOF {
+			return CountFooter{}, err
+		}
+		if magic == footerMagic {
+			end -= int64(footerSize)
+		}
+	}
+
+	if end < int64(countFooterSize) {
+		return CountFooter{}, ErrMissingCountFooter
+	}
+	var footer [countFooterSize]byte
+	_, err := r.ReadAt(footer[:], end-int64(countFooterSize))
+	if err != nil && err != io.EOF {
+		return CountFooter{}, err
+	}
+	if !bytes.Equal(footer[:len(countFooterMagic)], countFooterMagic[:]) {
+		return CountFooter{}, ErrMissingCountFooter
+	}
+
+	return parseCountFooter(footer[:]), nil
+}
+
+// parseCountFooter returns the counts held by footer, which starts with
+// countFooterMagic.
+func parseCountFooter(footer []byte) CountFooter {
+	counts := footer[len(countFooterMagic):]
+	return CountFooter{
+		Records:      int64(binary.LittleEndian.Uint64(counts)),
+		PayloadBytes: int64(binary.LittleEndian.Uint64(counts[8:])),
+	}
+}
+
+// appendCountFooter appends the footer of WithCountFooter to dst.
+func (w *Writer) appendCountFooter(dst []byte) []byte {
+	dst = append(dst, countFooterMagic[:]...)
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(w.stats.RecordsWritten))
+	return binary.LittleEndian.AppendUint64(dst, uint64(w.stats.BytesWritten))
+}
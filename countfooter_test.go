@@ -0,0 +1,108 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountFooter(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithCountFooter()},
+		{WithCountFooter(), WithChecksum(), WithWriteBuffer(64)},
+		{WithCountFooter(), WithFooterChecksum()},
+		{WithCountFooter(), WithChunking(8)},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, opts...)
+		payload := 0
+		for i := 0; i < 25; i++ {
+			rec := fmt.Sprintf("record number %d", i)
+			payload += len(rec)
+			_, err := w.WriteString(rec)
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+		data := bytes.NewReader(buf.Bytes())
+
+		count, err := RecordCount(data, data.Size())
+		require.NoError(t, err)
+		require.Equal(t, 25, count)
+
+		footer, err := ReadCountFooter(data, data.Size())
+		require.NoError(t, err)
+		require.Equal(t, CountFooter{Records: 25, PayloadBytes: int64(payload)}, footer)
+
+		// readers with the option do not return the footer
+		r := NewReader(bytes.NewReader(buf.Bytes()), opts...)
+		read := 0
+		for {
+			_, err := r.ReadRecord()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			read++
+		}
+		require.Equal(t, count, read)
+	}
+}
+
+func TestCountFooterWithChecksum(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithCountFooter(), WithFooterChecksum())
+	_, err := w.WriteString("record")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	data := bytes.NewReader(buf.Bytes())
+	require.NoError(t, VerifyFooter(data, data.Size()))
+	require.Equal(t, StreamOverhead(WithCountFooter(), WithFooterChecksum())+FrameSize(len("record")), buf.Len())
+
+	// the checksum covers the count footer
+	tampered := bytes.Clone(buf.Bytes())
+	tampered[len(tampered)-footerSize-1]++
+	data = bytes.NewReader(tampered)
+	require.ErrorIs(t, VerifyFooter(data, data.Size()), ErrFooterMismatch)
+
+	r := NewReader(bytes.NewReader(tampered), WithCountFooter(), WithFooterChecksum())
+	_, err = r.ReadRecord()
+	require.NoError(t, err)
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, ErrFooterMismatch)
+}
+
+func TestCountFooterMissing(t *testing.T) {
+	// a writer that is never closed writes no footer
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithCountFooter())
+	for i := 0; i < 5; i++ {
+		_, err := w.WriteString("record")
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Flush())
+
+	data := bytes.NewReader(buf.Bytes())
+	_, err := RecordCount(data, data.Size())
+	require.ErrorIs(t, err, ErrMissingCountFooter)
+	_, err = RecordCount(data, 3)
+	require.ErrorIs(t, err, ErrMissingCountFooter)
+
+	// so the records have to be counted by reading them
+	offsets, err := ScanOffsets(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Len(t, offsets, 5)
+
+	// readers with the option hold back what should be the footer, so they
+	// fail before the last records
+	r := NewReader(bytes.NewReader(buf.Bytes()), WithCountFooter())
+	for i := 0; i < 2; i++ {
+		_, err = r.ReadRecord()
+		require.NoError(t, err)
+	}
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, ErrMissingCountFooter)
+}
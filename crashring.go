@@ -0,0 +1,72 @@
+package recio
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// WithCrashRing makes the writer keep copies of the last maxRecords records
+// it has written in memory, so that they can be dumped with DumpRecent, for
+// example from a crash handler when the output may not have been flushed.
+func WithCrashRing(maxRecords int) Option {
+	return func(o *options) {
+		o.crashRing = maxRecords
+	}
+}
+
+// crashRing holds the most recently written records.
+type crashRing struct {
+	mu      sync.Mutex
+	records [][]byte
+	next    int
+	full    bool
+}
+
+func newCrashRing(size int) *crashRing {
+	return &crashRing{
+		records: make([][]byte, size),
+	}
+}
+
+func (c *crashRing) add(p []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// reuse the slot's buffer where possible
+	c.records[c.next] = append(c.records[c.next][:0], p...)
+	c.next++
+	if c.next == len(c.records) {
+		c.next = 0
+		c.full = true
+	}
+}
+
+// DumpRecent writes the records kept by WithCrashRing to w as a stream in the
+// default framing, oldest record first. It returns ErrRecordTooLarge, and
+// writes nothing, if a record doesn't fit in the default length prefix.
+// DumpRecent may be called from another goroutine than the one writing
+// records.
+func (w *Writer) DumpRecent(dst io.Writer) error {
+	if w.ring == nil {
+		return errors.New("writer was not created with WithCrashRing")
+	}
+
+	c := w.ring
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var records [][]byte
+	if c.full {
+		records = append(records, c.records[c.next:]...)
+	}
+	records = append(records, c.records[:c.next]...)
+
+	buf, err := Encode(nil, records)
+	if err != nil {
+		return err
+	}
+
+	_, err = dst.Write(buf)
+	return err
+}
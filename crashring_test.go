@@ -0,0 +1,47 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrashRing(t *testing.T) {
+	w := NewWriter(io.Discard, WithCrashRing(5))
+
+	// fewer records than the ring holds
+	for i := 0; i < 3; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	dump := bytes.NewBuffer([]byte{})
+	require.NoError(t, w.DumpRecent(dump))
+	records, _, err := Decode(dump.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("record 0"), []byte("record 1"), []byte("record 2")}, records)
+
+	// wrap around the ring
+	for i := 3; i < 23; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	dump.Reset()
+	require.NoError(t, w.DumpRecent(dump))
+
+	r := NewReader(dump)
+	readBuffer := make([]byte, 100)
+	for i := 18; i < 23; i++ {
+		n, err := r.Read(readBuffer)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(readBuffer[:n]))
+	}
+	_, err = r.Read(readBuffer)
+	require.ErrorIs(t, err, io.EOF)
+
+	require.Error(t, NewWriter(io.Discard).DumpRecent(dump))
+}
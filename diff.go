@@ -0,0 +1,78 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+)
+
+// DiffKind describes how two streams differ at a given record.
+type DiffKind int
+
+// Kinds of differences reported by Diff.
+const (
+	// DiffChanged means both streams have a record at the index but the
+	// payloads differ.
+	DiffChanged DiffKind = iota
+	// DiffOnlyInA means the first stream has a record at the index and the
+	// second stream has ended.
+	DiffOnlyInA
+	// DiffOnlyInB means the second stream has a record at the index and the
+	// first stream has ended.
+	DiffOnlyInB
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case DiffChanged:
+		return "changed"
+	case DiffOnlyInA:
+		return "only in a"
+	case DiffOnlyInB:
+		return "only in b"
+	default:
+		return "unknown"
+	}
+}
+
+// DiffEntry is a single difference found by Diff. A and B hold the payloads
+// of the two streams at Index, and are nil for a stream that has ended.
+type DiffEntry struct {
+	Index int64
+	Kind  DiffKind
+	A     []byte
+	B     []byte
+}
+
+// Diff compares two record streams record by record and returns the
+// differences. Records are compared by position, so an inserted or removed
+// record shows up as a change of every following record. Diff stops after
+// limit differences have been found; a limit of zero or less means no limit.
+func Diff(a, b *Reader, limit int) ([]DiffEntry, error) {
+	var diffs []DiffEntry
+
+	for index := int64(0); limit <= 0 || len(diffs) < limit; index++ {
+		recA, err := a.readRecord()
+		if err != nil && err != io.EOF {
+			return diffs, err
+		}
+		doneA := err == io.EOF
+
+		recB, err := b.readRecord()
+		if err != nil && err != io.EOF {
+			return diffs, err
+		}
+		doneB := err == io.EOF
+
+		switch {
+		case doneA && doneB:
+			return diffs, nil
+		case doneB:
+			diffs = append(diffs, DiffEntry{Index: index, Kind: DiffOnlyInA, A: recA})
+		case doneA:
+			diffs = append(diffs, DiffEntry{Index: index, Kind: DiffOnlyInB, B: recB})
+		case !bytes.Equal(recA, recB):
+			diffs = append(diffs, DiffEntry{Index: index, Kind: DiffChanged, A: recA, B: recB})
+		}
+	}
+	return diffs, nil
+}
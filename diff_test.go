@@ -0,0 +1,50 @@
+package recio
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func recordStream(t *testing.T, records ...string) *Reader {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for _, rec := range records {
+		_, err := w.Write([]byte(rec))
+		require.NoError(t, err)
+	}
+	return NewReader(bytes.NewReader(buf.Bytes()))
+}
+
+func TestDiff(t *testing.T) {
+	a := []string{"one", "two", "three", "four", "five"}
+	b := []string{"one", "TWO", "three", "four", "FIVE", "six", "seven"}
+
+	diffs, err := Diff(recordStream(t, a...), recordStream(t, b...), 0)
+	require.NoError(t, err)
+	require.Equal(t, []DiffEntry{
+		{Index: 1, Kind: DiffChanged, A: []byte("two"), B: []byte("TWO")},
+		{Index: 4, Kind: DiffChanged, A: []byte("five"), B: []byte("FIVE")},
+		{Index: 5, Kind: DiffOnlyInB, B: []byte("six")},
+		{Index: 6, Kind: DiffOnlyInB, B: []byte("seven")},
+	}, diffs)
+
+	// swapping the streams swaps the sides
+	diffs, err = Diff(recordStream(t, b...), recordStream(t, a...), 0)
+	require.NoError(t, err)
+	require.Len(t, diffs, 4)
+	require.Equal(t, DiffOnlyInA, diffs[2].Kind)
+	require.Equal(t, "six", string(diffs[2].A))
+
+	// the limit caps the number of reported differences
+	diffs, err = Diff(recordStream(t, a...), recordStream(t, b...), 2)
+	require.NoError(t, err)
+	require.Len(t, diffs, 2)
+	require.Equal(t, int64(4), diffs[1].Index)
+
+	// identical streams have no differences
+	diffs, err = Diff(recordStream(t, a...), recordStream(t, a...), 0)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+}
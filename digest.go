@@ -0,0 +1,24 @@
+package recio
+
+import "crypto/sha256"
+
+// NextWithDigest reads the next record and returns it together with the
+// SHA-256 digest of the payload, for use as a key in content addressed
+// storage. The returned payload is owned by the caller.
+func (r *Reader) NextWithDigest() ([]byte, [sha256.Size]byte, error) {
+	var digest [sha256.Size]byte
+
+	payload, err := r.readRecord()
+	if err != nil {
+		return nil, digest, err
+	}
+
+	if r.digest == nil {
+		r.digest = sha256.New()
+	}
+	r.digest.Reset()
+	r.digest.Write(payload)
+	r.digest.Sum(digest[:0])
+
+	return payload, digest, nil
+}
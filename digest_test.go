@@ -0,0 +1,35 @@
+package recio
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextWithDigest(t *testing.T) {
+	payloads := make([][]byte, 20)
+
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for i := range payloads {
+		payloads[i] = make([]byte, rand.Intn(4096))
+		rand.Read(payloads[i])
+		_, err := w.Write(payloads[i])
+		require.NoError(t, err)
+	}
+
+	r := NewReader(bytes.NewReader(buf.Bytes()))
+	for _, expected := range payloads {
+		payload, digest, err := r.NextWithDigest()
+		require.NoError(t, err)
+		require.Equal(t, expected, payload)
+		require.Equal(t, sha256.Sum256(expected), digest)
+	}
+
+	_, _, err := r.NextWithDigest()
+	require.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,120 @@
+package recio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Encode appends the records, framed as opts describe, to dst and returns
+// the extended slice, reusing the capacity of dst where possible. The
+// framing is exactly what a Writer created with opts writes, stream header
+// and footers included, so records that don't fit in the length prefix fail
+// with ErrRecordTooLarge. On error dst is returned as it was. Decode with
+// the same options reverses Encode.
+func Encode(dst []byte, records [][]byte, opts ...Option) ([]byte, error) {
+	out := &appendWriter{b: dst}
+	w := NewWriter(out, opts...)
+	for _, rec := range records {
+		_, err := w.Write(rec)
+		if err != nil {
+			return dst, err
+		}
+	}
+
+	err := w.Close()
+	if err != nil {
+		return dst, err
+	}
+	return out.b, nil
+}
+
+// defaultChecksumFlag is the checksum flag of the default length prefix.
+const defaultChecksumFlag = 1 << 31
+
+// appendWriter appends everything written to it to b.
+type appendWriter struct {
+	b []byte
+}
+
+func (a *appendWriter) Write(p []byte) (int, error) {
+	a.b = append(a.b, p...)
+	return len(p), nil
+}
+
+// Decode parses as many complete records as possible from data and returns
+// them along with the number of bytes they occupy. A partial record at the
+// end of data is not an error: it is left unconsumed so the caller can
+// retry once more data has arrived. Other errors are returned along with
+// the records before them.
+//
+// Without options, data is taken to use the default framing and the
+// returned records are slices of data. A length prefix with its highest bit
+// set, which marks a checksummed record or one begun by BeginRecord that
+// was never committed, can't be read that way, and Decode returns an error
+// wrapping ErrChecksumMismatch or ErrAbandonedRecord.
+//
+// With options, data is read as a Reader created with them would, and the
+// records are copied. Options that keep state from record to record, or a
+// stream header, need data to hold the stream from its start, so the retry
+// has to pass all of it again rather than what follows the bytes consumed.
+func Decode(data []byte, opts ...Option) ([][]byte, int, error) {
+	if len(opts) > 0 {
+		return decodeWith(data, opts)
+	}
+
+	var (
+		records  [][]byte
+		consumed int
+	)
+
+	for {
+		rest := data[consumed:]
+		if len(rest) < 4 {
+			return records, consumed, nil
+		}
+
+		length := uint64(binary.LittleEndian.Uint32(rest))
+		if length == defaultChecksumFlag {
+			return records, consumed, ErrAbandonedRecord
+		}
+		if length&defaultChecksumFlag != 0 {
+			return records, consumed, fmt.Errorf("%w: record has a checksum, which Decode can't verify", ErrChecksumMismatch)
+		}
+		if length > uint64(len(rest)-4) {
+			return records, consumed, nil
+		}
+
+		end := 4 + int(length)
+		records = append(records, rest[4:end:end])
+		consumed += end
+	}
+}
+
+// decodeWith implements Decode for the framing described by opts.
+func decodeWith(data []byte, opts []Option) ([][]byte, int, error) {
+	var (
+		records  [][]byte
+		consumed int
+	)
+
+	r := NewReader(bytes.NewReader(data), opts...)
+	for {
+		rec, err := r.ReadRecord()
+		if err == io.EOF {
+			return records, len(data), nil
+		}
+
+		// the rest of the stream, or its footers, are yet to come
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, ErrMissingFooter) || errors.Is(err, ErrMissingCountFooter) {
+			return records, consumed, nil
+		}
+		if err != nil {
+			return records, consumed, err
+		}
+		records = append(records, rec)
+		consumed = int(r.count.n)
+	}
+}
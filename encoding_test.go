@@ -0,0 +1,204 @@
+package recio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	a := NewArenaWriter(0)
+	a.Write([]byte("first"))
+	a.Write([]byte{})
+	a.Write([]byte("third"))
+	complete := a.Len()
+
+	// add a partial record
+	a.Write([]byte("truncated"))
+	data := a.Bytes()[:complete+6]
+
+	records, consumed, err := Decode(data)
+	require.NoError(t, err)
+	require.Equal(t, complete, consumed)
+	require.Equal(t, [][]byte{[]byte("first"), {}, []byte("third")}, records)
+
+	// a partial length prefix is left unconsumed too
+	records, consumed, err = Decode(data[:2])
+	require.NoError(t, err)
+	require.Zero(t, consumed)
+	require.Empty(t, records)
+
+	// prefixes with the checksum flag set can't be decoded
+	checksummed, err := Encode(nil, [][]byte{[]byte("checked")}, WithChecksum())
+	require.NoError(t, err)
+	records, consumed, err = Decode(append(a.Bytes()[:complete:complete], checksummed...))
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+	require.Equal(t, complete, consumed)
+	require.Len(t, records, 3)
+
+	_, _, err = Decode([]byte{0, 0, 0, 0x80, 'x'})
+	require.ErrorIs(t, err, ErrAbandonedRecord)
+}
+
+func FuzzDecode(f *testing.F) {
+	a := NewArenaWriter(0)
+	a.Write([]byte("hello"))
+	a.Write([]byte{})
+	f.Add(a.Bytes())
+	f.Add([]byte{})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0x00})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		records, consumed, err := Decode(data)
+		if err != nil {
+			return
+		}
+		require.LessOrEqual(t, consumed, len(data))
+
+		// the decoded records must frame back into exactly the consumed bytes
+		a := NewArenaWriter(consumed)
+		for _, rec := range records {
+			a.Write(rec)
+		}
+		require.True(t, bytes.Equal(data[:consumed], a.Bytes()))
+	})
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name    string
+		records [][]byte
+	}{
+		{name: "none"},
+		{name: "single", records: [][]byte{[]byte("hello")}},
+		{name: "empty records", records: [][]byte{{}, {}, {}}},
+		{name: "mixed", records: [][]byte{[]byte("a"), {}, bytes.Repeat([]byte("b"), 70000)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			prefix := []byte("existing")
+			data, err := Encode(append([]byte{}, prefix...), test.records)
+			require.NoError(t, err)
+			require.Equal(t, prefix, data[:len(prefix)])
+
+			records, consumed, err := Decode(data[len(prefix):])
+			require.NoError(t, err)
+			require.Equal(t, len(data)-len(prefix), consumed)
+			require.Len(t, records, len(test.records))
+			for i := range records {
+				require.Equal(t, test.records[i], records[i])
+			}
+		})
+	}
+}
+
+func FuzzEncodeDecode(f *testing.F) {
+	f.Add([]byte("hello world"), uint8(3))
+	f.Add([]byte{}, uint8(1))
+
+	f.Fuzz(func(t *testing.T, data []byte, size uint8) {
+		// split the input into records of at most size bytes
+		var records [][]byte
+		for len(data) > 0 {
+			n := int(size)
+			if n == 0 {
+				records = append(records, []byte{})
+				n = 1
+			}
+			if n > len(data) {
+				n = len(data)
+			}
+			records = append(records, data[:n])
+			data = data[n:]
+		}
+
+		encoded, err := Encode(nil, records)
+		require.NoError(t, err)
+
+		decoded, consumed, err := Decode(encoded)
+		require.NoError(t, err)
+		require.Equal(t, len(encoded), consumed)
+		require.Len(t, decoded, len(records))
+		for i := range records {
+			require.True(t, bytes.Equal(records[i], decoded[i]))
+		}
+	})
+}
+
+func TestEncodeOptions(t *testing.T) {
+	records := [][]byte{[]byte("first"), {}, bytes.Repeat([]byte("x"), 300)}
+
+	for _, opts := range [][]Option{
+		{WithChecksum()},
+		{WithVarintLength(), WithStreamGuard()},
+		{WithHeader(), WithSyncMarkers(), WithCountFooter()},
+		{WithLengthFieldSize(2), WithByteOrder(binary.BigEndian)},
+	} {
+		// Encode frames records exactly as the Writer does
+		var buf bytes.Buffer
+		w := NewWriter(&buf, opts...)
+		for _, rec := range records {
+			_, err := w.Write(rec)
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+
+		data, err := Encode(nil, records, opts...)
+		require.NoError(t, err)
+		require.Equal(t, buf.Bytes(), data)
+
+		r := NewReader(bytes.NewReader(data), opts...)
+		for _, want := range records {
+			got, err := r.ReadRecord()
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+		}
+		_, err = r.ReadRecord()
+		require.ErrorIs(t, err, io.EOF)
+
+		// and so does Decode with the same options
+		decoded, consumed, err := Decode(data, opts...)
+		require.NoError(t, err)
+		require.Equal(t, len(data), consumed)
+		require.Equal(t, records, decoded)
+
+		// leaving a partial record for later
+		decoded, consumed, err = Decode(data[:len(data)-1], opts...)
+		require.NoError(t, err)
+		require.Less(t, consumed, len(data))
+		require.Less(t, len(decoded), len(records))
+		require.Equal(t, records[:len(decoded)], decoded)
+	}
+}
+
+func TestEncodeTooLarge(t *testing.T) {
+	dst := []byte("existing")
+
+	// a one byte prefix holds lengths up to 127
+	data, err := Encode(dst, [][]byte{[]byte("ok"), make([]byte, 128)}, WithLengthFieldSize(1))
+	require.ErrorIs(t, err, ErrRecordTooLarge)
+	require.Equal(t, dst, data)
+
+	// the default prefix reserves its highest bit for the checksum flag
+	size := uint64(1 << 31)
+	if size > math.MaxInt {
+		t.Skip("slices can't be that large on this platform")
+	}
+	if raceEnabled {
+		t.Skip("the race detector refuses slices that outgrow their memory")
+	}
+	b := make([]byte, 1)
+	huge := unsafe.Slice(&b[0], int(size))
+
+	_, err = Encode(nil, [][]byte{huge})
+	require.ErrorIs(t, err, ErrRecordTooLarge)
+
+	_, err = NewArenaWriter(0).Write(huge)
+	require.ErrorIs(t, err, ErrRecordTooLarge)
+}
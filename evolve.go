@@ -0,0 +1,57 @@
+package recio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrNoMigrator = errors.New("no migrator for schema version")
+
+// EvolvingReader reads records written with WithSchemaVersion and upgrades
+// each of them to the latest schema version, so consumers only ever see the
+// latest schema even if the stream contains records of older versions.
+type EvolvingReader struct {
+	reader    *Reader
+	migrators map[uint8]func([]byte) ([]byte, error)
+	latest    uint8
+}
+
+// NewEvolvingReader returns an EvolvingReader reading records from r. The
+// migrator registered for version v upgrades a payload from version v to
+// version v+1, so a record of version v is passed through the migrators for
+// v, v+1, ..., latest-1 in turn.
+func NewEvolvingReader(r io.Reader, migrators map[uint8]func([]byte) ([]byte, error), latest uint8, opts ...Option) *EvolvingReader {
+	return &EvolvingReader{
+		reader:    NewReader(r, opts...),
+		migrators: migrators,
+		latest:    latest,
+	}
+}
+
+// Next reads the next record and returns its payload migrated to the latest
+// schema version. It returns an error wrapping ErrNoMigrator if a migrator
+// on the way is missing or the record is newer than the latest version.
+func (e *EvolvingReader) Next() ([]byte, error) {
+	version, payload, err := e.reader.ReadVersioned()
+	if err != nil {
+		return nil, err
+	}
+
+	if version > e.latest {
+		return nil, fmt.Errorf("%w: version %d is newer than %d", ErrNoMigrator, version, e.latest)
+	}
+
+	for ; version < e.latest; version++ {
+		migrate, ok := e.migrators[version]
+		if !ok {
+			return nil, fmt.Errorf("%w: version %d", ErrNoMigrator, version)
+		}
+
+		payload, err = migrate(payload)
+		if err != nil {
+			return nil, fmt.Errorf("migrating from version %d: %w", version, err)
+		}
+	}
+	return payload, nil
+}
@@ -0,0 +1,49 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvolvingReader(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	NewWriter(buf, WithSchemaVersion(1)).Write([]byte("a"))
+	NewWriter(buf, WithSchemaVersion(2)).Write([]byte("b:v2"))
+	NewWriter(buf, WithSchemaVersion(3)).Write([]byte("c:v2:v3"))
+	NewWriter(buf, WithSchemaVersion(1)).Write([]byte("d"))
+	data := buf.Bytes()
+
+	migrators := map[uint8]func([]byte) ([]byte, error){
+		1: func(p []byte) ([]byte, error) { return append(p, ":v2"...), nil },
+		2: func(p []byte) ([]byte, error) { return append(p, ":v3"...), nil },
+	}
+
+	r := NewEvolvingReader(bytes.NewReader(data), migrators, 3)
+	for _, expected := range []string{"a:v2:v3", "b:v2:v3", "c:v2:v3", "d:v2:v3"} {
+		payload, err := r.Next()
+		require.NoError(t, err)
+		require.Equal(t, expected, string(payload))
+	}
+	_, err := r.Next()
+	require.ErrorIs(t, err, io.EOF)
+
+	// without the migrator from version 1, version 1 records fail
+	r = NewEvolvingReader(bytes.NewReader(data), map[uint8]func([]byte) ([]byte, error){2: migrators[2]}, 3)
+	_, err = r.Next()
+	require.ErrorIs(t, err, ErrNoMigrator)
+	payload, err := r.Next()
+	require.NoError(t, err)
+	require.Equal(t, "b:v2:v3", string(payload))
+
+	// records newer than the latest version can't be migrated
+	r = NewEvolvingReader(bytes.NewReader(data), migrators, 2)
+	_, err = r.Next()
+	require.NoError(t, err)
+	_, err = r.Next()
+	require.NoError(t, err)
+	_, err = r.Next()
+	require.ErrorIs(t, err, ErrNoMigrator)
+}
@@ -0,0 +1,116 @@
+package recio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+var (
+	ErrNoExtendedHeader = errors.New("extended header frames require WithExtendedHeader")
+	ErrMalformedHeader  = errors.New("malformed record header")
+)
+
+// WithExtendedHeader replaces the default framing with a self-describing
+// header for every record:
+//
+//	[payload length uvarint][type uvarint][flags byte][payload]
+//
+// Both varints use the encoding of encoding/binary. The payload length and
+// the type must fit in 32 bits. Records are written with WriteFrameExt and
+// read with ReadFrameExt; Write writes type 0 with no flags, and the plain
+// read methods return the payload without the type and flags. The layout
+// has no room for the other framing options, so with WithStreamGuard,
+// WithSchemaVersion, WithMerkleChain, WithPrevFrameCRC, WithChecksum,
+// WithSyncMarkers, WithTypeTag or WithChunking every Write and Read fails
+// with an error wrapping ErrInvalidOptions.
+func WithExtendedHeader() Option {
+	return func(o *options) {
+		o.extendedHeader = true
+	}
+}
+
+// WriteFrameExt writes payload as a record with the given type and flags.
+// The writer must have been created with WithExtendedHeader.
+func (w *Writer) WriteFrameExt(typ uint32, flags byte, payload []byte) error {
+	if !w.opts.extendedHeader {
+		return ErrNoExtendedHeader
+	}
+	if w.err != nil {
+		return w.err
+	}
+
+	p, err := w.transform(payload)
+	if err != nil {
+		return err
+	}
+
+	if uint64(len(p)) > math.MaxUint32 {
+		return fmt.Errorf("%w: %d byte payload does not fit in the length prefix", ErrRecordTooLarge, len(p))
+	}
+
+	w.frame = appendExtFrame(w.frame[:0], typ, flags, p)
+	w.frame = w.appendPadding(w.frame, w.offset)
+
+	_, err = w.writeFrame(w.frame)
+	if err != nil {
+		return w.flushOnError(err)
+	}
+	return w.recordWritten(payload, len(w.frame))
+}
+
+// appendExtFrame appends an extended header frame to dst.
+func appendExtFrame(dst []byte, typ uint32, flags byte, payload []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(payload)))
+	dst = binary.AppendUvarint(dst, uint64(typ))
+	dst = append(dst, flags)
+	return append(dst, payload...)
+}
+
+// ReadFrameExt reads the next record along with its type and flags. The
+// reader must have been created with WithExtendedHeader.
+func (r *Reader) ReadFrameExt() (uint32, byte, []byte, error) {
+	if !r.opts.extendedHeader {
+		return 0, 0, nil, ErrNoExtendedHeader
+	}
+
+	payload, err := r.readRecord()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return r.extType, r.extFlags, payload, nil
+}
+
+// readExtHeader reads an extended header, remembers its type and flags and
+// returns the payload length.
+func (r *Reader) readExtHeader() (uint32, error) {
+	length, err := r.readUvarint(!r.midFrame())
+	if err != nil {
+		return 0, err
+	}
+	if length > math.MaxUint32 {
+		return 0, fmt.Errorf("%w: payload length %d does not fit in 32 bits", ErrMalformedHeader, length)
+	}
+
+	typ, err := r.readUvarint(false)
+	if err != nil {
+		return 0, err
+	}
+	if typ > math.MaxUint32 {
+		return 0, fmt.Errorf("%w: type %d does not fit in 32 bits", ErrMalformedHeader, typ)
+	}
+
+	flags, err := r.readByte()
+	if err == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	r.extType = uint32(typ)
+	r.extFlags = flags
+	return uint32(length), nil
+}
@@ -0,0 +1,78 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtendedHeader(t *testing.T) {
+	frames := []struct {
+		typ     uint32
+		flags   byte
+		payload []byte
+	}{
+		{0, 0, []byte("zero")},
+		{1, 0x01, []byte("one")},
+		{127, 0x80, []byte{}},
+		{300, 0xff, bytes.Repeat([]byte{'x'}, 200)},
+		{math.MaxUint32, 0x55, []byte("max")},
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithExtendedHeader())
+	for _, f := range frames {
+		require.NoError(t, w.WriteFrameExt(f.typ, f.flags, f.payload))
+	}
+
+	r := NewReader(bytes.NewReader(buf.Bytes()), WithExtendedHeader())
+	for _, f := range frames {
+		typ, flags, payload, err := r.ReadFrameExt()
+		require.NoError(t, err)
+		require.Equal(t, f.typ, typ)
+		require.Equal(t, f.flags, flags)
+		require.Equal(t, f.payload, payload)
+	}
+	_, _, _, err := r.ReadFrameExt()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestExtendedHeaderLayout(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithExtendedHeader())
+	require.NoError(t, w.WriteFrameExt(300, 0x05, []byte("hi")))
+	require.Equal(t, []byte{0x02, 0xac, 0x02, 0x05, 'h', 'i'}, buf.Bytes())
+
+	// Write uses type 0 without flags
+	buf.Reset()
+	_, err := w.Write([]byte("abc"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x03, 0x00, 0x00, 'a', 'b', 'c'}, buf.Bytes())
+
+	// the plain read methods return just the payload
+	p := make([]byte, 10)
+	n, err := NewReader(bytes.NewReader(buf.Bytes()), WithExtendedHeader()).Read(p)
+	require.NoError(t, err)
+	require.Equal(t, []byte("abc"), p[:n])
+}
+
+func TestExtendedHeaderErrors(t *testing.T) {
+	require.ErrorIs(t, NewWriter(io.Discard).WriteFrameExt(1, 0, nil), ErrNoExtendedHeader)
+	_, _, _, err := NewReader(bytes.NewReader(nil)).ReadFrameExt()
+	require.ErrorIs(t, err, ErrNoExtendedHeader)
+
+	// truncated header
+	_, _, _, err = NewReader(bytes.NewReader([]byte{0x02, 0xac}), WithExtendedHeader()).ReadFrameExt()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// length that does not fit in 32 bits
+	_, _, _, err = NewReader(bytes.NewReader([]byte{0x80, 0x80, 0x80, 0x80, 0x10, 0, 0}), WithExtendedHeader()).ReadFrameExt()
+	require.ErrorIs(t, err, ErrMalformedHeader)
+
+	// varint that never ends
+	_, _, _, err = NewReader(bytes.NewReader(bytes.Repeat([]byte{0xff}, 11)), WithExtendedHeader()).ReadFrameExt()
+	require.ErrorIs(t, err, ErrMalformedHeader)
+}
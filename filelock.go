@@ -0,0 +1,24 @@
+package recio
+
+import "errors"
+
+var (
+	ErrLocked          = errors.New("file is locked by another writer")
+	ErrLockUnsupported = errors.New("file locking is not supported on this platform")
+)
+
+// WithFileLock makes OpenForAppend take an exclusive lock on the file before
+// reading it, so that two writers can't append to the same log at once. If
+// another writer holds the lock, OpenForAppend fails at once with an error
+// matching ErrLocked rather than wait for it. The lock is held until the
+// Writer is closed, which closes the file, and is released if OpenForAppend
+// fails.
+//
+// The lock is advisory, taken with flock(2), and only keeps out writers that
+// ask for it too. NewWriter, which is not given the file, ignores the option.
+// On platforms without flock OpenForAppend fails with ErrLockUnsupported.
+func WithFileLock() Option {
+	return func(o *options) {
+		o.fileLock = true
+	}
+}
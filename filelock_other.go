@@ -0,0 +1,11 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package recio
+
+import "os"
+
+func lockFile(*os.File) error {
+	return ErrLockUnsupported
+}
+
+func unlockFile(*os.File) {}
@@ -0,0 +1,48 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package recio
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLock(t *testing.T) {
+	f := createTemp(t)
+	_, err := NewWriter(f).Write([]byte("record"))
+	require.NoError(t, err)
+
+	open := func() *os.File {
+		f, err := os.OpenFile(f.Name(), os.O_RDWR, 0)
+		require.NoError(t, err)
+		t.Cleanup(func() { f.Close() })
+		return f
+	}
+
+	w, err := OpenForAppend(open(), WithFileLock())
+	require.NoError(t, err)
+
+	// a second writer is kept out until the first is closed
+	second := open()
+	_, err = OpenForAppend(second, WithFileLock())
+	require.ErrorIs(t, err, ErrLocked)
+
+	_, err = w.Write([]byte("appended"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	w, err = OpenForAppend(second, WithFileLock())
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	// a failed open releases the lock
+	bad := open()
+	_, err = bad.WriteAt([]byte{0xff}, 0)
+	require.NoError(t, err)
+	_, err = OpenForAppend(bad, WithFileLock(), WithMaxRecordSize(16))
+	require.Error(t, err)
+	_, err = OpenForAppend(open(), WithFileLock(), WithMaxRecordSize(16))
+	require.ErrorIs(t, err, ErrRecordTooLarge)
+}
@@ -0,0 +1,126 @@
+package recio
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// followPollInterval is how often a reader created by NewFollowReader checks
+// the file for new records once it has read all there is.
+const followPollInterval = 50 * time.Millisecond
+
+// NewFollowReader returns a Reader that follows f as records are appended to
+// it, like tail -f. Instead of returning io.EOF at the end of the file, a
+// read waits until more is written, polling the file for new data, so a
+// record that has only been written in part is returned once it is
+// complete. Reading stops when the Reader is closed, which closes f and may
+// be done from another goroutine to end a blocked read with io.EOF, or when
+// the context given to ReadContext is done.
+func NewFollowReader(f *os.File, opts ...Option) *Reader {
+	return NewReadCloser(newFollower(f, followPollInterval), opts...)
+}
+
+// follower is an io.ReadCloser that waits at the end of a file for more
+// data. It supports read deadlines, so ReadContext can interrupt it.
+type follower struct {
+	f    *os.File
+	poll time.Duration
+
+	mu       sync.Mutex
+	deadline time.Time
+
+	// wake is signalled when the deadline changes, closed when the
+	// follower is closed.
+	wake      chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newFollower(f *os.File, poll time.Duration) *follower {
+	return &follower{
+		f:      f,
+		poll:   poll,
+		wake:   make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+}
+
+func (fl *follower) Read(p []byte) (int, error) {
+	for {
+		n, err := fl.f.Read(p)
+		if fl.isClosed() {
+			return 0, io.EOF
+		}
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+
+		err = fl.wait()
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// wait waits for the next poll, or until the deadline passes or the
+// follower is closed.
+func (fl *follower) wait() error {
+	fl.mu.Lock()
+	deadline := fl.deadline
+	fl.mu.Unlock()
+
+	d := fl.poll
+	if !deadline.IsZero() {
+		left := time.Until(deadline)
+		if left <= 0 {
+			return os.ErrDeadlineExceeded
+		}
+		d = min(d, left)
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-fl.wake:
+	case <-fl.closed:
+		return io.EOF
+	}
+	return nil
+}
+
+// SetReadDeadline makes reads waiting for more data fail with
+// os.ErrDeadlineExceeded once t has passed. The zero time clears the
+// deadline.
+func (fl *follower) SetReadDeadline(t time.Time) error {
+	fl.mu.Lock()
+	fl.deadline = t
+	fl.mu.Unlock()
+
+	select {
+	case fl.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (fl *follower) isClosed() bool {
+	select {
+	case <-fl.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close ends a read that is waiting and closes the file.
+func (fl *follower) Close() error {
+	err := os.ErrClosed
+	fl.closeOnce.Do(func() {
+		close(fl.closed)
+		err = fl.f.Close()
+	})
+	return err
+}
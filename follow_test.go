@@ -0,0 +1,102 @@
+package recio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newFollowFile returns a file to append records to and a follower of it.
+func newFollowFile(t *testing.T, opts ...Option) (*os.File, *Reader) {
+	filename := filepath.Join(t.TempDir(), "follow.seq")
+	out, err := os.Create(filename)
+	require.NoError(t, err)
+	t.Cleanup(func() { out.Close() })
+
+	in, err := os.Open(filename)
+	require.NoError(t, err)
+	r := NewFollowReader(in, opts...)
+	t.Cleanup(func() { r.Close() })
+	return out, r
+}
+
+func TestFollowReader(t *testing.T) {
+	out, r := newFollowFile(t, WithChecksum())
+
+	const records = 20
+	go func() {
+		w := NewWriter(out, WithChecksum())
+		for i := 0; i < records; i++ {
+			_, err := w.Write([]byte(fmt.Sprintf("record %02d", i)))
+			if err != nil {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	for i := 0; i < records; i++ {
+		rec, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %02d", i), string(rec))
+	}
+
+	// closing the reader ends a read that is waiting for more records
+	done := make(chan error)
+	go func() {
+		_, err := r.ReadRecord()
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, r.Close())
+	require.Equal(t, io.EOF, <-done)
+}
+
+func TestFollowReaderPartialFrame(t *testing.T) {
+	out, r := newFollowFile(t)
+
+	var frame bytes.Buffer
+	_, err := NewWriter(&frame).Write([]byte("a record written in two parts"))
+	require.NoError(t, err)
+
+	// the first part holds the length prefix and part of the payload
+	_, err = out.Write(frame.Bytes()[:10])
+	require.NoError(t, err)
+
+	done := make(chan []byte)
+	go func() {
+		rec, err := r.ReadRecord()
+		if err != nil {
+			close(done)
+			return
+		}
+		done <- rec
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("read returned before the record was complete")
+	case <-time.After(2 * followPollInterval):
+	}
+
+	_, err = out.Write(frame.Bytes()[10:])
+	require.NoError(t, err)
+	require.Equal(t, "a record written in two parts", string(<-done))
+}
+
+func TestFollowReaderContext(t *testing.T) {
+	_, r := newFollowFile(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := r.ReadContext(ctx, make([]byte, 100))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
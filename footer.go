@@ -0,0 +1,303 @@
+package recio
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"io"
+)
+
+// footerSize is the size of the footer written by WithFooterChecksum: a
+// magic number followed by a SHA-256 digest.
+const footerSize = len(footerMagic) + sha256.Size
+
+var footerMagic = [8]byte{'r', 'e', 'c', 'i', 'o', 'f', 't', 'r'}
+
+var (
+	ErrMissingFooter  = errors.New("stream does not end with a footer checksum")
+	ErrFooterMismatch = errors.New("footer checksum mismatch")
+)
+
+// WithFooterChecksum makes Close on the writer end the stream with a footer
+// holding the SHA-256 digest of every byte written before it, so that a
+// single check confirms that a file is complete and unaltered, e.g. after a
+// transfer. Use VerifyFooter to check the footer of a file.
+//
+// A reader created with the same option computes the digest as it reads
+// and returns io.EOF at the footer only if it matches. It returns
+// ErrFooterMismatch if it does not and ErrMissingFooter if the stream ends
+// without a footer. The digest covers the whole stream, so a reader that
+// seeks away from the start only checks that the footer is there.
+//
+// The digest is of what the writer wrote from the start of the stream,
+// which makes the option a poor fit for WithAppendOnly and in place updates.
+func WithFooterChecksum() Option {
+	return func(o *options) {
+		o.footerChecksum = true
+	}
+}
+
+// VerifyFooter checks that the size bytes of r end with a footer written by
+// WithFooterChecksum and that the footer matches the rest of r. It returns
+// ErrMissingFooter if there is no footer, for example because the file is
+// truncated, and ErrFooterMismatch if anything before the footer has
+// changed.
+func VerifyFooter(r io.ReaderAt, size int64) error {
+	if size < int64(footerSize) {
+		return ErrMissingFooter
+	}
+
+	var footer [footerSize]byte
+	_, err := r.ReadAt(footer[:], size-int64(footerSize))
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if !bytes.Equal(footer[:len(footerMagic)], footerMagic[:]) {
+		return ErrMissingFooter
+	}
+
+	digest := sha256.New()
+	_, err = io.Copy(digest, io.NewSectionReader(r, 0, size-int64(footerSize)))
+	if err != nil {
+		return err
+	}
+
+	var sum [sha256.Size]byte
+	if !bytes.Equal(digest.Sum(sum[:0]), footer[len(footerMagic):]) {
+		return ErrFooterMismatch
+	}
+	return nil
+}
+
+// footerWriter hashes the bytes written to the underlying writer.
+type footerWriter struct {
+	w      io.Writer
+	digest hash.Hash
+}
+
+func (f *footerWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.digest.Write(p[:n])
+	return n, err
+}
+
+// reset makes f hash a new stream written to w.
+func (f *footerWriter) reset(w io.Writer) {
+	f.w = w
+	f.digest.Reset()
+}
+
+// writeFooter writes the footers of WithCountFooter and WithFooterChecksum,
+// in that order, unless they have been written already.
+func (w *Writer) writeFooter() error {
+	if w.footerDone || w.err != nil {
+		return nil
+	}
+	w.footerDone = true
+
+	if w.opts.countFooter {
+		footer := w.appendCountFooter(make([]byte, 0, countFooterSize))
+		_, err := w.writeFrame(footer)
+		if err != nil {
+			return err
+		}
+		w.offset += int64(len(footer))
+	}
+	if w.footer == nil {
+		return nil
+	}
+
+	// the digest only covers what has reached the underlying writer
+	err := w.Flush()
+	if err != nil {
+		return err
+	}
+
+	footer := make([]byte, 0, footerSize)
+	footer = append(footer, footerMagic[:]...)
+	footer = w.footer.digest.Sum(footer)
+
+	_, err = w.writeFrame(footer)
+	if err != nil {
+		return err
+	}
+	w.offset += int64(len(footer))
+	return nil
+}
+
+// footerReader holds back the footers at the end of the stream, of
+// WithCountFooter and WithFooterChecksum, checking them at the end of the
+// stream. With WithFooterChecksum it hashes the bytes read from the
+// underlying reader. With WithTrailerAware it holds back room for both and
+// works out at the end of the stream which of them are there.
+type footerReader struct {
+	r      io.Reader
+	size   int
+	count  bool
+	digest hash.Hash
+	sum    bool
+	check  bool
+
+	// the footers the options ask for, and whether to look for others
+	wantCount bool
+	wantSum   bool
+	aware     bool
+
+	// trailer is the count footer, if there was one
+	trailer    CountFooter
+	hasTrailer bool
+
+	// buf[start:end] has been read from r but not returned yet
+	buf        []byte
+	start, end int
+	eof        bool
+	err        error
+}
+
+func newFooterReader(r io.Reader, o *options) *footerReader {
+	f := &footerReader{
+		wantCount: o.countFooter,
+		wantSum:   o.footerChecksum,
+		aware:     o.trailerAware,
+	}
+	if f.wantSum || f.aware {
+		f.digest = sha256.New()
+	}
+	f.reset(r, true)
+	return f
+}
+
+func (f *footerReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for {
+		if f.end-f.start > f.size {
+			n := copy(p, f.buf[f.start:f.end-f.size])
+			if f.digest != nil {
+				f.digest.Write(p[:n])
+			}
+			f.start += n
+			return n, nil
+		}
+		if f.err != nil {
+			return 0, f.err
+		}
+		if f.eof {
+			f.err = f.verify()
+			return 0, f.err
+		}
+
+		// move what is held to the front, making room for at least p
+		if len(f.buf) < f.size+len(p) {
+			buf := make([]byte, f.size+len(p))
+			f.end = copy(buf, f.buf[f.start:f.end])
+			f.buf = buf
+		} else {
+			f.end = copy(f.buf, f.buf[f.start:f.end])
+		}
+		f.start = 0
+
+		n, err := f.r.Read(f.buf[f.end:])
+		f.end += n
+		if err == io.EOF {
+			f.eof = true
+			if f.aware {
+				f.detect()
+			}
+		} else if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// detect works out which footers end the stream for WithTrailerAware, and
+// holds back only those.
+func (f *footerReader) detect() {
+	tail := f.buf[f.start:f.end]
+
+	f.size = 0
+	f.sum = f.wantSum || hasMagic(tail, footerSize, footerMagic)
+	if f.sum {
+		f.size += footerSize
+	}
+	if len(tail) > f.size {
+		tail = tail[:len(tail)-f.size]
+	} else {
+		tail = nil
+	}
+	f.count = f.wantCount || hasMagic(tail, countFooterSize, countFooterMagic)
+	if f.count {
+		f.size += countFooterSize
+	}
+}
+
+// hasMagic reports whether b ends with a footer of the given size that
+// starts with magic.
+func hasMagic(b []byte, size int, magic [8]byte) bool {
+	return len(b) >= size && bytes.Equal(b[len(b)-size:][:len(magic)], magic[:])
+}
+
+// verify checks the footers held back at the end of the stream and returns
+// io.EOF if they are good.
+func (f *footerReader) verify() error {
+	footer := f.buf[f.start:f.end]
+
+	var checksum []byte
+	if f.sum {
+		if len(footer) < footerSize {
+			return ErrMissingFooter
+		}
+		checksum = footer[len(footer)-footerSize:]
+		footer = footer[:len(footer)-footerSize]
+		if !bytes.Equal(checksum[:len(footerMagic)], footerMagic[:]) {
+			return ErrMissingFooter
+		}
+	}
+
+	if f.count {
+		if len(footer) < countFooterSize || !bytes.Equal(footer[:len(countFooterMagic)], countFooterMagic[:]) {
+			return ErrMissingCountFooter
+		}
+		f.trailer = parseCountFooter(footer)
+		f.hasTrailer = true
+	}
+	if !f.sum || !f.check {
+		return io.EOF
+	}
+
+	// the digest covers the count footer as well
+	f.digest.Write(footer)
+	var sum [sha256.Size]byte
+	if !bytes.Equal(f.digest.Sum(sum[:0]), checksum[len(footerMagic):]) {
+		return ErrFooterMismatch
+	}
+	return io.EOF
+}
+
+// reset makes f read a new stream from r. The digest is only checked if the
+// stream is read from the start.
+func (f *footerReader) reset(r io.Reader, fromStart bool) {
+	f.r = r
+	f.start, f.end = 0, 0
+	f.eof = false
+	f.err = nil
+	if f.digest != nil {
+		f.digest.Reset()
+	}
+	f.check = fromStart
+
+	f.count = f.wantCount
+	f.sum = f.wantSum
+	f.size = 0
+	if f.count || f.aware {
+		f.size += countFooterSize
+	}
+	if f.sum || f.aware {
+		f.size += footerSize
+	}
+	f.trailer = CountFooter{}
+	f.hasTrailer = false
+}
@@ -0,0 +1,100 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFooterFile(t *testing.T, opts ...Option) []byte {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, append([]Option{WithFooterChecksum()}, opts...)...)
+	for i := 0; i < 10; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	// closing again does not write a second footer
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func readFooterFile(data []byte, opts ...Option) (int, error) {
+	r := NewReader(bytes.NewReader(data), append([]Option{WithFooterChecksum()}, opts...)...)
+	count := 0
+	for {
+		_, err := r.ReadRecord()
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+func TestFooterChecksum(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithWriteBuffer(16), WithReadBuffer(16)},
+		{WithHeader(), WithChecksum()},
+	} {
+		data := writeFooterFile(t, opts...)
+		require.NoError(t, VerifyFooter(bytes.NewReader(data), int64(len(data))))
+
+		count, err := readFooterFile(data, opts...)
+		require.Equal(t, io.EOF, err)
+		require.Equal(t, 10, count)
+	}
+}
+
+func TestFooterChecksumTruncated(t *testing.T) {
+	data := writeFooterFile(t)
+
+	for _, size := range []int{len(data) - 1, len(data) - footerSize, 3, 0} {
+		err := VerifyFooter(bytes.NewReader(data), int64(size))
+		require.ErrorIs(t, err, ErrMissingFooter)
+
+		_, err = readFooterFile(data[:size])
+		require.ErrorIs(t, err, ErrMissingFooter)
+	}
+}
+
+func TestFooterChecksumCorrupt(t *testing.T) {
+	data := writeFooterFile(t)
+	i := bytes.Index(data, []byte("record 7"))
+	require.Positive(t, i)
+	data[i+7] ^= 0x01
+
+	err := VerifyFooter(bytes.NewReader(data), int64(len(data)))
+	require.ErrorIs(t, err, ErrFooterMismatch)
+
+	count, err := readFooterFile(data)
+	require.ErrorIs(t, err, ErrFooterMismatch)
+	require.Equal(t, 10, count)
+}
+
+func TestFooterChecksumSeek(t *testing.T) {
+	data := writeFooterFile(t)
+	r := NewReader(bytes.NewReader(data), WithFooterChecksum())
+
+	_, err := r.ReadRecord()
+	require.NoError(t, err)
+	pos, err := r.Seek(0, io.SeekCurrent)
+	require.NoError(t, err)
+	require.EqualValues(t, 4+8, pos)
+
+	// after seeking into the stream only the presence of the footer is
+	// checked
+	_, err = r.Seek(pos, io.SeekStart)
+	require.NoError(t, err)
+	for i := 1; i < 10; i++ {
+		rec, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(rec))
+	}
+	_, err = r.ReadRecord()
+	require.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,50 @@
+package recio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// frameCRCSize is the size of the previous frame CRC stored in each record.
+const frameCRCSize = 4
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// WithPrevFrameCRC stores the CRC32 (Castagnoli) of the previous record's
+// complete frame at the start of every record body, using zero for the
+// first record. A reader created with the same option returns
+// ErrFrameChainBroken when the stored value does not match the frame it
+// just read, which detects records that have been inserted, removed or
+// reordered. It is much cheaper than WithMerkleChain but only protects
+// against accidents, not tampering. The CRC costs 4 bytes per record and is
+// included in the length prefix.
+func WithPrevFrameCRC() Option {
+	return func(o *options) {
+		o.prevFrameCRC = true
+	}
+}
+
+// readPrevFrameCRC reads the previous frame CRC stored at the start of a
+// record body and checks it against the frame that was read before it.
+func (r *Reader) readPrevFrameCRC(length uint64) (uint64, error) {
+	if length < frameCRCSize {
+		return 0, fmt.Errorf("%w: record is too short to hold a frame CRC", ErrFrameChainBroken)
+	}
+
+	var stored [frameCRCSize]byte
+	_, err := io.ReadFull(r.reader, stored[:])
+	if err == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	got := binary.LittleEndian.Uint32(stored[:])
+	if got != r.prevCRC {
+		return 0, fmt.Errorf("%w: expected 0x%08x, got 0x%08x", ErrFrameChainBroken, r.prevCRC, got)
+	}
+	return length - frameCRCSize, nil
+}
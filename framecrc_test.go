@@ -0,0 +1,83 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrevFrameCRC(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithPrevFrameCRC(), WithStreamGuard())
+	for i := 0; i < 10; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	r := NewReader(bytes.NewReader(buf.Bytes()), WithPrevFrameCRC(), WithStreamGuard())
+	readBuffer := make([]byte, 100)
+	for i := 0; i < 10; i++ {
+		n, err := r.Read(readBuffer)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(readBuffer[:n]))
+	}
+	_, err := r.Read(readBuffer)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestPrevFrameCRCRemovedRecord(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithPrevFrameCRC())
+	for i := 0; i < 10; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+	data := buf.Bytes()
+
+	// remove record 4 from the stream
+	frameSize := 4 + frameCRCSize + len("record 0")
+	dropped := append(append([]byte{}, data[:4*frameSize]...), data[5*frameSize:]...)
+
+	r := NewReader(bytes.NewReader(dropped), WithPrevFrameCRC())
+	readBuffer := make([]byte, 100)
+	for i := 0; i < 4; i++ {
+		_, err := r.Read(readBuffer)
+		require.NoError(t, err)
+	}
+	_, err := r.Read(readBuffer)
+	require.ErrorIs(t, err, ErrFrameChainBroken)
+	require.Contains(t, err.Error(), "record 4")
+}
+
+func TestPrevFrameCRCSyncMarkers(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithSyncMarkers(), WithPrevFrameCRC()},
+		{WithSyncMarkers(), WithPrevFrameCRC(), WithStreamGuard(), WithChecksum()},
+		{WithSyncMarkers(), WithPrevFrameCRC(), WithChunking(4)},
+		{WithSyncMarkers(), WithPrevFrameCRC(), WithAlignment(8)},
+	} {
+		buf := bytes.NewBuffer([]byte{})
+		w := NewWriter(buf, opts...)
+		for i := 0; i < 10; i++ {
+			_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+			require.NoError(t, err)
+		}
+		_, err := w.WriteLen(len("streamed"), bytes.NewReader([]byte("streamed")))
+		require.NoError(t, err)
+
+		r := NewReader(bytes.NewReader(buf.Bytes()), opts...)
+		for i := 0; i < 10; i++ {
+			rec, err := r.ReadRecord()
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("record %d", i), string(rec))
+		}
+		rec, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.Equal(t, "streamed", string(rec))
+		_, err = r.ReadRecord()
+		require.ErrorIs(t, err, io.EOF)
+	}
+}
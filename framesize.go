@@ -0,0 +1,88 @@
+package recio
+
+import (
+	"encoding/binary"
+)
+
+// FrameSize returns the number of bytes that a record with a payloadLen byte
+// payload takes up in a stream written with opts, alignment padding
+// included. With WithChunking it covers every chunk of the record. A
+// compressed size can't be known in advance, so with WithCodec payloadLen is
+// taken to be the length of the compressed payload. The stream header and
+// footer are written once per stream rather than per record, see
+// StreamOverhead.
+func FrameSize(payloadLen int, opts ...Option) int {
+	o := newOptions(opts)
+	if o.chunkSize <= 0 || payloadLen <= o.chunkSize {
+		return o.frameSize(payloadLen)
+	}
+
+	chunks := payloadLen / o.chunkSize
+	size := chunks * o.frameSize(o.chunkSize)
+	if last := payloadLen % o.chunkSize; last > 0 {
+		size += o.frameSize(last)
+	}
+	return size
+}
+
+// StreamOverhead returns the number of bytes that a stream written with
+// opts takes up on top of its records: the stream header of WithHeader and
+// the footers of WithCountFooter and WithFooterChecksum. The size of a
+// stream is StreamOverhead plus the FrameSize of every record.
+func StreamOverhead(opts ...Option) int {
+	o := newOptions(opts)
+
+	size := 0
+	if o.header {
+		size += o.padded(headerSize)
+	}
+	if o.countFooter {
+		size += countFooterSize
+	}
+	if o.footerChecksum {
+		size += footerSize
+	}
+	return size
+}
+
+// frameSize returns the size of the frame, padding included, for a single
+// n byte chunk of a record.
+func (o *options) frameSize(n int) int {
+	if o.aead != nil {
+		n += o.aead.NonceSize() + o.aead.Overhead()
+	}
+
+	if o.extendedHeader {
+		// the type of records written by Write is 0, a single byte
+		var prefix [binary.MaxVarintLen64]byte
+		return o.padded(binary.PutUvarint(prefix[:], uint64(n)) + 2 + n)
+	}
+
+	size := 0
+	if o.syncMarkers {
+		size += len(syncMarker)
+	}
+	if o.streamGuard {
+		size++
+	}
+
+	l := o.frameLength(n)
+	if o.varintLength {
+		if o.checksum {
+			l |= o.checksumFlag()
+		}
+		var prefix [binary.MaxVarintLen64]byte
+		size += binary.PutUvarint(prefix[:], l)
+	} else {
+		size += o.lengthSize
+	}
+	return o.padded(size + int(o.frameLength(n)))
+}
+
+// padded returns n rounded up to the alignment of WithAlignment.
+func (o *options) padded(n int) int {
+	if o.alignment < 2 {
+		return n
+	}
+	return (n + o.alignment - 1) / o.alignment * o.alignment
+}
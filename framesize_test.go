@@ -0,0 +1,46 @@
+package recio
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameSize(t *testing.T) {
+	lengths := []int{0, 1, 5, 127, 128, 300, 1000, 20000}
+
+	for _, opts := range [][]Option{
+		nil,
+		{WithChecksum()},
+		{WithLength64()},
+		{WithLengthFieldSize(2), WithStreamGuard()},
+		{WithVarintLength(), WithChecksum()},
+		{WithAlignment(8), WithHeader(), WithChecksum()},
+		{WithAlignment(64), WithVarintLength()},
+		{WithSyncMarkers(), WithMerkleChain(), WithPrevFrameCRC()},
+		{WithTypeTag(), WithSchemaVersion(1)},
+		{WithChunking(100), WithChecksum()},
+		{WithExtendedHeader(), WithAlignment(4)},
+		{WithProtobufFraming()},
+		{WithFooterChecksum(), WithHeader()},
+		{WithAEAD(newTestAEAD(t), nil)},
+	} {
+		// every record the writer emits is as large as FrameSize says, and
+		// the stream as large as all of them together
+		var buf bytes.Buffer
+		w := NewWriter(&buf, opts...)
+		want := StreamOverhead(opts...)
+		for _, n := range lengths {
+			before := buf.Len()
+			_, err := w.Write(bytes.Repeat([]byte{0xa5}, n))
+			require.NoError(t, err)
+			if before > 0 {
+				require.Equal(t, buf.Len()-before, FrameSize(n, opts...), "%d byte payload", n)
+			}
+			want += FrameSize(n, opts...)
+		}
+		require.NoError(t, w.Close())
+		require.Equal(t, want, buf.Len())
+	}
+}
@@ -0,0 +1,49 @@
+package recio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// FramingError is returned by the Reader when a record is not framed the
+// way the options say it should be, or cannot be returned as framed. Offset
+// is where the record starts in the stream and Index its number, counting
+// from 0. Err is the cause, such as ErrTargetBufferTooSmall or
+// io.ErrUnexpectedEOF, and can be matched with errors.Is.
+type FramingError struct {
+	Offset int64
+	Index  int64
+	Err    error
+}
+
+func (e *FramingError) Error() string {
+	return fmt.Sprintf("record %d at offset %d: %v", e.Index, e.Offset, e.Err)
+}
+
+func (e *FramingError) Unwrap() error {
+	return e.Err
+}
+
+// framingError wraps err in a FramingError for the current record. io.EOF
+// at a record boundary is not a framing error and is returned as is, as
+// are errors that already carry their position.
+func (r *Reader) framingError(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+
+	var fe *FramingError
+	if errors.As(err, &fe) {
+		return err
+	}
+	return r.observeError(&FramingError{Offset: r.recordOffset, Index: r.index, Err: err})
+}
+
+// observeError reports err to the observer and returns it.
+func (r *Reader) observeError(err error) error {
+	if r.opts.observer != nil {
+		r.opts.observer.OnError(err)
+	}
+	return err
+}
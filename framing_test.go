@@ -0,0 +1,78 @@
+package recio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFramingErrorTooSmall(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithNoSkipOnTooSmall()},
+		{WithCodec(failingCodec{})},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, opts...)
+		var offset int
+		for i, rec := range []string{"a", "bb", "a record that is too large", "ccc"} {
+			if i == 2 {
+				offset = buf.Len()
+			}
+			_, err := w.WriteString(rec)
+			require.NoError(t, err)
+		}
+
+		r := NewReader(&buf, opts...)
+		p := make([]byte, 10)
+		for _, want := range []string{"a", "bb"} {
+			n, err := r.Read(p)
+			require.NoError(t, err)
+			require.Equal(t, want, string(p[:n]))
+		}
+
+		_, err := r.Read(p)
+		require.ErrorIs(t, err, ErrTargetBufferTooSmall)
+
+		var fe *FramingError
+		require.True(t, errors.As(err, &fe))
+		require.EqualValues(t, 2, fe.Index)
+		require.EqualValues(t, offset, fe.Offset)
+		require.Contains(t, err.Error(), fmt.Sprintf("record 2 at offset %d", offset))
+	}
+}
+
+func TestFramingErrorTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithChecksum())
+	for _, rec := range []string{"first", "second"} {
+		_, err := w.WriteString(rec)
+		require.NoError(t, err)
+	}
+	data := buf.Bytes()[:buf.Len()-2]
+
+	r := NewReader(bytes.NewReader(data), WithChecksum())
+	_, err := r.ReadRecord()
+	require.NoError(t, err)
+
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	var fe *FramingError
+	require.True(t, errors.As(err, &fe))
+	require.EqualValues(t, 1, fe.Index)
+	require.EqualValues(t, 4+5+4, fe.Offset)
+
+	// the end of the stream at a record boundary is not a framing error
+	r = NewReader(bytes.NewReader(buf.Bytes()), WithChecksum())
+	for i := 0; i < 2; i++ {
+		_, err = r.ReadRecord()
+		require.NoError(t, err)
+	}
+	_, err = r.ReadRecord()
+	require.Equal(t, io.EOF, err)
+}
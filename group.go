@@ -0,0 +1,44 @@
+package recio
+
+import "io"
+
+// GroupBy reads the remaining records and calls fn once for every run of
+// consecutive records that share the same key, passing the records in the
+// order they were read. The key of a record is computed by keyFn.
+//
+// The stream must already be sorted (or at least grouped) by key: records
+// with the same key that are not adjacent end up in separate groups. Only one
+// group is held in memory at a time.
+func (r *Reader) GroupBy(keyFn func([]byte) string, fn func(key string, records [][]byte) error) error {
+	var (
+		key   string
+		group [][]byte
+	)
+
+	for {
+		rec, err := r.readRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		k := keyFn(rec)
+		if len(group) > 0 && k != key {
+			err := fn(key, group)
+			if err != nil {
+				return err
+			}
+			group = nil
+		}
+
+		key = k
+		group = append(group, rec)
+	}
+
+	if len(group) > 0 {
+		return fn(key, group)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package recio
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupBy(t *testing.T) {
+	records := []string{"a:1", "a:2", "a:3", "b:1", "c:1", "c:2"}
+
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for _, rec := range records {
+		_, err := w.Write([]byte(rec))
+		require.NoError(t, err)
+	}
+
+	keyFn := func(rec []byte) string {
+		return strings.SplitN(string(rec), ":", 2)[0]
+	}
+
+	var keys []string
+	groups := make(map[string][]string)
+
+	r := NewReader(bytes.NewReader(buf.Bytes()))
+	err := r.GroupBy(keyFn, func(key string, records [][]byte) error {
+		keys = append(keys, key)
+		for _, rec := range records {
+			groups[key] = append(groups[key], string(rec))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"a", "b", "c"}, keys)
+	require.Equal(t, []string{"a:1", "a:2", "a:3"}, groups["a"])
+	require.Equal(t, []string{"b:1"}, groups["b"])
+	require.Equal(t, []string{"c:1", "c:2"}, groups["c"])
+
+	// errors from fn stop the iteration
+	errStop := errors.New("stop")
+	calls := 0
+	r = NewReader(bytes.NewReader(buf.Bytes()))
+	err = r.GroupBy(keyFn, func(string, [][]byte) error {
+		calls++
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+	require.Equal(t, 1, calls)
+}
@@ -0,0 +1,61 @@
+package recio
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// OpenMaybeGzip opens the file at path for reading records. If the file
+// starts with the gzip magic bytes or its name ends in ".gz", it is
+// transparently decompressed, including files made of several concatenated
+// gzip members. This handles whole-file compression, as produced by running
+// gzip on a stream. Closing the returned Reader closes the file.
+func OpenMaybeGzip(path string, opts ...Option) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		f.Close()
+		return nil, err
+	}
+
+	if !bytes.Equal(magic, gzipMagic) && !strings.HasSuffix(path, ".gz") {
+		r := NewReader(br, opts...)
+		r.closer = f
+		return r, nil
+	}
+
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	r := NewReader(zr, opts...)
+	r.closer = &gzipFileCloser{zr: zr, f: f}
+	return r, nil
+}
+
+type gzipFileCloser struct {
+	zr *gzip.Reader
+	f  *os.File
+}
+
+func (c *gzipFileCloser) Close() error {
+	zerr := c.zr.Close()
+	err := c.f.Close()
+	if zerr != nil {
+		return zerr
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package recio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeRecordFile(t *testing.T, filename string, compress bool, numRecords int) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for i := 0; i < numRecords; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	f, err := os.Create(filename)
+	require.NoError(t, err)
+	defer f.Close()
+
+	if !compress {
+		_, err = f.Write(buf.Bytes())
+		require.NoError(t, err)
+		return
+	}
+
+	zw := gzip.NewWriter(f)
+	_, err = zw.Write(buf.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+}
+
+func TestOpenMaybeGzip(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]bool{
+		"plain.seq":         false,
+		"compressed.seq.gz": true,
+		// detected by the magic bytes rather than the extension
+		"compressed.seq": true,
+	}
+
+	for name, compress := range files {
+		filename := filepath.Join(dir, name)
+		writeRecordFile(t, filename, compress, 50)
+
+		r, err := OpenMaybeGzip(filename)
+		require.NoError(t, err)
+
+		readBuffer := make([]byte, 100)
+		for i := 0; ; i++ {
+			n, err := r.Read(readBuffer)
+			if err == io.EOF {
+				require.Equal(t, 50, i, name)
+				break
+			}
+			require.NoError(t, err, name)
+			require.Equal(t, fmt.Sprintf("record %d", i), string(readBuffer[:n]), name)
+		}
+		require.NoError(t, r.Close())
+	}
+
+	// an empty plain file is an empty stream
+	filename := filepath.Join(dir, "empty.seq")
+	require.NoError(t, os.WriteFile(filename, nil, 0o644))
+	r, err := OpenMaybeGzip(filename)
+	require.NoError(t, err)
+	_, err = r.Read(make([]byte, 10))
+	require.ErrorIs(t, err, io.EOF)
+	require.NoError(t, r.Close())
+
+	_, err = OpenMaybeGzip(filepath.Join(dir, "missing.seq"))
+	require.Error(t, err)
+}
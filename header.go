@@ -0,0 +1,153 @@
+package recio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// headerMagic starts every stream written using WithHeader.
+	headerMagic = "RCIO"
+
+	// headerVersion is the newest version of the stream header that is
+	// supported, which is the version that is written. Version 1 headers
+	// consist of the magic and the version, version 2 adds the ID of the
+	// codec in use and version 3 a byte of framing flags.
+	headerVersion = 3
+
+	headerSize = len(headerMagic) + 3
+)
+
+// Framing flags stored in the stream header.
+const (
+	headerFlagLength64 = 1 << iota
+	headerFlagVarintLength
+	headerFlagLength8
+	headerFlagLength16
+	headerFlagEncrypted
+	headerFlagTypeTag
+	headerFlagChunked
+)
+
+var (
+	ErrBadMagic           = errors.New("stream does not start with the recio magic")
+	ErrUnsupportedVersion = errors.New("unsupported stream header version")
+	ErrFramingMismatch    = errors.New("stream was written with different framing options")
+)
+
+// WithHeader makes NewWriter write a stream header, consisting of the magic
+// "RCIO", a version byte, the ID of the Codec given with WithCodec, or 0, and
+// a byte recording the format of the length prefix, before the first
+// record, and makes the reader validate it before reading the first record.
+// A reader returns ErrBadMagic if the stream does not start with the magic,
+// for example because it is not a recio stream at all, ErrUnsupportedVersion
+// if it was written by a newer version of this package, ErrCodecMismatch if
+// it was written using a different codec and ErrFramingMismatch if it was
+// written with a different length prefix, see WithLengthFieldSize and
+// WithVarintLength. Writer and reader must both use it.
+//
+// Combined with WithAppendOnly the header is only written if the stream is
+// empty. An error writing the header is returned by every Write.
+func WithHeader() Option {
+	return func(o *options) {
+		o.header = true
+	}
+}
+
+// writeHeader writes the stream header.
+func (w *Writer) writeHeader() error {
+	header := append([]byte(headerMagic), headerVersion, codecID(w.opts.codec), w.opts.headerFlags())
+	header = w.appendPadding(header, 0)
+	_, err := w.writeFrame(header)
+	if err != nil {
+		return err
+	}
+	w.offset += int64(len(header))
+	return nil
+}
+
+// readStreamHeader reads and validates the stream header.
+func (r *Reader) readStreamHeader() error {
+	// read the part that is common to all versions first
+	var header [len(headerMagic) + 1]byte
+	_, err := io.ReadFull(r.reader, header[:])
+	if err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: stream is too short to hold a header", ErrBadMagic)
+	}
+	if err != nil {
+		return err
+	}
+
+	magic := header[:len(headerMagic)]
+	if string(magic) != headerMagic {
+		return fmt.Errorf("%w: got %q", ErrBadMagic, magic)
+	}
+
+	version := header[len(headerMagic)]
+	if version == 0 || version > headerVersion {
+		return fmt.Errorf("%w: %d, newest supported is %d", ErrUnsupportedVersion, version, headerVersion)
+	}
+
+	// version 1 streams predate codecs and version 2 streams flags
+	var codec, flags uint8
+	if version >= 2 {
+		codec, err = r.readHeaderByte()
+		if err != nil {
+			return err
+		}
+	}
+	if version >= 3 {
+		flags, err = r.readHeaderByte()
+		if err != nil {
+			return err
+		}
+	}
+
+	expected := codecID(r.opts.codec)
+	if codec != expected {
+		return fmt.Errorf("%w: stream uses codec %d, reader uses codec %d", ErrCodecMismatch, codec, expected)
+	}
+
+	if flags != r.opts.headerFlags() {
+		return fmt.Errorf("%w: stream has flags 0x%02x, reader expects 0x%02x", ErrFramingMismatch, flags, r.opts.headerFlags())
+	}
+
+	r.headerDone = true
+	return nil
+}
+
+// readHeaderByte reads a byte of the stream header.
+func (r *Reader) readHeaderByte() (byte, error) {
+	b, err := r.readByte()
+	if err == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return b, err
+}
+
+// headerFlags returns the framing flags that describe o.
+func (o *options) headerFlags() byte {
+	var flags byte
+	switch o.lengthSize {
+	case 1:
+		flags |= headerFlagLength8
+	case 2:
+		flags |= headerFlagLength16
+	case 8:
+		flags |= headerFlagLength64
+	}
+	if o.varintLength {
+		flags |= headerFlagVarintLength
+	}
+	if o.aead != nil {
+		flags |= headerFlagEncrypted
+	}
+	if o.typeTag {
+		flags |= headerFlagTypeTag
+	}
+	if o.chunkSize > 0 {
+		flags |= headerFlagChunked
+	}
+	return flags
+}
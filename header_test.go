@@ -0,0 +1,96 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeader(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithHeader())
+	require.Equal(t, []byte{'R', 'C', 'I', 'O', headerVersion, 0, 0}, buf.Bytes())
+
+	for i := 0; i < 3; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	r := NewReader(bytes.NewReader(buf.Bytes()), WithHeader())
+	p := make([]byte, 100)
+	for i := 0; i < 3; i++ {
+		n, err := r.Read(p)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(p[:n]))
+	}
+	_, err := r.Read(p)
+	require.Equal(t, io.EOF, err)
+
+	// seeking back to the start validates the header again
+	_, err = r.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	n, err := r.Read(p)
+	require.NoError(t, err)
+	require.Equal(t, "record 0", string(p[:n]))
+
+	// an empty stream has no records
+	_, err = NewReader(bytes.NewReader(nil), WithHeader()).Read(p)
+	require.Equal(t, io.EOF, err)
+}
+
+func TestHeaderBadMagic(t *testing.T) {
+	p := make([]byte, 100)
+
+	_, err := NewReader(bytes.NewReader([]byte("GIF89a...")), WithHeader()).Read(p)
+	require.ErrorIs(t, err, ErrBadMagic)
+
+	_, err = NewReader(bytes.NewReader([]byte("RC")), WithHeader()).Read(p)
+	require.ErrorIs(t, err, ErrBadMagic)
+}
+
+func TestHeaderUnsupportedVersion(t *testing.T) {
+	p := make([]byte, 100)
+
+	_, err := NewReader(bytes.NewReader([]byte{'R', 'C', 'I', 'O', headerVersion + 1}), WithHeader()).Read(p)
+	require.ErrorIs(t, err, ErrUnsupportedVersion)
+}
+
+func TestHeaderAppendOnly(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "header.seq")
+
+	for i := 0; i < 2; i++ {
+		f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0o644)
+		require.NoError(t, err)
+		_, err = NewWriter(f, WithHeader(), WithAppendOnly()).Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, 1, bytes.Count(data, []byte(headerMagic)))
+
+	r := NewReader(bytes.NewReader(data), WithHeader())
+	p := make([]byte, 100)
+	for i := 0; i < 2; i++ {
+		n, err := r.Read(p)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(p[:n]))
+	}
+}
+
+func TestHeaderVersion1(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{'R', 'C', 'I', 'O', 1})
+	_, err := NewWriter(buf).Write([]byte("record"))
+	require.NoError(t, err)
+
+	p := make([]byte, 100)
+	n, err := NewReader(bytes.NewReader(buf.Bytes()), WithHeader()).Read(p)
+	require.NoError(t, err)
+	require.Equal(t, "record", string(p[:n]))
+}
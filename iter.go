@@ -0,0 +1,43 @@
+package recio
+
+import (
+	"io"
+	"iter"
+)
+
+// Records returns an iterator over the remaining records, for use with
+// range:
+//
+//	for rec, err := range r.Records() {
+//		...
+//	}
+//
+// The sequence ends at the end of the stream. Any other error is yielded
+// along with a nil record, after which the sequence ends. The yielded slice
+// is only valid until the next iteration unless the Reader was created using
+// WithRecordCopies.
+func (r *Reader) Records() iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		for {
+			var rec []byte
+			var err error
+			if r.opts.recordCopies {
+				rec, err = r.readRecord()
+			} else {
+				rec, err = r.readBuffered()
+			}
+
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if !yield(rec, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecords(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for i := 0; i < 10; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	i := 0
+	for rec, err := range NewReader(bytes.NewReader(buf.Bytes())).Records() {
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(rec))
+		i++
+	}
+	require.Equal(t, 10, i)
+
+	// copies stay valid after the iteration
+	var records [][]byte
+	for rec, err := range NewReader(bytes.NewReader(buf.Bytes()), WithRecordCopies()).Records() {
+		require.NoError(t, err)
+		records = append(records, rec)
+	}
+	for i, rec := range records {
+		require.Equal(t, fmt.Sprintf("record %d", i), string(rec))
+	}
+
+	// stopping early
+	i = 0
+	for range NewReader(bytes.NewReader(buf.Bytes())).Records() {
+		i++
+		if i == 3 {
+			break
+		}
+	}
+	require.Equal(t, 3, i)
+}
+
+func TestRecordsError(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for i := 0; i < 3; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	var errs []error
+	n := 0
+	for rec, err := range NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-2])).Records() {
+		if err != nil {
+			require.Nil(t, rec)
+			errs = append(errs, err)
+			continue
+		}
+		n++
+	}
+	require.Equal(t, 2, n)
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[0], io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,106 @@
+package recio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrEmbeddedNewline = errors.New("record contains a newline and is not valid JSON")
+
+// DecodeInto reads the next record and unmarshals it as JSON into v. The same
+// v can be passed on every call to avoid allocating a new value per record.
+// Like json.Unmarshal, DecodeInto does not clear v first: fields missing from
+// the record keep their previous values and slices and maps are reused, so
+// callers that depend on a clean value must reset v themselves.
+func (r *Reader) DecodeInto(v any) error {
+	body, err := r.readBuffered()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// JSONLinesFrom reads the records of the recio stream r, read with opts,
+// and writes them to dst as JSON lines, each followed by a newline, one
+// record at a time. Records are not otherwise checked to be JSON.
+//
+// A newline in a record would split it over two lines. Newlines can only
+// occur in JSON as whitespace, so records that contain them are compacted
+// if they are valid JSON, and make JSONLinesFrom fail with
+// ErrEmbeddedNewline if they are not. Whatever was written before the
+// failing record is flushed to dst.
+func JSONLinesFrom(r io.Reader, dst io.Writer, opts ...Option) error {
+	reader := NewReader(r, opts...)
+	bw := bufio.NewWriter(dst)
+
+	var compacted bytes.Buffer
+	for i := 0; ; i++ {
+		rec, err := reader.Next()
+		if err == io.EOF {
+			return bw.Flush()
+		}
+		if err != nil {
+			bw.Flush()
+			return err
+		}
+
+		if bytes.IndexByte(rec, '\n') >= 0 {
+			compacted.Reset()
+			if json.Compact(&compacted, rec) != nil {
+				bw.Flush()
+				return fmt.Errorf("%w: record %d", ErrEmbeddedNewline, i)
+			}
+			rec = compacted.Bytes()
+		}
+
+		// a failed write makes the next one fail as well
+		bw.Write(rec)
+		err = bw.WriteByte('\n')
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// JSONLinesTo reads newline delimited lines from src and writes every line,
+// without its line ending, as a record to w, one line at a time. A final
+// line need not end in a newline. Empty lines are skipped, and lines are not
+// checked to be JSON. The records are flushed to w once src ends.
+func JSONLinesTo(src io.Reader, w *Writer) error {
+	br := bufio.NewReader(src)
+
+	var long []byte
+	for {
+		line, err := br.ReadSlice('\n')
+
+		// lines longer than the buffer are put together in long
+		if err == bufio.ErrBufferFull {
+			long = append(long, line...)
+			continue
+		}
+		if len(long) > 0 {
+			line = append(long, line...)
+			long = long[:0]
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		line = bytes.TrimSuffix(line, []byte{'\n'})
+		line = bytes.TrimSuffix(line, []byte{'\r'})
+		if len(line) > 0 {
+			_, werr := w.Write(line)
+			if werr != nil {
+				return werr
+			}
+		}
+
+		if err == io.EOF {
+			return w.Flush()
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package recio
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type jsonRecord struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func writeJSONRecords(t testing.TB, n int) []byte {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for i := 0; i < n; i++ {
+		data, err := json.Marshal(jsonRecord{ID: i, Name: "some record"})
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeInto(t *testing.T) {
+	r := NewReader(bytes.NewReader(writeJSONRecords(t, 100)))
+
+	var rec jsonRecord
+	count := 0
+	for {
+		err := r.DecodeInto(&rec)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, count, rec.ID)
+		require.Equal(t, "some record", rec.Name)
+		count++
+	}
+	require.Equal(t, 100, count)
+}
+
+func BenchmarkDecodeInto(b *testing.B) {
+	r := NewReader(bytes.NewReader(writeJSONRecords(b, b.N)))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var rec jsonRecord
+	for i := 0; i < b.N; i++ {
+		require.NoError(b, r.DecodeInto(&rec))
+	}
+}
+
+func BenchmarkDecodeFresh(b *testing.B) {
+	r := NewReader(bytes.NewReader(writeJSONRecords(b, b.N)))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		body, err := r.readRecord()
+		require.NoError(b, err)
+		rec := new(jsonRecord)
+		require.NoError(b, json.Unmarshal(body, rec))
+	}
+}
+
+func TestJSONLines(t *testing.T) {
+	stream := writeJSONRecords(t, 100)
+
+	var lines bytes.Buffer
+	require.NoError(t, JSONLinesFrom(bytes.NewReader(stream), &lines))
+	require.Equal(t, 100, bytes.Count(lines.Bytes(), []byte{'\n'}))
+
+	var buf bytes.Buffer
+	require.NoError(t, JSONLinesTo(&lines, NewWriter(&buf)))
+	require.Equal(t, stream, buf.Bytes())
+}
+
+func TestJSONLinesTo(t *testing.T) {
+	long := `{"name":"` + strings.Repeat("x", 10000) + `"}`
+	input := "{\"id\":1}\r\n\n" + long + "\n{\"id\":2}"
+
+	var buf bytes.Buffer
+	require.NoError(t, JSONLinesTo(strings.NewReader(input), NewWriter(&buf)))
+
+	r := NewReader(&buf)
+	for _, want := range []string{`{"id":1}`, long, `{"id":2}`} {
+		rec, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.Equal(t, want, string(rec))
+	}
+	_, err := r.ReadRecord()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestJSONLinesFromNewlines(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithChecksum())
+	for _, rec := range []string{`{"id":1}`, "{\n  \"id\": 2\n}", "not\njson", `{"id":4}`} {
+		_, err := w.WriteString(rec)
+		require.NoError(t, err)
+	}
+
+	// indented JSON is compacted onto one line, anything else is refused
+	var lines bytes.Buffer
+	err := JSONLinesFrom(&buf, &lines, WithChecksum())
+	require.ErrorIs(t, err, ErrEmbeddedNewline)
+	require.Equal(t, "{\"id\":1}\n{\"id\":2}\n", lines.String())
+}
@@ -0,0 +1,110 @@
+package recio
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrLimitExceeded = errors.New("record is larger than the memory limit")
+
+// Limiter bounds the memory that readers sharing it hold in records at any
+// one time. It is safe for concurrent use.
+type Limiter struct {
+	mu    sync.Mutex
+	freed *sync.Cond
+	max   int64
+	used  int64
+}
+
+// NewLimiter returns a Limiter that lets the readers using it hold at most
+// maxBytes of record data at once.
+func NewLimiter(maxBytes int64) *Limiter {
+	l := &Limiter{max: maxBytes}
+	l.freed = sync.NewCond(&l.mu)
+	return l
+}
+
+// InUse returns the number of bytes currently held by readers.
+func (l *Limiter) InUse() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.used
+}
+
+// acquire blocks until n bytes are available and takes them. A record
+// larger than the limit can never be read and fails at once.
+func (l *Limiter) acquire(n int64) error {
+	if n > l.max {
+		return fmt.Errorf("%w: %d bytes, limit is %d", ErrLimitExceeded, n, l.max)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.used+n > l.max {
+		l.freed.Wait()
+	}
+	l.used += n
+	return nil
+}
+
+// release gives back n bytes taken by acquire.
+func (l *Limiter) release(n int64) {
+	if n == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	l.used -= n
+	l.mu.Unlock()
+	l.freed.Broadcast()
+}
+
+// WithLimiter makes the reader take the memory for every record it reads
+// into memory from l, blocking until other readers sharing l have released
+// enough, so that many readers cannot collectively exhaust memory. A record
+// larger than the limit is skipped, and the read fails with
+// ErrLimitExceeded wrapped in a FramingError.
+//
+// The memory of a record is held until the next record is read, or the
+// reader is closed or reset, since that is as long as the record read by
+// e.g. Next is valid. A reader that is abandoned part way through a stream
+// must be closed to release it. Records streamed by NextReader or read into
+// the caller's buffer by Read are not held in memory by the reader and do
+// not count. With WithCodec the encoded size of the record counts.
+func WithLimiter(l *Limiter) Option {
+	return func(o *options) {
+		o.limiter = l
+	}
+}
+
+// reserve takes the memory for a record of length bytes from the limiter,
+// releasing that of the previous record.
+func (r *Reader) reserve(length uint64) error {
+	if r.opts.limiter == nil {
+		return nil
+	}
+
+	r.releaseReserved()
+	err := r.opts.limiter.acquire(int64(length))
+	if err != nil {
+		// skip the record, like one that does not fit the target buffer
+		tooLarge := &FramingError{Offset: r.recordOffset, Index: r.index, Err: err}
+		err := r.discardPayload(length)
+		if err != nil {
+			return err
+		}
+		return r.observeError(tooLarge)
+	}
+	r.reserved = int64(length)
+	return nil
+}
+
+// releaseReserved gives back the memory held for the last record read.
+func (r *Reader) releaseReserved() {
+	if r.opts.limiter == nil {
+		return
+	}
+	r.opts.limiter.release(r.reserved)
+	r.reserved = 0
+}
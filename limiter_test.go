@@ -0,0 +1,126 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterConcurrentReaders(t *testing.T) {
+	const (
+		readers    = 8
+		records    = 20
+		recordSize = 100
+		limit      = 3 * recordSize
+	)
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for i := 0; i < records; i++ {
+		_, err := w.Write(bytes.Repeat([]byte{byte(i)}, recordSize))
+		require.NoError(t, err)
+	}
+
+	l := NewLimiter(limit)
+	var peak atomic.Int64
+	var wg sync.WaitGroup
+	errs := make([]error, readers)
+
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := NewReader(bytes.NewReader(buf.Bytes()), WithLimiter(l))
+			defer r.Close()
+
+			for {
+				_, err := r.ReadRecord()
+				if err == io.EOF {
+					return
+				}
+				if err != nil {
+					errs[i] = err
+					return
+				}
+
+				used := l.InUse()
+				for {
+					p := peak.Load()
+					if used <= p || peak.CompareAndSwap(p, used) {
+						break
+					}
+				}
+				time.Sleep(100 * time.Microsecond)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	require.LessOrEqual(t, peak.Load(), int64(limit))
+	require.Positive(t, peak.Load())
+	require.Zero(t, l.InUse())
+}
+
+func TestLimiterBlocks(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	_, err := w.Write(make([]byte, 80))
+	require.NoError(t, err)
+
+	l := NewLimiter(100)
+	first := NewReader(bytes.NewReader(buf.Bytes()), WithLimiter(l), WithReadBuffer(256))
+	_, err = first.Next()
+	require.NoError(t, err)
+	require.EqualValues(t, 80, l.InUse())
+
+	done := make(chan error)
+	go func() {
+		second := NewReader(bytes.NewReader(buf.Bytes()), WithLimiter(l))
+		_, err := second.ReadRecord()
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("second reader did not wait for the first")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// reaching the end of the stream releases the first record
+	_, err = first.Next()
+	require.Equal(t, io.EOF, err)
+	require.NoError(t, <-done)
+	require.EqualValues(t, 80, l.InUse())
+}
+
+func TestLimiterRecordTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, size := range []int{200, 10} {
+		_, err := w.Write(make([]byte, size))
+		require.NoError(t, err)
+	}
+
+	l := NewLimiter(100)
+	r := NewReader(&buf, WithLimiter(l))
+	_, err := r.ReadRecord()
+	require.ErrorIs(t, err, ErrLimitExceeded)
+	require.Zero(t, l.InUse())
+
+	var fe *FramingError
+	require.ErrorAs(t, err, &fe)
+	require.EqualValues(t, 0, fe.Index)
+
+	// the record that is too large is skipped
+	rec, err := r.ReadRecord()
+	require.NoError(t, err)
+	require.Len(t, rec, 10)
+}
@@ -0,0 +1,111 @@
+package recio
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const merkleHashSize = sha256.Size
+
+// RootHash returns the chain hash of the last record written by a Writer
+// created with WithMerkleChain. Once the stream is complete this hash
+// commits to every record in it and to their order.
+func (w *Writer) RootHash() [sha256.Size]byte {
+	return w.chain
+}
+
+// nextChainHash returns the chain hash of a record with payload p following
+// the last record written.
+func (w *Writer) nextChainHash(p []byte) [merkleHashSize]byte {
+	var sum [merkleHashSize]byte
+
+	w.beginChainHash()
+	w.hasher.Write(p)
+	w.hasher.Sum(sum[:0])
+	return sum
+}
+
+// beginChainHash starts the chain hash of the next frame with the hash of
+// the last and the bytes of the frame that precede the payload and carry
+// meaning: the type tag, the chunk flag and the schema version.
+func (w *Writer) beginChainHash() {
+	w.hasher.Reset()
+	w.hasher.Write(w.chain[:])
+	if w.opts.typeTag {
+		w.hasher.Write([]byte{w.tag})
+	}
+	if w.opts.chunkSize > 0 {
+		w.hasher.Write([]byte{w.continued})
+	}
+	if w.opts.hasSchemaVersion {
+		w.hasher.Write([]byte{w.opts.schemaVersion})
+	}
+}
+
+// VerifyChain reads the remaining records and verifies that they form an
+// unbroken hash chain. It returns an error wrapping ErrChainBroken naming
+// the first record that does not match. The Reader must have been created
+// with WithMerkleChain.
+func (r *Reader) VerifyChain() error {
+	if !r.opts.merkleChain {
+		return errors.New("reader was not created with WithMerkleChain")
+	}
+
+	for {
+		_, err := r.readBuffered()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// beginChainRecord prepares the chain hash for a record whose length prefix
+// says length and returns the length of the part of the record preceding
+// the chain hash.
+func (r *Reader) beginChainRecord(length uint64) (uint64, error) {
+	if length < merkleHashSize {
+		return 0, fmt.Errorf("%w: record is too short to hold a chain hash", ErrChainBroken)
+	}
+
+	// the schema version is hashed with the payload as it is read
+	r.hasher.Reset()
+	r.hasher.Write(r.chain[:])
+	if r.opts.typeTag {
+		r.hasher.Write([]byte{r.tag})
+	}
+	if r.opts.chunkSize > 0 {
+		var flag byte
+		if r.continued {
+			flag = chunkContinued
+		}
+		r.hasher.Write([]byte{flag})
+	}
+	return length - merkleHashSize, nil
+}
+
+// verifyChainRecord reads the chain hash that follows a record and compares
+// it to the hash computed while the record was read.
+func (r *Reader) verifyChainRecord() error {
+	var stored [merkleHashSize]byte
+	_, err := io.ReadFull(r.reader, stored[:])
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+
+	var sum [merkleHashSize]byte
+	if !bytes.Equal(r.hasher.Sum(sum[:0]), stored[:]) {
+		return ErrChainBroken
+	}
+
+	r.chain = stored
+	return nil
+}
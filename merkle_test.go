@@ -0,0 +1,128 @@
+package recio
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerkleChain(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithMerkleChain())
+
+	var expected [sha256.Size]byte
+	for i := 0; i < 10; i++ {
+		payload := []byte(fmt.Sprintf("audit entry %d", i))
+		_, err := w.Write(payload)
+		require.NoError(t, err)
+
+		expected = sha256.Sum256(append(expected[:], payload...))
+	}
+	require.Equal(t, expected, w.RootHash())
+
+	data := buf.Bytes()
+
+	// records read back without the chain hash
+	r := NewReader(bytes.NewReader(data), WithMerkleChain())
+	readBuffer := make([]byte, 100)
+	for i := 0; ; i++ {
+		n, err := r.Read(readBuffer)
+		if err == io.EOF {
+			require.Equal(t, 10, i)
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("audit entry %d", i), string(readBuffer[:n]))
+	}
+
+	require.NoError(t, NewReader(bytes.NewReader(data), WithMerkleChain()).VerifyChain())
+
+	// tamper with the payload of record 5
+	tampered := append([]byte{}, data...)
+	frameSize := 4 + len("audit entry 0") + sha256.Size
+	tampered[5*frameSize+4] = 'A'
+
+	err := NewReader(bytes.NewReader(tampered), WithMerkleChain()).VerifyChain()
+	require.ErrorIs(t, err, ErrChainBroken)
+	require.Contains(t, err.Error(), "record 5")
+
+	// dropping a record breaks the chain as well
+	dropped := append(append([]byte{}, data[:3*frameSize]...), data[4*frameSize:]...)
+	err = NewReader(bytes.NewReader(dropped), WithMerkleChain()).VerifyChain()
+	require.ErrorIs(t, err, ErrChainBroken)
+	require.Contains(t, err.Error(), "record 3")
+}
+
+func TestMerkleChainFraming(t *testing.T) {
+	// the type tag and the chunk flag are chained as well
+	for _, tc := range []struct {
+		opts  []Option
+		at    int
+		value byte
+	}{
+		{[]Option{WithMerkleChain(), WithTypeTag()}, 4, TombstoneTag},
+		{[]Option{WithMerkleChain(), WithChunking(64)}, 4, chunkContinued},
+		{[]Option{WithMerkleChain(), WithTypeTag(), WithChunking(64), WithSchemaVersion(2)}, 5, chunkContinued},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, tc.opts...)
+		for i := 0; i < 3; i++ {
+			_, err := w.WriteTyped(1, []byte(fmt.Sprintf("entry %d", i)))
+			if err == ErrNoTypeTag {
+				_, err = w.Write([]byte(fmt.Sprintf("entry %d", i)))
+			}
+			require.NoError(t, err)
+		}
+		data := buf.Bytes()
+		require.NoError(t, NewReader(bytes.NewReader(data), tc.opts...).VerifyChain())
+
+		// turn record 1 into a tombstone, or into the first chunk of a
+		// record that goes on
+		tampered := append([]byte{}, data...)
+		frameSize := len(data) / 3
+		tampered[frameSize+tc.at] = tc.value
+
+		err := NewReader(bytes.NewReader(tampered), tc.opts...).VerifyChain()
+		require.ErrorIs(t, err, ErrChainBroken)
+		require.Contains(t, err.Error(), "record 1")
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithMerkleChain(), WithTypeTag())
+	for _, p := range []string{"key 1", "key 2"} {
+		_, err := w.WriteTyped(1, []byte(p))
+		require.NoError(t, err)
+	}
+	tampered := buf.Bytes()
+	tampered[len(tampered)/2+4] = TombstoneTag
+
+	r := NewReader(bytes.NewReader(tampered), WithMerkleChain(), WithTypeTag())
+	_, _, err := r.ReadTyped()
+	require.NoError(t, err)
+	_, _, err = r.ReadTyped()
+	require.ErrorIs(t, err, ErrChainBroken)
+}
+
+func TestMerkleChainNextReader(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithMerkleChain())
+	for i := 0; i < 3; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("audit entry %d", i)))
+		require.NoError(t, err)
+	}
+
+	r := NewReader(bytes.NewReader(buf.Bytes()), WithMerkleChain())
+	for i := 0; i < 3; i++ {
+		rr, err := r.NextReader()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rr)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("audit entry %d", i), string(data))
+	}
+	_, err := r.NextReader()
+	require.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,23 @@
+package recio
+
+// RecordMeta describes where a record was found in the stream.
+type RecordMeta struct {
+	// Index is the position of the record in the stream, counting from 0.
+	Index int64
+
+	// Offset is the byte offset at which the record's frame starts. This is
+	// the offset of the length prefix unless WithSyncMarkers or
+	// WithStreamGuard put bytes in front of it.
+	Offset int64
+}
+
+// ReadRecordMeta reads the next record like ReadRecord and also returns its
+// position in the stream. Offsets are counted from the bytes consumed, so
+// they are available on streams that can't seek too.
+func (r *Reader) ReadRecordMeta() ([]byte, RecordMeta, error) {
+	rec, err := r.readRecord()
+	if err != nil {
+		return nil, RecordMeta{}, err
+	}
+	return rec, RecordMeta{Index: r.index - 1, Offset: r.recordOffset}, nil
+}
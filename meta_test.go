@@ -0,0 +1,37 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadRecordMeta(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithHeader(), WithSyncMarkers(), WithStreamGuard()},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, append(opts, WithIndex())...)
+		for i := 0; i < 5; i++ {
+			_, err := w.WriteString(fmt.Sprintf("record %d %s", i, bytes.Repeat([]byte("x"), i)))
+			require.NoError(t, err)
+		}
+		offsets := w.Offsets()
+
+		// hide the bytes.Reader so the stream is not seekable
+		r := NewReader(struct{ io.Reader }{&buf}, opts...)
+		for i := 0; i < 5; i++ {
+			rec, meta, err := r.ReadRecordMeta()
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("record %d %s", i, bytes.Repeat([]byte("x"), i)), string(rec))
+			require.Equal(t, RecordMeta{Index: int64(i), Offset: offsets[i]}, meta)
+		}
+
+		_, _, err := r.ReadRecordMeta()
+		require.ErrorIs(t, err, io.EOF)
+	}
+}
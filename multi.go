@@ -0,0 +1,70 @@
+package recio
+
+import (
+	"io"
+	"os"
+)
+
+// NewMultiReader returns a Reader that reads the records in readers one
+// after the other, as if they were a single stream. It returns io.EOF once
+// the last of them is exhausted. Records must not be split across readers,
+// which holds for files that are rotated at record boundaries.
+//
+// Options apply to the combined stream: a stream header is only expected at
+// the start of the first reader, and options that carry state from one
+// record to the next, such as WithStreamGuard, carry it across readers.
+func NewMultiReader(readers []io.Reader, opts ...Option) *Reader {
+	return NewReader(io.MultiReader(readers...), opts...)
+}
+
+// NewSegmentReader returns a Reader that reads the files at paths as one
+// stream, like NewMultiReader. Each file is opened when reading reaches it
+// and closed at its end, so only one is open at a time. An error opening a
+// file is returned by the read that reaches it. Closing the Reader closes
+// the file that is open.
+func NewSegmentReader(paths []string, opts ...Option) *Reader {
+	return NewReadCloser(&segmentReader{paths: paths}, opts...)
+}
+
+// segmentReader reads a list of files one after the other.
+type segmentReader struct {
+	paths []string
+	f     *os.File
+}
+
+func (s *segmentReader) Read(p []byte) (int, error) {
+	for {
+		if s.f == nil {
+			if len(s.paths) == 0 {
+				return 0, io.EOF
+			}
+			f, err := os.Open(s.paths[0])
+			if err != nil {
+				return 0, err
+			}
+			s.f = f
+			s.paths = s.paths[1:]
+		}
+
+		n, err := s.f.Read(p)
+		if err != io.EOF {
+			return n, err
+		}
+
+		err = s.f.Close()
+		s.f = nil
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+func (s *segmentReader) Close() error {
+	s.paths = nil
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
@@ -0,0 +1,91 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeSegments writes records to three segment files in dir, and all of
+// them to a single file, returning the segment paths and the single file.
+func writeSegments(t *testing.T, dir string, opts ...Option) ([]string, string) {
+	var all bytes.Buffer
+	whole := NewWriter(&all, opts...)
+
+	var paths []string
+	record := 0
+	for i, count := range []int{3, 1, 4} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, opts...)
+		for j := 0; j < count; j++ {
+			rec := fmt.Sprintf("record %d", record)
+			record++
+			_, err := w.WriteString(rec)
+			require.NoError(t, err)
+			_, err = whole.WriteString(rec)
+			require.NoError(t, err)
+		}
+
+		path := filepath.Join(dir, fmt.Sprintf("data.%d.seq", i))
+		require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+		paths = append(paths, path)
+	}
+
+	path := filepath.Join(dir, "data.seq")
+	require.NoError(t, os.WriteFile(path, all.Bytes(), 0o644))
+	return paths, path
+}
+
+// readAll returns every record in r, failing on anything but io.EOF.
+func readAll(t *testing.T, r *Reader) []string {
+	var records []string
+	for {
+		rec, err := r.ReadRecord()
+		if err == io.EOF {
+			return records
+		}
+		require.NoError(t, err)
+		records = append(records, string(rec))
+	}
+}
+
+func TestSegmentReader(t *testing.T) {
+	paths, single := writeSegments(t, t.TempDir(), WithChecksum())
+
+	f, err := os.Open(single)
+	require.NoError(t, err)
+	want := readAll(t, NewReader(f, WithChecksum()))
+	require.Len(t, want, 8)
+
+	r := NewSegmentReader(paths, WithChecksum())
+	require.Equal(t, want, readAll(t, r))
+	require.EqualValues(t, 8, r.Stats().RecordsRead)
+	require.NoError(t, r.Close())
+
+	var readers []io.Reader
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		readers = append(readers, bytes.NewReader(data))
+	}
+	require.Equal(t, want, readAll(t, NewMultiReader(readers, WithChecksum())))
+}
+
+func TestSegmentReaderMissing(t *testing.T) {
+	paths, _ := writeSegments(t, t.TempDir())
+	paths[1] = filepath.Join(filepath.Dir(paths[1]), "missing.seq")
+
+	r := NewSegmentReader(paths)
+	defer r.Close()
+	for i := 0; i < 3; i++ {
+		_, err := r.ReadRecord()
+		require.NoError(t, err)
+	}
+	_, err := r.ReadRecord()
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
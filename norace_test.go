@@ -0,0 +1,5 @@
+//go:build !race
+
+package recio
+
+const raceEnabled = false
@@ -0,0 +1,31 @@
+package recio
+
+// Observer is notified of the records passing through a Writer or Reader,
+// for example to feed metrics. Its methods are called synchronously from
+// the writing or reading goroutine, so they should be quick.
+type Observer interface {
+	// OnWrite is called for every record written, with the length of its
+	// payload.
+	OnWrite(payloadLen int)
+
+	// OnRead is called for every record read, with the length of its
+	// payload as it is stored, so before decoding with WithCodec.
+	OnRead(payloadLen int)
+
+	// OnSkip is called for every record skipped, with the length of its
+	// payload. Records are skipped by Skip and when they do not fit the
+	// buffer given to Read.
+	OnSkip(recordLen int)
+
+	// OnError is called when writing or reading a record fails, with the
+	// error returned to the caller. The end of the stream is not an error.
+	OnError(err error)
+}
+
+// WithObserver makes the writer or reader notify o of every record written,
+// read or skipped, and of errors. Without it there is no cost at all.
+func WithObserver(o Observer) Option {
+	return func(opts *options) {
+		opts.observer = o
+	}
+}
@@ -0,0 +1,70 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingObserver records the calls made to it.
+type recordingObserver struct {
+	calls  []string
+	errors []error
+}
+
+func (o *recordingObserver) OnWrite(payloadLen int) {
+	o.calls = append(o.calls, fmt.Sprintf("write %d", payloadLen))
+}
+
+func (o *recordingObserver) OnRead(payloadLen int) {
+	o.calls = append(o.calls, fmt.Sprintf("read %d", payloadLen))
+}
+
+func (o *recordingObserver) OnSkip(recordLen int) {
+	o.calls = append(o.calls, fmt.Sprintf("skip %d", recordLen))
+}
+
+func (o *recordingObserver) OnError(err error) {
+	o.calls = append(o.calls, "error")
+	o.errors = append(o.errors, err)
+}
+
+func TestObserver(t *testing.T) {
+	var buf bytes.Buffer
+	wo := &recordingObserver{}
+	w := NewWriter(&buf, WithObserver(wo), WithLengthFieldSize(1))
+	for _, rec := range []string{"a", "bb", "ccc"} {
+		_, err := w.WriteString(rec)
+		require.NoError(t, err)
+	}
+	_, err := w.Write(make([]byte, 200))
+	require.ErrorIs(t, err, ErrRecordTooLarge)
+
+	require.Equal(t, []string{"write 1", "write 2", "write 3", "error"}, wo.calls)
+	require.ErrorIs(t, wo.errors[0], ErrRecordTooLarge)
+
+	ro := &recordingObserver{}
+	r := NewReader(&buf, WithObserver(ro), WithLengthFieldSize(1))
+	_, err = r.ReadRecord()
+	require.NoError(t, err)
+	require.NoError(t, r.Skip())
+	_, err = r.Read(make([]byte, 1))
+	require.ErrorIs(t, err, ErrTargetBufferTooSmall)
+	_, err = r.ReadRecord()
+	require.Equal(t, io.EOF, err)
+
+	require.Equal(t, []string{"read 1", "skip 2", "skip 3", "error"}, ro.calls)
+	require.ErrorIs(t, ro.errors[0], ErrTargetBufferTooSmall)
+}
+
+func TestObserverWriteError(t *testing.T) {
+	o := &recordingObserver{}
+	w := NewWriter(&shortWriter{limit: 3}, WithObserver(o))
+	_, err := w.WriteString("does not fit")
+	require.Error(t, err)
+	require.Equal(t, []string{"error"}, o.calls)
+	require.Equal(t, err, o.errors[0])
+}
@@ -0,0 +1,153 @@
+package recio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// offsetSize is the size of an entry in an offset index.
+const offsetSize = 8
+
+// WithIndex makes the writer keep the byte offset at which every record it
+// writes starts, which is the offset of its stream guard or length prefix,
+// for random access to the records later. The offsets are relative to the
+// start of the stream: they include the stream header and, with
+// WithAppendOnly, the records that were already there. They are returned
+// by Offsets and can be saved to a sidecar file using WriteIndex.
+func WithIndex() Option {
+	return func(o *options) {
+		o.index = true
+	}
+}
+
+// Offsets returns the offsets of the records written so far when the writer
+// was created using WithIndex.
+func (w *Writer) Offsets() []int64 {
+	return append([]int64{}, w.offsets...)
+}
+
+// WriteIndex writes the offsets of the records written so far to dst, as
+// one little endian int64 per record, so the offset of record n is found at
+// offset 8*n.
+func (w *Writer) WriteIndex(dst io.Writer) error {
+	buf := make([]byte, 0, len(w.offsets)*offsetSize)
+	for _, offset := range w.offsets {
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(offset))
+	}
+
+	_, err := dst.Write(buf)
+	return err
+}
+
+// IndexWriter writes the offset of every record to an index stream as the
+// records are written, in the format of WriteIndex, so that the index
+// survives a crash of the writing process. Use WithIndexWriter to attach it
+// to a Writer.
+//
+// The offset of a record is only written once the record has reached the
+// underlying writer: right after the record is written by an unbuffered
+// Writer and on Flush when writes are buffered. The index may therefore be
+// shorter than the records after a crash, but never longer.
+type IndexWriter struct {
+	dst     io.Writer
+	pending []byte
+	entries int
+}
+
+// NewIndexWriter returns an IndexWriter that writes the index to dst.
+func NewIndexWriter(dst io.Writer) *IndexWriter {
+	return &IndexWriter{dst: dst}
+}
+
+// Len returns the number of offsets written to the index.
+func (iw *IndexWriter) Len() int {
+	return iw.entries
+}
+
+// flush writes the offsets of the records that have been written.
+func (iw *IndexWriter) flush() error {
+	if len(iw.pending) == 0 {
+		return nil
+	}
+
+	n, err := iw.dst.Write(iw.pending)
+	iw.entries += n / offsetSize
+	iw.pending = iw.pending[:copy(iw.pending, iw.pending[n:])]
+	return err
+}
+
+// WithIndexWriter makes the writer write the offset of every record to iw,
+// the same offsets that WithIndex keeps in memory.
+func WithIndexWriter(iw *IndexWriter) Option {
+	return func(o *options) {
+		o.indexWriter = iw
+	}
+}
+
+// indexRecord adds the offset of the record that has just been written to
+// the index writer.
+func (w *Writer) indexRecord(offset int64) error {
+	iw := w.opts.indexWriter
+	iw.pending = binary.LittleEndian.AppendUint64(iw.pending, uint64(offset))
+
+	// buffered records reach the underlying writer on Flush
+	if _, buffered := w.writer.(*bufio.Writer); buffered {
+		return nil
+	}
+	return iw.flush()
+}
+
+// IndexReader holds the offsets read from an index written by WriteIndex or
+// an IndexWriter, for use with NewSeekableReader.
+type IndexReader struct {
+	offsets []int64
+}
+
+// NewIndexReader reads the index in r. An entry that is cut short, as it
+// may be after a crash, is ignored, so the index holds the offsets of the
+// records that were indexed in full. Use Len to find out how many records
+// that is, and ScanOffsets from there to find the rest.
+func NewIndexReader(r io.Reader) (*IndexReader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make([]int64, len(data)/offsetSize)
+	for i := range offsets {
+		offsets[i] = int64(binary.LittleEndian.Uint64(data[i*offsetSize:]))
+	}
+	return &IndexReader{offsets: offsets}, nil
+}
+
+// Len returns the number of records in the index.
+func (ir *IndexReader) Len() int {
+	return len(ir.offsets)
+}
+
+// Offsets returns the offsets of the records in the index.
+func (ir *IndexReader) Offsets() []int64 {
+	return ir.offsets
+}
+
+// ScanOffsets reads the records in r and returns their offsets, which
+// rebuilds the index of a stream that has none. The options must match the
+// ones the records were written with. To complete a partial index, seek r
+// to the end of the last indexed record and add the offset where reading
+// started to the offsets found.
+func ScanOffsets(r io.Reader, opts ...Option) ([]int64, error) {
+	reader := NewReader(r, opts...)
+
+	var offsets []int64
+	for {
+		err := reader.Skip()
+		if err == io.EOF {
+			return offsets, nil
+		}
+		if err != nil {
+			return offsets, err
+		}
+		offsets = append(offsets, reader.recordOffset)
+	}
+}
@@ -0,0 +1,162 @@
+package recio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex(t *testing.T) {
+	opts := []Option{WithIndex(), WithHeader(), WithSchemaVersion(1)}
+
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, opts...)
+	for i := 0; i < 10; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+	offsets := w.Offsets()
+	require.Len(t, offsets, 10)
+
+	// the offsets match the positions found by scanning the stream
+	r := NewReader(bytes.NewReader(buf.Bytes()), opts...)
+	p := make([]byte, 100)
+	for i := 0; i < 10; i++ {
+		pos, err := r.Seek(0, io.SeekCurrent)
+		require.NoError(t, err)
+		_, err = r.Read(p)
+		require.NoError(t, err)
+
+		if i == 0 {
+			// the header is read along with the first record
+			pos = int64(headerSize)
+		}
+		require.Equal(t, pos, offsets[i])
+	}
+
+	// seeking to each offset reads the record
+	for i := len(offsets) - 1; i >= 0; i-- {
+		_, err := r.Seek(offsets[i], io.SeekStart)
+		require.NoError(t, err)
+
+		version, rec, err := r.ReadVersioned()
+		require.NoError(t, err)
+		require.Equal(t, uint8(1), version)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(rec))
+	}
+
+	// the index can be written to a sidecar
+	idx := bytes.NewBuffer([]byte{})
+	require.NoError(t, w.WriteIndex(idx))
+	require.Equal(t, 10*offsetSize, idx.Len())
+	for i, offset := range offsets {
+		require.Equal(t, uint64(offset), binary.LittleEndian.Uint64(idx.Bytes()[i*offsetSize:]))
+	}
+}
+
+func TestIndexAppendOnly(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "index.seq")
+
+	var offsets []int64
+	for i := 0; i < 2; i++ {
+		f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0o644)
+		require.NoError(t, err)
+		w := NewWriter(f, WithIndex(), WithAppendOnly())
+		_, err = w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		offsets = append(offsets, w.Offsets()...)
+	}
+	require.Equal(t, []int64{0, 4 + 8}, offsets)
+}
+
+func TestIndexWriter(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithHeader()},
+		{WithWriteBuffer(64), WithChecksum()},
+	} {
+		var data, idx bytes.Buffer
+		iw := NewIndexWriter(&idx)
+		w := NewWriter(&data, append(opts, WithIndex(), WithIndexWriter(iw))...)
+		for i := 0; i < 20; i++ {
+			_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+			require.NoError(t, err)
+
+			// the index never holds records that have not been written
+			require.LessOrEqual(t, int64(iw.Len()), w.Stats().RecordsWritten)
+		}
+		require.NoError(t, w.Flush())
+		require.Equal(t, 20, iw.Len())
+
+		ir, err := NewIndexReader(&idx)
+		require.NoError(t, err)
+		require.Equal(t, 20, ir.Len())
+		require.Equal(t, w.Offsets(), ir.Offsets())
+
+		s := NewSeekableReader(bytes.NewReader(data.Bytes()), ir.Offsets(), opts...)
+		rec, err := s.ReadAt(13)
+		require.NoError(t, err)
+		require.Equal(t, "record 13", string(rec))
+	}
+}
+
+func TestIndexReaderPartial(t *testing.T) {
+	var data, idx bytes.Buffer
+	w := NewWriter(&data, WithIndexWriter(NewIndexWriter(&idx)))
+	for i := 0; i < 10; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	// a crash part way through writing the entry of record 6
+	ir, err := NewIndexReader(bytes.NewReader(idx.Bytes()[:6*offsetSize+3]))
+	require.NoError(t, err)
+	require.Equal(t, 6, ir.Len())
+
+	s := NewSeekableReader(bytes.NewReader(data.Bytes()), ir.Offsets())
+	rec, err := s.ReadAt(5)
+	require.NoError(t, err)
+	require.Equal(t, "record 5", string(rec))
+
+	// the rest of the index is found by scanning from the end of the last
+	// indexed record
+	last := ir.Offsets()[ir.Len()-1]
+	start := last + 4 + int64(len("record 5"))
+	rest, err := ScanOffsets(bytes.NewReader(data.Bytes()[start:]))
+	require.NoError(t, err)
+	require.Len(t, rest, 4)
+
+	offsets := ir.Offsets()
+	for _, offset := range rest {
+		offsets = append(offsets, start+offset)
+	}
+
+	full, err := NewIndexReader(&idx)
+	require.NoError(t, err)
+	require.Equal(t, full.Offsets(), offsets)
+}
+
+func TestScanOffsets(t *testing.T) {
+	opts := []Option{WithHeader(), WithStreamGuard(), WithAlignment(16)}
+
+	var data bytes.Buffer
+	w := NewWriter(&data, append(opts, WithIndex())...)
+	for i := 0; i < 10; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	offsets, err := ScanOffsets(bytes.NewReader(data.Bytes()), opts...)
+	require.NoError(t, err)
+	require.Equal(t, w.Offsets(), offsets)
+
+	offsets, err = ScanOffsets(bytes.NewReader(nil))
+	require.NoError(t, err)
+	require.Empty(t, offsets)
+}
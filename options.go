@@ -0,0 +1,350 @@
+package recio
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Option configures a Writer or a Reader.
+type Option func(*options)
+
+type options struct {
+	schemaVersion    uint8
+	hasSchemaVersion bool
+	drainOnClose     bool
+	streamGuard      bool
+	readBufferSize   int
+	writeBufferSize  int
+	merkleChain      bool
+	oversizePolicy   OversizePolicy
+	allocator        Allocator
+	retryAttempts    int
+	retryBackoff     func(attempt int) time.Duration
+	retryable        func(error) bool
+	timestampIndex   io.Writer
+	prevFrameCRC     bool
+	crashRing        int
+	preReadHook      func(declaredLen uint32) error
+	appendOnly       bool
+	extendedHeader   bool
+	protobufFraming  bool
+	aead             cipher.AEAD
+	nonceFunc        func() []byte
+	rateLimit        int
+	typeTag          bool
+	alignment        int
+	footerChecksum   bool
+	countFooter      bool
+	limiter          *Limiter
+	indexWriter      *IndexWriter
+	chunkSize        int
+	observer         Observer
+	progress         func(read, total int64)
+	progressEvery    int64
+	checksum         bool
+	byteOrder        binary.ByteOrder
+	varintLength     bool
+	header           bool
+	maxRecordSize    uint32
+	recordCopies     bool
+	index            bool
+	codec            Codec
+	syncMarkers      bool
+	skipGaps         bool
+	lengthSize       int
+	readTimeout      time.Duration
+	onSkip           func(offset int64, err error)
+	trailerAware     bool
+	fileLock         bool
+}
+
+// WithSchemaVersion makes the writer prepend the version byte v to the body
+// of every record so that readers can dispatch on it using ReadVersioned.
+// The version byte is part of the record body: it is included in the length
+// prefix and covered by anything computed over the body.
+func WithSchemaVersion(v uint8) Option {
+	return func(o *options) {
+		o.schemaVersion = v
+		o.hasSchemaVersion = true
+	}
+}
+
+// WithDrainOnClose makes Close on a Reader created by NewReadCloser read and
+// discard everything left in the underlying stream before closing it. This
+// allows e.g. HTTP clients to reuse the connection of a response body.
+func WithDrainOnClose() Option {
+	return func(o *options) {
+		o.drainOnClose = true
+	}
+}
+
+// WithStreamGuard writes a rotating guard byte in front of every length prefix
+// and verifies it on read, returning ErrStreamDesync as soon as the stream
+// loses alignment. This is a cheap way to catch framing bugs in protocols
+// built on top of this package. Writer and reader must both use it.
+func WithStreamGuard() Option {
+	return func(o *options) {
+		o.streamGuard = true
+	}
+}
+
+// WithReadBuffer makes the reader buffer the underlying reader with a buffer
+// of the given size. Without buffering every record costs at least two reads
+// on the underlying reader, which is expensive for e.g. a raw *os.File.
+func WithReadBuffer(size int) Option {
+	return func(o *options) {
+		o.readBufferSize = size
+	}
+}
+
+// WithWriteBuffer makes the writer buffer writes to the underlying writer
+// with a buffer of the given size, so that many small records cost a single
+// write. Buffered records are written by Flush and Close, and when a write
+// fails.
+func WithWriteBuffer(size int) Option {
+	return func(o *options) {
+		o.writeBufferSize = size
+	}
+}
+
+// WithMerkleChain appends a SHA-256 hash to every record that chains it to the
+// previous record: H(previous hash || body), starting from a zero hash. With
+// WithTypeTag and WithChunking the body includes the type tag and the chunk
+// flag. A reader created with the same option strips the hash and fails
+// with ErrChainBroken if a record has been altered, removed or reordered.
+// The hash costs 32 bytes per record and is included in the length prefix.
+func WithMerkleChain() Option {
+	return func(o *options) {
+		o.merkleChain = true
+	}
+}
+
+// OversizePolicy is what Read does with a record that does not fit the
+// target buffer.
+type OversizePolicy int
+
+const (
+	// OversizeSkip discards the record and returns ErrTargetBufferTooSmall.
+	// The stream is left at the start of the next record. This is the
+	// default.
+	OversizeSkip OversizePolicy = iota
+
+	// OversizeError returns ErrTargetBufferTooSmall and leaves the stream at
+	// the start of the record, so that the next read with a large enough
+	// buffer returns it.
+	OversizeError
+
+	// OversizeTruncate fills the buffer with the start of the record,
+	// discards the rest and returns len(p) along with ErrTargetBufferTooSmall.
+	// The stream is left at the start of the next record.
+	OversizeTruncate
+)
+
+// WithOversizePolicy sets what Read does when the target buffer is too small
+// for the next record. Readers that transform payloads, through WithCodec,
+// WithAEAD or WithChunking, read the whole record before they know its
+// size, so for them OversizeError consumes the record as OversizeSkip does.
+func WithOversizePolicy(p OversizePolicy) Option {
+	return func(o *options) {
+		o.oversizePolicy = p
+	}
+}
+
+// WithNoSkipOnTooSmall changes what Read does when the target buffer is too
+// small for the next record. Instead of skipping the record, Read returns
+// ErrTargetBufferTooSmall and leaves the record in the stream, so that the
+// next read with a large enough buffer returns it. It is the same as
+// WithOversizePolicy(OversizeError).
+func WithNoSkipOnTooSmall() Option {
+	return WithOversizePolicy(OversizeError)
+}
+
+// WithAllocator makes the reader obtain the buffers it returns from its
+// allocating read methods from a. The default is HeapAllocator.
+func WithAllocator(a Allocator) Option {
+	return func(o *options) {
+		o.allocator = a
+	}
+}
+
+// WithPreReadHook makes the reader call fn with the value of every length
+// prefix it reads, before the payload is read. If fn returns an error, the
+// payload is skipped and the read fails with that error, so the next read
+// continues with the following record. This can be used to enforce dynamic
+// size limits or quotas. With WithLength64, lengths that don't fit in 32 bits
+// are passed as math.MaxUint32.
+func WithPreReadHook(fn func(declaredLen uint32) error) Option {
+	return func(o *options) {
+		o.preReadHook = fn
+	}
+}
+
+// WithMaxRecordSize makes the reader return ErrRecordTooLarge, along with
+// the length and the byte offset of the record, instead of reading or
+// skipping a record whose payload is longer than n bytes. A single corrupt
+// length prefix can otherwise make the reader allocate or skip up to 2GiB,
+// so setting a limit is strongly recommended when reading untrusted input.
+// The stream can't be read past such a record. The default of 0 means no
+// limit.
+func WithMaxRecordSize(n uint32) Option {
+	return func(o *options) {
+		o.maxRecordSize = n
+	}
+}
+
+// WithRecordCopies makes Records yield a newly allocated copy of every
+// record, obtained from the configured Allocator, instead of reusing an
+// internal buffer.
+func WithRecordCopies() Option {
+	return func(o *options) {
+		o.recordCopies = true
+	}
+}
+
+// WithByteOrder sets the byte order of the length prefix. The default is
+// binary.LittleEndian. Writer and reader must use the same byte order.
+func WithByteOrder(order binary.ByteOrder) Option {
+	return func(o *options) {
+		o.byteOrder = order
+	}
+}
+
+// WithLength64 uses a uint64 length prefix, for records that don't fit in
+// the default uint32 prefix, whose highest bit marks checksummed records
+// and which therefore holds lengths below 2GiB. Writer and reader must both
+// use it; with WithHeader, readers detect a mismatch. It is the same as
+// WithLengthFieldSize(8).
+func WithLength64() Option {
+	return WithLengthFieldSize(8)
+}
+
+// WithLengthFieldSize sets the size of the length prefix to n bytes, which
+// must be 1, 2, 4 or 8. The default is 4. A smaller prefix saves space when
+// records are known to be small. The highest bit of the prefix marks
+// checksummed records, so a prefix of n bytes holds lengths below 2^(8n-1),
+// including the framing that is counted in the length; Write returns
+// ErrRecordTooLarge for records that don't fit. Writer and reader must use
+// the same size; with WithHeader, readers detect a mismatch. Any other size
+// makes every Write and Read fail with ErrInvalidLengthFieldSize.
+func WithLengthFieldSize(n int) Option {
+	return func(o *options) {
+		o.lengthSize = n
+	}
+}
+
+// validLengthSize reports whether the length prefix size is supported.
+func (o *options) validLengthSize() bool {
+	switch o.lengthSize {
+	case 1, 2, 4, 8:
+		return true
+	}
+	return false
+}
+
+// checksumFlag returns the bit of the length prefix that marks records that
+// carry a checksum, which is the highest bit of the prefix. Varint lengths
+// are read as 32 bit values whatever the prefix size, so for them it is
+// always bit 31.
+func (o *options) checksumFlag() uint64 {
+	if o.varintLength {
+		return 1 << 31
+	}
+	return 1 << (8*o.lengthSize - 1)
+}
+
+// maxLength returns the largest value that can be stored in the length
+// prefix, leaving out the bit that marks checksummed records.
+func (o *options) maxLength() uint64 {
+	return o.checksumFlag() - 1
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		allocator:  HeapAllocator{},
+		byteOrder:  binary.LittleEndian,
+		lengthSize: 4,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.protobufFraming {
+		o.varintLength = true
+	}
+	return o
+}
+
+// optionConflict is an option that may contradict another, and whether it
+// is in use.
+type optionConflict struct {
+	set  bool
+	name string
+}
+
+// validate returns ErrInvalidLengthFieldSize if the length prefix size is
+// not supported and an error wrapping ErrInvalidOptions, naming the options
+// involved, if options contradict each other.
+func (o *options) validate() error {
+	if !o.validLengthSize() {
+		return ErrInvalidLengthFieldSize
+	}
+
+	// protobuf streams carry nothing but the length and the message
+	if o.protobufFraming {
+		err := conflicting("WithProtobufFraming", []optionConflict{
+			{o.extendedHeader, "WithExtendedHeader"},
+			{o.header, "WithHeader"},
+			{o.codec != nil, "WithCodec"},
+			{o.aead != nil, "WithAEAD"},
+			{o.typeTag, "WithTypeTag"},
+			{o.chunkSize > 0, "WithChunking"},
+			{o.alignment > 1, "WithAlignment"},
+			{o.streamGuard, "WithStreamGuard"},
+			{o.hasSchemaVersion, "WithSchemaVersion"},
+			{o.merkleChain, "WithMerkleChain"},
+			{o.prevFrameCRC, "WithPrevFrameCRC"},
+			{o.checksum, "WithChecksum"},
+			{o.syncMarkers, "WithSyncMarkers"},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// the extended header has no room for the other framing options
+	if o.extendedHeader {
+		err := conflicting("WithExtendedHeader", []optionConflict{
+			{o.typeTag, "WithTypeTag"},
+			{o.chunkSize > 0, "WithChunking"},
+			{o.streamGuard, "WithStreamGuard"},
+			{o.hasSchemaVersion, "WithSchemaVersion"},
+			{o.merkleChain, "WithMerkleChain"},
+			{o.prevFrameCRC, "WithPrevFrameCRC"},
+			{o.checksum, "WithChecksum"},
+			{o.syncMarkers, "WithSyncMarkers"},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conflicting returns an error wrapping ErrInvalidOptions if any of others
+// is in use along with option.
+func conflicting(option string, others []optionConflict) error {
+	var names []string
+	for _, c := range others {
+		if c.set {
+			names = append(names, c.name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s can't be combined with %s", ErrInvalidOptions, option, strings.Join(names, ", "))
+}
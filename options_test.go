@@ -0,0 +1,50 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvalidOptions(t *testing.T) {
+	for _, tc := range []struct {
+		opts      []Option
+		conflicts string
+	}{
+		{[]Option{WithProtobufFraming(), WithHeader()}, "WithHeader"},
+		{[]Option{WithProtobufFraming(), WithCodec(GzipCodec{})}, "WithCodec"},
+		{[]Option{WithProtobufFraming(), WithChecksum(), WithSyncMarkers()}, "WithChecksum, WithSyncMarkers"},
+		{[]Option{WithMerkleChain(), WithProtobufFraming()}, "WithMerkleChain"},
+		{[]Option{WithProtobufFraming(), WithExtendedHeader()}, "WithExtendedHeader"},
+		{[]Option{WithExtendedHeader(), WithChecksum(), WithMerkleChain()}, "WithMerkleChain, WithChecksum"},
+		{[]Option{WithExtendedHeader(), WithTypeTag()}, "WithTypeTag"},
+		{[]Option{WithExtendedHeader(), WithChunking(16)}, "WithChunking"},
+		{[]Option{WithStreamGuard(), WithExtendedHeader()}, "WithStreamGuard"},
+	} {
+		_, err := NewWriter(io.Discard, tc.opts...).Write([]byte("record"))
+		require.ErrorIs(t, err, ErrInvalidOptions)
+		require.ErrorContains(t, err, "can't be combined with "+tc.conflicts)
+
+		// every way of reading fails the same way
+		stream := []byte{6, 'r', 'e', 'c', 'o', 'r', 'd'}
+		for _, read := range []func(r *Reader) error{
+			func(r *Reader) error { _, err := r.ReadRecord(); return err },
+			func(r *Reader) error { _, err := r.Next(); return err },
+			func(r *Reader) error { _, err := r.Read(make([]byte, 16)); return err },
+			(*Reader).Skip,
+		} {
+			r := NewReader(bytes.NewReader(stream), append(tc.opts, WithReadBuffer(64))...)
+			require.ErrorIs(t, read(r), ErrInvalidOptions)
+		}
+	}
+
+	// options that go together are fine
+	var buf bytes.Buffer
+	_, err := NewWriter(&buf, WithProtobufFraming(), WithMaxRecordSize(64)).Write([]byte("record"))
+	require.NoError(t, err)
+	rec, err := NewReader(&buf, WithProtobufFraming(), WithMaxRecordSize(64)).ReadRecord()
+	require.NoError(t, err)
+	require.Equal(t, "record", string(rec))
+}
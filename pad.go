@@ -0,0 +1,63 @@
+package recio
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrPastPadSize = errors.New("stream is already larger than the size to pad to")
+
+// padChunk is the block of zero bytes Pad writes the padding from.
+var padChunk [4096]byte
+
+// WithSkipGaps makes the reader skip the padding written by Writer.Pad. The
+// padding is a run of zero bytes, just like the padding of WithAlignment,
+// and since a sync marker is never zero, zero bytes found where a sync
+// marker is expected can only be padding. This needs WithSyncMarkers and
+// does nothing without it. A stream that ends in padding ends cleanly.
+func WithSkipGaps() Option {
+	return func(o *options) {
+		o.skipGaps = true
+	}
+}
+
+// Pad writes zero bytes until the stream is toSize bytes long, so that
+// segments preallocated to a fixed size all have the same size on disk.
+// Records can still be written after the padding. Readers need
+// WithSkipGaps to skip it; without WithSyncMarkers the padding can't be
+// told apart from a record, so Pad returns ErrInvalidOptions. If the
+// stream is already longer than toSize, Pad returns ErrPastPadSize. With
+// WithAlignment, toSize should be a multiple of the alignment so that the
+// records after the padding stay aligned.
+func (w *Writer) Pad(toSize int64) error {
+	if w.err != nil {
+		return w.err
+	}
+	if !w.opts.syncMarkers {
+		return fmt.Errorf("%w: Pad needs WithSyncMarkers", ErrInvalidOptions)
+	}
+	if w.offset > toSize {
+		return fmt.Errorf("%w: stream is %d bytes, padding to %d", ErrPastPadSize, w.offset, toSize)
+	}
+
+	for w.offset < toSize {
+		pad := padChunk[:min(toSize-w.offset, int64(len(padChunk)))]
+		n, err := w.writeFrame(pad)
+		w.offset += int64(n)
+		if err != nil {
+			return w.flushOnError(err)
+		}
+	}
+	return nil
+}
+
+// skipGap skips the padding in front of a sync marker and returns the first
+// byte of the marker. A stream that ends in padding returns io.EOF.
+func (r *Reader) skipGap() (byte, error) {
+	for {
+		b, err := r.readByte()
+		if err != nil || b != 0 {
+			return b, err
+		}
+	}
+}
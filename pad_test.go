@@ -0,0 +1,99 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPad(t *testing.T) {
+	const segmentSize = 10000
+
+	for _, opts := range [][]Option{
+		{WithSyncMarkers()},
+		{WithSyncMarkers(), WithHeader(), WithChecksum(), WithPrevFrameCRC(), WithMerkleChain(), WithStreamGuard()},
+		{WithSyncMarkers(), WithAlignment(16), WithWriteBuffer(64)},
+		{WithSyncMarkers(), WithFooterChecksum(), WithCountFooter()},
+	} {
+		f := createTemp(t)
+		w := NewWriter(f, opts...)
+		var records []string
+		for i := 0; i < 10; i++ {
+			records = append(records, fmt.Sprintf("record %d", i))
+			_, err := w.WriteString(records[i])
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Pad(segmentSize))
+		require.NoError(t, w.Pad(segmentSize))
+		require.NoError(t, w.Flush())
+
+		info, err := f.Stat()
+		require.NoError(t, err)
+		require.EqualValues(t, segmentSize, info.Size())
+
+		// records can follow the padding
+		records = append(records, "after")
+		_, err = w.WriteString("after")
+		require.NoError(t, err)
+		require.NoError(t, w.Pad(2*segmentSize))
+		require.NoError(t, w.Close())
+
+		data, err := os.ReadFile(f.Name())
+		require.NoError(t, err)
+
+		r := NewReader(bytes.NewReader(data), append(opts, WithSkipGaps())...)
+		for i, rec := range records {
+			got, err := r.ReadRecord()
+			require.NoError(t, err)
+			require.Equal(t, rec, string(got))
+			if i == len(records)-1 {
+				require.EqualValues(t, segmentSize, r.recordOffset)
+			}
+		}
+		_, err = r.ReadRecord()
+		require.ErrorIs(t, err, io.EOF)
+
+		// a reader that isn't aware of gaps stops at the padding
+		r = NewReader(bytes.NewReader(data), opts...)
+		for range 10 {
+			_, err := r.ReadRecord()
+			require.NoError(t, err)
+		}
+		_, err = r.ReadRecord()
+		require.ErrorIs(t, err, ErrMissingSyncMarker)
+	}
+}
+
+func TestPadErrors(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithSyncMarkers())
+	_, err := w.WriteString("record")
+	require.NoError(t, err)
+	size := int64(buf.Len())
+
+	err = w.Pad(size - 1)
+	require.ErrorIs(t, err, ErrPastPadSize)
+	require.NoError(t, w.Pad(size))
+	require.EqualValues(t, size, buf.Len())
+
+	w = NewWriter(&buf)
+	err = w.Pad(1 << 20)
+	require.ErrorIs(t, err, ErrInvalidOptions)
+
+	// a marker cut short after the padding is a truncated record
+	buf.Reset()
+	w = NewWriter(&buf, WithSyncMarkers())
+	_, err = w.WriteString("record")
+	require.NoError(t, err)
+	require.NoError(t, w.Pad(100))
+	buf.Write(syncMarker[:2])
+	r := NewReader(&buf, WithSyncMarkers(), WithSkipGaps())
+	_, err = r.ReadRecord()
+	require.NoError(t, err)
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,217 @@
+package recio
+
+import (
+	"errors"
+	"hash/fnv"
+	"io"
+)
+
+// Downsample copies every nth record from src to dst, starting with the
+// first, and skips the payloads of the others without buffering them. It
+// returns the number of records written to dst.
+func Downsample(dst *Writer, src *Reader, n int) (int64, error) {
+	if n < 1 {
+		return 0, errors.New("downsampling interval must be at least 1")
+	}
+
+	var kept int64
+	for i := 0; ; i++ {
+		if i%n != 0 {
+			err := src.Skip()
+			if err == io.EOF {
+				return kept, nil
+			}
+			if err != nil {
+				return kept, err
+			}
+			continue
+		}
+
+		rec, err := src.readBuffered()
+		if err == io.EOF {
+			return kept, nil
+		}
+		if err != nil {
+			return kept, err
+		}
+
+		_, err = dst.Write(rec)
+		if err != nil {
+			return kept, err
+		}
+		kept++
+	}
+}
+
+// Shard distributes the records of src over dsts, writing each record to
+// dsts[by(record) % len(dsts)]. Every shard is a valid stream of its own and
+// records keep their relative order within a shard. If by is nil, records
+// are distributed by the FNV-1a hash of their payload, which balances
+// shards roughly evenly.
+func Shard(src *Reader, dsts []*Writer, by func([]byte) int) error {
+	if len(dsts) == 0 {
+		return errors.New("no shards to write to")
+	}
+	if by == nil {
+		by = hashShard
+	}
+
+	for {
+		rec, err := src.readBuffered()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		shard := by(rec) % len(dsts)
+		if shard < 0 {
+			shard += len(dsts)
+		}
+
+		_, err = dsts[shard].Write(rec)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func hashShard(rec []byte) int {
+	h := fnv.New32a()
+	h.Write(rec)
+	return int(h.Sum32() & 0x7fffffff)
+}
+
+// CopyTo reads the remaining records, passes each through transform and
+// writes the result to dst. It returns the number of records written. A nil
+// transform copies the records unchanged.
+func (r *Reader) CopyTo(dst *Writer, transform func([]byte) ([]byte, error)) (int64, error) {
+	var n int64
+	for {
+		rec, err := r.readBuffered()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+
+		if transform != nil {
+			rec, err = transform(rec)
+			if err != nil {
+				return n, err
+			}
+		}
+
+		_, err = dst.Write(rec)
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+// Compact rewrites the stream in src to dst, keeping only the records for
+// which keep returns true. keep is called with the index of every record,
+// counting from 0, and its payload, which is only valid during the call.
+// Records keep their order, along with their type tags if WithTypeTag is in
+// use, and dst receives a fresh stream written with the same options src is
+// read with, header included. With WithSchemaVersion, the payload passed to
+// keep does not include the version byte. It returns the number
+// of records written to dst.
+func Compact(src io.Reader, dst io.Writer, keep func(index int, payload []byte) bool, opts ...Option) (int, error) {
+	r := NewReader(src, opts...)
+	w := NewWriter(dst, opts...)
+
+	kept := 0
+	for index := 0; ; index++ {
+		rec, err := r.readBuffered()
+		if err == io.EOF {
+			return kept, w.Flush()
+		}
+		if err != nil {
+			return kept, err
+		}
+
+		// the writer adds the schema version back
+		if r.opts.hasSchemaVersion {
+			rec = rec[1:]
+		}
+
+		if !keep(index, rec) {
+			continue
+		}
+
+		if r.opts.typeTag {
+			_, err = w.WriteTyped(r.tag, rec)
+		} else {
+			_, err = w.Write(rec)
+		}
+		if err != nil {
+			return kept, err
+		}
+		kept++
+	}
+}
+
+// DrainTo reads the remaining records and writes them to w, one record for
+// every record read, and returns the number of records written. Reaching
+// the end of the stream is not an error. The records are read into a
+// buffer that is reused from record to record. With WithSchemaVersion the
+// version byte of every record is replaced by the one w writes, if any, and
+// type tags are carried over when both use WithTypeTag. w is not flushed.
+// WithProgressCallback reports the progress of the copy.
+func (r *Reader) DrainTo(w *Writer) (int, error) {
+	n := 0
+	for {
+		rec, err := r.readBuffered()
+		if err == io.EOF {
+			r.reportProgress(true)
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+
+		if r.opts.hasSchemaVersion {
+			if len(rec) < 1 {
+				return n, ErrMissingSchemaVersion
+			}
+			rec = rec[1:]
+		}
+
+		if r.opts.typeTag && w.opts.typeTag {
+			_, err = w.WriteTyped(r.tag, rec)
+		} else {
+			_, err = w.Write(rec)
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+		r.reportProgress(false)
+	}
+}
+
+// Convert reads the records in src, framed as described by srcOpts, and
+// writes them to dst framed as described by dstOpts, which makes it
+// possible to migrate a stream to other framing options, for example to
+// add checksums or change the byte order. Records keep their order. The
+// schema version and type tag of a record are framing as well: they are
+// written as dstOpts say and dropped if dstOpts use neither, with type tags
+// carried over when both sides use WithTypeTag. It returns the number of
+// records converted.
+func Convert(src io.Reader, srcOpts []Option, dst io.Writer, dstOpts []Option) (int, error) {
+	w := NewWriter(dst, dstOpts...)
+	n, err := NewReader(src, srcOpts...).DrainTo(w)
+	if err != nil {
+		return n, err
+	}
+
+	err = w.writeFooter()
+	if err == nil {
+		err = w.Flush()
+	}
+	return n, err
+}
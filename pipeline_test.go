@@ -0,0 +1,304 @@
+package recio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownsample(t *testing.T) {
+	src := bytes.NewBuffer([]byte{})
+	w := NewWriter(src)
+	for i := 0; i < 100; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	dst := bytes.NewBuffer([]byte{})
+	kept, err := Downsample(NewWriter(dst), NewReader(src), 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), kept)
+
+	r := NewReader(dst)
+	readBuffer := make([]byte, 100)
+	for i := 0; i < 100; i += 10 {
+		n, err := r.Read(readBuffer)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(readBuffer[:n]))
+	}
+	_, err = r.Read(readBuffer)
+	require.ErrorIs(t, err, io.EOF)
+
+	_, err = Downsample(NewWriter(dst), NewReader(src), 0)
+	require.Error(t, err)
+}
+
+func TestShard(t *testing.T) {
+	numRecords := 300
+
+	src := bytes.NewBuffer([]byte{})
+	w := NewWriter(src)
+	for i := 0; i < numRecords; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	shards := make([]*bytes.Buffer, 3)
+	writers := make([]*Writer, 3)
+	for i := range shards {
+		shards[i] = bytes.NewBuffer([]byte{})
+		writers[i] = NewWriter(shards[i])
+	}
+
+	require.NoError(t, Shard(NewReader(src), writers, nil))
+
+	seen := make(map[string]bool)
+	for _, shard := range shards {
+		records, consumed, err := Decode(shard.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, shard.Len(), consumed)
+
+		// every shard gets a reasonable share
+		require.Greater(t, len(records), numRecords/10)
+
+		for _, rec := range records {
+			require.False(t, seen[string(rec)])
+			seen[string(rec)] = true
+		}
+	}
+
+	require.Len(t, seen, numRecords)
+	for i := 0; i < numRecords; i++ {
+		require.True(t, seen[fmt.Sprintf("record %d", i)])
+	}
+}
+
+func TestShardByKey(t *testing.T) {
+	src := bytes.NewBuffer([]byte{})
+	w := NewWriter(src)
+	for i := 0; i < 10; i++ {
+		_, err := w.Write([]byte{byte(i)})
+		require.NoError(t, err)
+	}
+
+	shards := []*bytes.Buffer{{}, {}}
+	writers := []*Writer{NewWriter(shards[0]), NewWriter(shards[1])}
+
+	err := Shard(NewReader(src), writers, func(rec []byte) int {
+		return -int(rec[0])
+	})
+	require.NoError(t, err)
+
+	even, _, err := Decode(shards[0].Bytes())
+	require.NoError(t, err)
+	odd, _, err := Decode(shards[1].Bytes())
+	require.NoError(t, err)
+
+	require.Equal(t, [][]byte{{0}, {2}, {4}, {6}, {8}}, even)
+	require.Equal(t, [][]byte{{1}, {3}, {5}, {7}, {9}}, odd)
+}
+
+func TestCopyTo(t *testing.T) {
+	src := bytes.NewBuffer([]byte{})
+	w := NewWriter(src)
+	for _, rec := range []string{"user=alice pw=secret", "user=bob pw=hunter2", ""} {
+		_, err := w.Write([]byte(rec))
+		require.NoError(t, err)
+	}
+	data := src.Bytes()
+
+	redact := func(rec []byte) ([]byte, error) {
+		if i := bytes.Index(rec, []byte("pw=")); i >= 0 {
+			return append(rec[:i:i], "pw=***"...), nil
+		}
+		return rec, nil
+	}
+
+	dst := bytes.NewBuffer([]byte{})
+	n, err := NewReader(bytes.NewReader(data)).CopyTo(NewWriter(dst), redact)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), n)
+
+	records, _, err := Decode(dst.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("user=alice pw=***"), []byte("user=bob pw=***"), {}}, records)
+
+	// without a transform the stream is copied verbatim
+	dst.Reset()
+	n, err = NewReader(bytes.NewReader(data)).CopyTo(NewWriter(dst), nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), n)
+	require.Equal(t, data, dst.Bytes())
+
+	// transform errors stop the copy
+	_, err = NewReader(bytes.NewReader(data)).CopyTo(NewWriter(dst), func([]byte) ([]byte, error) {
+		return nil, io.ErrShortWrite
+	})
+	require.ErrorIs(t, err, io.ErrShortWrite)
+}
+
+func TestCompact(t *testing.T) {
+	for _, tc := range []struct {
+		opts []Option
+		// prefix is what the reader returns in front of the payload
+		prefix string
+	}{
+		{nil, ""},
+		{[]Option{WithHeader(), WithChecksum(), WithMerkleChain(), WithStreamGuard()}, ""},
+		{[]Option{WithSchemaVersion(7)}, "\x07"},
+	} {
+		opts := tc.opts
+		src := bytes.NewBuffer([]byte{})
+		w := NewWriter(src, opts...)
+		for i := 0; i < 10; i++ {
+			// every third record is empty
+			p := fmt.Sprintf("record %d", i)
+			if i%3 == 0 {
+				p = ""
+			}
+			_, err := w.WriteString(p)
+			require.NoError(t, err)
+		}
+
+		dst := bytes.NewBuffer([]byte{})
+		kept, err := Compact(src, dst, func(index int, payload []byte) bool {
+			return index%2 == 0
+		}, opts...)
+		require.NoError(t, err)
+		require.Equal(t, 5, kept)
+
+		r := NewReader(dst, opts...)
+		for i := 0; i < 10; i += 2 {
+			rec, err := r.ReadRecord()
+			require.NoError(t, err)
+
+			expected := fmt.Sprintf("record %d", i)
+			if i%3 == 0 {
+				expected = ""
+			}
+			require.Equal(t, tc.prefix+expected, string(rec))
+		}
+		_, err = r.ReadRecord()
+		require.ErrorIs(t, err, io.EOF)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	records := [][]byte{[]byte("first"), {}, bytes.Repeat([]byte("x"), 300), {}, []byte("last")}
+
+	for _, c := range []struct {
+		srcOpts, dstOpts []Option
+	}{
+		{nil, []Option{WithVarintLength()}},
+		{nil, []Option{WithByteOrder(binary.BigEndian)}},
+		{[]Option{WithByteOrder(binary.BigEndian)}, []Option{WithChecksum(), WithHeader()}},
+		{[]Option{WithSchemaVersion(1), WithTypeTag()}, []Option{WithSchemaVersion(2), WithTypeTag(), WithFooterChecksum()}},
+	} {
+		var src bytes.Buffer
+		w := NewWriter(&src, c.srcOpts...)
+		for i, rec := range records {
+			var err error
+			if w.opts.typeTag {
+				_, err = w.WriteTyped(byte(i), rec)
+			} else {
+				_, err = w.Write(rec)
+			}
+			require.NoError(t, err)
+		}
+
+		var dst bytes.Buffer
+		n, err := Convert(&src, c.srcOpts, &dst, c.dstOpts)
+		require.NoError(t, err)
+		require.Equal(t, len(records), n)
+
+		r := NewReader(&dst, c.dstOpts...)
+		for i, want := range records {
+			if r.opts.typeTag {
+				tag, got, err := r.ReadTyped()
+				require.NoError(t, err)
+				require.Equal(t, byte(i), tag)
+				require.Equal(t, byte(2), got[0])
+				require.Equal(t, want, got[1:])
+				continue
+			}
+
+			got, err := r.ReadRecord()
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+		}
+		_, err = r.ReadRecord()
+		require.Equal(t, io.EOF, err)
+	}
+}
+
+func TestConvertByteOrder(t *testing.T) {
+	var src bytes.Buffer
+	_, err := NewWriter(&src).Write([]byte("abc"))
+	require.NoError(t, err)
+
+	var dst bytes.Buffer
+	_, err = Convert(&src, nil, &dst, []Option{WithByteOrder(binary.BigEndian)})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0, 0, 0, 3, 'a', 'b', 'c'}, dst.Bytes())
+}
+
+func TestDrainTo(t *testing.T) {
+	var src bytes.Buffer
+	w := NewWriter(&src)
+	for i := 0; i < 10; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+	_, err := w.Write(nil)
+	require.NoError(t, err)
+
+	// read the first half, then move the rest to a file of its own
+	r := NewReader(bytes.NewReader(src.Bytes()))
+	var first [][]byte
+	for i := 0; i < 4; i++ {
+		rec, err := r.ReadRecord()
+		require.NoError(t, err)
+		first = append(first, rec)
+	}
+
+	var rest bytes.Buffer
+	n, err := r.DrainTo(NewWriter(&rest))
+	require.NoError(t, err)
+	require.Equal(t, 7, n)
+
+	// draining an exhausted stream transfers nothing
+	n, err = r.DrainTo(NewWriter(io.Discard))
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	second, _, err := Decode(rest.Bytes())
+	require.NoError(t, err)
+
+	all, _, err := Decode(src.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, all, append(first, second...))
+	require.Empty(t, second[len(second)-1])
+}
+
+func TestDrainToAllocs(t *testing.T) {
+	var src bytes.Buffer
+	w := NewWriter(&src)
+	for i := 0; i < 100; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	r := NewReader(bytes.NewReader(src.Bytes()))
+	dst := NewWriter(io.Discard)
+	_, err := r.ReadRecord()
+	require.NoError(t, err)
+
+	allocs := testing.AllocsPerRun(1, func() {
+		r.DrainTo(dst)
+	})
+	require.Less(t, allocs, float64(10))
+}
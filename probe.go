@@ -0,0 +1,39 @@
+package recio
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// checksumBit is the checksum flag in a default four byte length prefix.
+const checksumBit = 1 << 31
+
+// LooksLikeRecordStart reports whether the bytes at offset in r plausibly
+// start a record in the default framing: a length prefix of at most maxLen,
+// without the checksum flag, followed by that many bytes of payload. It
+// also returns the length found in the prefix. Recovery tools can use it to
+// look for record boundaries in a corrupted file. Only the prefix and the
+// last byte of the payload are read, so probing is cheap whatever the
+// length.
+func LooksLikeRecordStart(r io.ReaderAt, offset int64, maxLen uint32) (bool, uint32) {
+	var prefix [4]byte
+	n, err := r.ReadAt(prefix[:], offset)
+	if n < len(prefix) {
+		return false, 0
+	}
+	if err != nil && err != io.EOF {
+		return false, 0
+	}
+
+	length := binary.LittleEndian.Uint32(prefix[:])
+	if length > maxLen || length&checksumBit != 0 {
+		return false, length
+	}
+	if length == 0 {
+		return true, 0
+	}
+
+	// the payload must not run past the end of r
+	n, _ = r.ReadAt(prefix[:1], offset+int64(len(prefix))+int64(length)-1)
+	return n == 1, length
+}
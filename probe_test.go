@@ -0,0 +1,70 @@
+package recio
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLooksLikeRecordStart(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, rec := range []string{"first", "", "third record"} {
+		_, err := w.WriteString(rec)
+		require.NoError(t, err)
+	}
+	data := bytes.NewReader(buf.Bytes())
+
+	for _, c := range []struct {
+		offset int64
+		ok     bool
+		length uint32
+	}{
+		{0, true, 5},
+		{9, true, 0},
+		{13, true, 12},
+
+		// in the middle of a length prefix and of a payload
+		{2, false, 0x69660000},
+		{19, false, 0x20647269},
+
+		// past the end
+		{int64(buf.Len()) - 2, false, 0},
+		{int64(buf.Len()), false, 0},
+	} {
+		ok, length := LooksLikeRecordStart(data, c.offset, 1<<20)
+		require.Equal(t, c.ok, ok, "offset %d", c.offset)
+		require.Equal(t, c.length, length, "offset %d", c.offset)
+	}
+}
+
+func TestLooksLikeRecordStartTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := NewWriter(&buf).WriteString("a record that is cut short")
+	require.NoError(t, err)
+
+	// the length fits maxLen, but the payload runs past the end of the file
+	data := bytes.NewReader(buf.Bytes()[:buf.Len()-1])
+	ok, length := LooksLikeRecordStart(data, 0, 100)
+	require.False(t, ok)
+	require.EqualValues(t, len("a record that is cut short"), length)
+
+	// and a length over maxLen is implausible however much data follows
+	ok, _ = LooksLikeRecordStart(bytes.NewReader(buf.Bytes()), 0, 10)
+	require.False(t, ok)
+}
+
+func TestLooksLikeRecordStartAllocs(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := NewWriter(&buf).Write(make([]byte, 1<<16))
+	require.NoError(t, err)
+	data := bytes.NewReader(buf.Bytes())
+
+	allocs := testing.AllocsPerRun(100, func() {
+		LooksLikeRecordStart(data, 0, 1<<20)
+	})
+	// the prefix buffer may escape through the interface, the payload never
+	// gets allocated
+	require.LessOrEqual(t, allocs, 1.0)
+}
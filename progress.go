@@ -0,0 +1,56 @@
+package recio
+
+import (
+	"io"
+	"os"
+)
+
+// WithProgressCallback makes Validate and DrainTo call fn whenever another
+// every bytes of the stream have been read, so that long scans can report
+// progress without polling. read is the number of bytes read so far and
+// total the size of the stream, or -1 if it is not known. The size is known
+// for readers with a Size method, such as *bytes.Reader, and for regular
+// files. fn is called once more when the end of the stream is reached.
+// Values of every below 1 mean no callback.
+func WithProgressCallback(every int64, fn func(read, total int64)) Option {
+	return func(o *options) {
+		o.progressEvery = every
+		o.progress = fn
+		if every < 1 {
+			o.progress = nil
+		}
+	}
+}
+
+// reportProgress calls the callback of WithProgressCallback if another
+// interval has been read since it was last called, or if done is set.
+func (r *Reader) reportProgress(done bool) {
+	if r.opts.progress == nil {
+		return
+	}
+
+	every := r.opts.progressEvery
+	if r.progressNext == 0 {
+		r.progressTotal = streamSize(r.src)
+		r.progressNext = r.count.n - r.count.n%every + every
+	}
+	if r.count.n < r.progressNext && !done {
+		return
+	}
+	r.progressNext = r.count.n - r.count.n%every + every
+	r.opts.progress(r.count.n, r.progressTotal)
+}
+
+// streamSize returns the size of src, or -1 if it is not known.
+func streamSize(src io.Reader) int64 {
+	switch s := src.(type) {
+	case interface{ Size() int64 }:
+		return s.Size()
+	case *os.File:
+		info, err := s.Stat()
+		if err == nil && info.Mode().IsRegular() {
+			return info.Size()
+		}
+	}
+	return -1
+}
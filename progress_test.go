@@ -0,0 +1,64 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressCallback(t *testing.T) {
+	const (
+		records = 10000
+		every   = 64 << 10
+	)
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithChecksum())
+	record := bytes.Repeat([]byte{'x'}, 100)
+	for range records {
+		_, err := w.Write(record)
+		require.NoError(t, err)
+	}
+	size := int64(buf.Len())
+	frameSize := size / records
+
+	type call struct{ read, total int64 }
+	check := func(calls []call, total int64) {
+		// one call per interval and one at the end
+		require.Len(t, calls, int(size/every)+1)
+		for i, c := range calls[:len(calls)-1] {
+			boundary := int64(i+1) * every
+			require.GreaterOrEqual(t, c.read, boundary)
+			require.Less(t, c.read, boundary+frameSize)
+			require.Equal(t, total, c.total)
+		}
+		require.Equal(t, call{size, total}, calls[len(calls)-1])
+	}
+
+	var calls []call
+	progress := WithProgressCallback(every, func(read, total int64) {
+		calls = append(calls, call{read, total})
+	})
+
+	n, err := Validate(bytes.NewReader(buf.Bytes()), WithChecksum(), progress)
+	require.NoError(t, err)
+	require.Equal(t, records, n)
+	check(calls, size)
+
+	// the size of a plain io.Reader is not known
+	calls = nil
+	r := NewReader(io.MultiReader(bytes.NewReader(buf.Bytes())), WithChecksum(), progress)
+	n, err = r.DrainTo(NewWriter(io.Discard))
+	require.NoError(t, err)
+	require.Equal(t, records, n)
+	check(calls, -1)
+
+	// no callback for intervals below 1
+	n, err = Validate(bytes.NewReader(buf.Bytes()), WithChecksum(), WithProgressCallback(0, func(read, total int64) {
+		t.Fatal("callback called")
+	}))
+	require.NoError(t, err)
+	require.Equal(t, records, n)
+}
@@ -0,0 +1,19 @@
+package recio
+
+// WithProtobufFraming reads and writes the length delimited format used for
+// streams of protobuf messages, as written by protodelim.MarshalTo and read
+// by protodelim.UnmarshalFrom: every message is preceded by its length as a
+// base 128 varint and nothing else. Streams written with it can be read by
+// protobuf tooling and the other way around.
+//
+// The option implies WithVarintLength and can't be combined with options
+// that add bytes to the stream or change the message, such as WithHeader,
+// WithChecksum, WithStreamGuard, WithCodec and WithAEAD: with any of them,
+// every Write and Read fails with an error wrapping ErrInvalidOptions that
+// names them. Records are limited to 2GiB, the largest message protobuf
+// accepts.
+func WithProtobufFraming() Option {
+	return func(o *options) {
+		o.protobufFraming = true
+	}
+}
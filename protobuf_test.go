@@ -0,0 +1,72 @@
+package recio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The tests use hand-rolled equivalents of protodelim.MarshalTo and
+// protodelim.UnmarshalFrom, which write and read a uvarint length followed
+// by the message.
+
+func TestProtobufFramingWrite(t *testing.T) {
+	messages := [][]byte{
+		{0x08, 0x96, 0x01},
+		{},
+		bytes.Repeat([]byte{0x12}, 300),
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithProtobufFraming())
+	for _, m := range messages {
+		_, err := w.Write(m)
+		require.NoError(t, err)
+	}
+
+	var expected []byte
+	for _, m := range messages {
+		expected = binary.AppendUvarint(expected, uint64(len(m)))
+		expected = append(expected, m...)
+	}
+	require.Equal(t, expected, buf.Bytes())
+
+	br := bufio.NewReader(&buf)
+	for _, m := range messages {
+		length, err := binary.ReadUvarint(br)
+		require.NoError(t, err)
+		got := make([]byte, length)
+		_, err = io.ReadFull(br, got)
+		require.NoError(t, err)
+		require.Equal(t, m, got)
+	}
+	_, err := binary.ReadUvarint(br)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestProtobufFramingRead(t *testing.T) {
+	messages := [][]byte{
+		{0x08, 0x01},
+		bytes.Repeat([]byte{0x1a}, 200),
+		{},
+	}
+
+	var stream []byte
+	for _, m := range messages {
+		stream = binary.AppendUvarint(stream, uint64(len(m)))
+		stream = append(stream, m...)
+	}
+
+	r := NewReader(bytes.NewReader(stream), WithProtobufFraming(), WithReadBuffer(64))
+	for _, m := range messages {
+		got, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.Equal(t, m, got)
+	}
+	_, err := r.ReadRecord()
+	require.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package recio
+
+// raceEnabled is set when the tests are built with the race detector, which
+// also enables pointer checks that some tests cannot pass.
+const raceEnabled = true
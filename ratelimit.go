@@ -0,0 +1,73 @@
+package recio
+
+import (
+	"time"
+)
+
+// WithRateLimit limits the rate at which the writer writes to the
+// underlying writer to bytesPerSecond, counting the complete frame of every
+// record, stream header included. Writes block until the rate allows them.
+// The limit is enforced by a token bucket that holds up to one second's
+// worth of bytes, so a writer that has been idle may write that much at
+// once before it is throttled. A frame larger than the bucket is written in
+// one piece, and the writes after it wait until the rate has caught up.
+func WithRateLimit(bytesPerSecond int) Option {
+	return func(o *options) {
+		o.rateLimit = bytesPerSecond
+	}
+}
+
+// throttle waits until n bytes may be written.
+func (w *Writer) throttle(n int) error {
+	d := w.limiter.reserve(n, w.now())
+	if d <= 0 {
+		return nil
+	}
+	if w.ctx == nil {
+		time.Sleep(d)
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
+
+// rateLimiter is a token bucket where every token is a byte. Its balance
+// may go negative, which makes the next reservation wait longer.
+type rateLimiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(bytesPerSecond int, now time.Time) *rateLimiter {
+	return &rateLimiter{
+		rate:   float64(bytesPerSecond),
+		burst:  float64(bytesPerSecond),
+		tokens: float64(bytesPerSecond),
+		last:   now,
+	}
+}
+
+// reserve takes n tokens from the bucket and returns how long to wait
+// before writing them.
+func (l *rateLimiter) reserve(n int, now time.Time) time.Duration {
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
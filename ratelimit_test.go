@@ -0,0 +1,47 @@
+package recio
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	start := time.Unix(1000, 0)
+	l := newRateLimiter(1000, start)
+
+	// the bucket starts full
+	require.Zero(t, l.reserve(600, start))
+	require.Zero(t, l.reserve(400, start))
+
+	// then every byte costs a millisecond
+	require.Equal(t, 100*time.Millisecond, l.reserve(100, start))
+
+	// waiting refills the bucket, but never beyond a second's worth
+	later := start.Add(10 * time.Second)
+	require.Zero(t, l.reserve(1000, later))
+	require.Equal(t, 500*time.Millisecond, l.reserve(500, later))
+}
+
+func TestRateLimit(t *testing.T) {
+	const rate = 50000
+
+	counter := &writeCounter{writer: io.Discard}
+	w := NewWriter(counter, WithRateLimit(rate))
+	p := make([]byte, 996)
+
+	start := time.Now()
+	total := 0
+	for i := 0; i < 75; i++ {
+		_, err := w.Write(p)
+		require.NoError(t, err)
+		total += 4 + len(p)
+	}
+	elapsed := time.Since(start)
+
+	// a full bucket's worth goes out at once, the rest at the rate
+	require.LessOrEqual(t, float64(total-rate), elapsed.Seconds()*rate)
+	require.Equal(t, 75, counter.writes)
+}
@@ -0,0 +1,262 @@
+package recio
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// WithReadTimeout makes the Reader give up on a record that has not arrived
+// in full within d of the read that asks for it. The read then fails with
+// an error matching os.ErrDeadlineExceeded, and the clock includes the time
+// spent waiting for the record to start.
+//
+// A timeout leaves the stream usable: the bytes of the record that have
+// been read are kept, and the next read starts the record over from its
+// first byte, so a reader can simply try again once the rest has had time
+// to arrive. The one exception is NextReader: payload bytes already handed
+// out cannot be taken back, so after a timeout the record's reader can be
+// read again to carry on where it stopped, but its record is not started
+// over.
+//
+// If the underlying reader has a SetReadDeadline method, as net.Conn does,
+// the deadline is set on it for every record and cleared afterwards.
+// Otherwise reads are done by a goroutine and a timed out read carries on in
+// the background, its data being returned by the next read. That goroutine
+// may outlive the Reader until the underlying read returns, which closing
+// the underlying reader usually makes it do.
+//
+// Keeping the record for a retry costs a copy of every frame read.
+func WithReadTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.readTimeout = d
+	}
+}
+
+// timeoutState is the reader state at the start of a record, which a read
+// that timed out goes back to.
+type timeoutState struct {
+	count      int64
+	guard      uint8
+	index      int64
+	stats      Stats
+	headerDone bool
+	markerSeen bool
+}
+
+// bindTimeout returns the reader the Reader reads src through with
+// WithReadTimeout, which is src itself if it can take read deadlines.
+func (r *Reader) bindTimeout(src io.Reader) (io.Reader, readDeadliner) {
+	if d, ok := src.(readDeadliner); ok {
+		r.pump = nil
+		return src, d
+	}
+	r.pump = &pumpReader{r: src}
+	return r.pump, r.pump
+}
+
+// beginTimed starts the clock for a record and, if the last read timed out,
+// goes back to the start of the record it was reading.
+func (r *Reader) beginTimed() {
+	if r.replay.timedOut {
+		s := r.timeoutState
+		r.count.n = s.count
+		r.guard = s.guard
+		r.index = s.index
+		r.stats = s.stats
+		r.headerDone = s.headerDone
+		r.markerSeen = s.markerSeen
+		r.continued = false
+		r.replay.rewind()
+	}
+
+	// the deadline covers every chunk of a record
+	if r.replay.recording {
+		return
+	}
+	r.timeoutState = timeoutState{
+		count:      r.count.n,
+		guard:      r.guard,
+		index:      r.index,
+		stats:      r.stats,
+		headerDone: r.headerDone,
+		markerSeen: r.markerSeen,
+	}
+	r.replay.start()
+}
+
+// endTimed stops the clock once a record has been read in full.
+func (r *Reader) endTimed() {
+	if r.continued {
+		return
+	}
+	r.replay.stop()
+}
+
+// resetTimed forgets the record being read, for Seek and Reset.
+func (r *Reader) resetTimed() {
+	r.replay.stop()
+	r.replay.replay = nil
+}
+
+// replayReader keeps the bytes read since the start of the current record,
+// so that the record can be read again after a timeout.
+type replayReader struct {
+	r       io.Reader
+	d       readDeadliner
+	timeout time.Duration
+
+	// rec holds the bytes read while recording and replay the bytes to
+	// return before reading on.
+	rec       []byte
+	replay    []byte
+	recording bool
+	timedOut  bool
+}
+
+func (rr *replayReader) Read(p []byte) (int, error) {
+	var n int
+	var err error
+	if len(rr.replay) > 0 {
+		n = copy(p, rr.replay)
+		rr.replay = rr.replay[n:]
+	} else {
+		// reading on after a timeout, rather than starting over, gets
+		// another d to finish the record
+		if rr.timedOut {
+			rr.timedOut = false
+			rr.d.SetReadDeadline(time.Now().Add(rr.timeout))
+		}
+		n, err = rr.r.Read(p)
+		if os.IsTimeout(err) {
+			rr.timedOut = rr.recording
+		}
+	}
+	if rr.recording {
+		rr.rec = append(rr.rec, p[:n]...)
+	}
+	return n, err
+}
+
+// start starts recording and the clock for a record.
+func (rr *replayReader) start() {
+	rr.rec = rr.rec[:0]
+	rr.recording = true
+	rr.timedOut = false
+	rr.d.SetReadDeadline(time.Now().Add(rr.timeout))
+}
+
+// stop stops recording and clears the deadline.
+func (rr *replayReader) stop() {
+	rr.recording = false
+	rr.timedOut = false
+	rr.d.SetReadDeadline(time.Time{})
+}
+
+// rewind makes the recorded bytes be read again.
+func (rr *replayReader) rewind() {
+	rr.replay = append(append([]byte(nil), rr.rec...), rr.replay...)
+	rr.recording = false
+	rr.timedOut = false
+}
+
+// pending returns the number of bytes that will be read again.
+func (rr *replayReader) pending() int {
+	n := len(rr.replay)
+	if rr.timedOut {
+		n += len(rr.rec)
+	}
+	return n
+}
+
+// pumpReader gives read deadlines to a reader that has none by reading from
+// it in a goroutine. A read that times out is left running and what it
+// reads is returned by the next Read.
+type pumpReader struct {
+	r        io.Reader
+	deadline time.Time
+
+	// buf is being read into while done is not nil, left is what has been
+	// read but not returned yet.
+	buf  []byte
+	left []byte
+	err  error
+	done chan ioResult
+}
+
+func (p *pumpReader) SetReadDeadline(t time.Time) error {
+	p.deadline = t
+	return nil
+}
+
+func (p *pumpReader) Read(b []byte) (int, error) {
+	if len(p.left) == 0 && p.err == nil {
+		err := p.wait()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(b, p.left)
+	p.left = p.left[n:]
+	if len(p.left) > 0 {
+		return n, nil
+	}
+	err := p.err
+	p.err = nil
+	return n, err
+}
+
+// wait waits for the read in flight, starting one if there is none, until
+// the deadline passes.
+func (p *pumpReader) wait() error {
+	if p.done == nil {
+		if cap(p.buf) == 0 {
+			p.buf = make([]byte, 4096)
+		}
+		p.done = make(chan ioResult, 1)
+		go func(r io.Reader, buf []byte, done chan<- ioResult) {
+			n, err := r.Read(buf)
+			done <- ioResult{n, err}
+		}(p.r, p.buf[:cap(p.buf)], p.done)
+	}
+
+	// a read that has finished counts even if the deadline has passed
+	select {
+	case res := <-p.done:
+		p.finish(res)
+		return nil
+	default:
+	}
+
+	var expired <-chan time.Time
+	if !p.deadline.IsZero() {
+		timer := time.NewTimer(time.Until(p.deadline))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case res := <-p.done:
+		p.finish(res)
+		return nil
+	case <-expired:
+		return os.ErrDeadlineExceeded
+	}
+}
+
+func (p *pumpReader) finish(res ioResult) {
+	p.done = nil
+	p.left = p.buf[:res.n]
+	p.err = res.err
+}
+
+// discard drops what has been read ahead, waiting for the read in flight.
+func (p *pumpReader) discard() {
+	if p.done != nil {
+		<-p.done
+		p.done = nil
+	}
+	p.left = nil
+	p.err = nil
+}
@@ -0,0 +1,134 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// slowPipe returns both ends of a pipe, one that can set read deadlines if
+// deadlines is set and one that can't otherwise.
+func slowPipe(t *testing.T, deadlines bool) (io.Reader, io.WriteCloser) {
+	if deadlines {
+		client, server := net.Pipe()
+		t.Cleanup(func() {
+			client.Close()
+			server.Close()
+		})
+		return server, client
+	}
+	pr, pw := io.Pipe()
+	t.Cleanup(func() { pw.Close() })
+	return pr, pw
+}
+
+func TestReadTimeout(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithReadBuffer(64)},
+		{WithChecksum(), WithStreamGuard()},
+		{WithChunking(4)},
+		{WithHeader(), WithReadBuffer(16)},
+	} {
+		for _, deadlines := range []bool{true, false} {
+			var buf bytes.Buffer
+			w := NewWriter(&buf, opts...)
+			for _, p := range []string{"hello world", "second"} {
+				_, err := w.Write([]byte(p))
+				require.NoError(t, err)
+			}
+			stream := buf.Bytes()
+
+			src, dst := slowPipe(t, deadlines)
+			r := NewReader(src, append(opts, WithReadTimeout(20*time.Millisecond))...)
+
+			// nothing has arrived yet
+			_, err := r.ReadRecord()
+			require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+			// the first record arrives in part, its tail after the timeout
+			tail := make(chan struct{})
+			go func() {
+				dst.Write(stream[:len(stream)/3])
+				<-tail
+				dst.Write(stream[len(stream)/3:])
+				dst.Close()
+			}()
+
+			_, err = r.ReadRecord()
+			require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+			close(tail)
+
+			for _, want := range []string{"hello world", "second"} {
+				got, err := r.ReadRecord()
+				require.NoError(t, err)
+				require.Equal(t, want, string(got))
+			}
+			_, err = r.ReadRecord()
+			require.ErrorIs(t, err, io.EOF)
+
+			// the timeouts are not counted as records read
+			ref := NewReader(bytes.NewReader(stream), opts...)
+			for {
+				_, err := ref.ReadRecord()
+				if err != nil {
+					break
+				}
+			}
+			require.Equal(t, ref.Stats(), r.Stats())
+		}
+	}
+}
+
+func TestReadTimeoutNextReader(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	_, err := w.Write([]byte("hello world"))
+	require.NoError(t, err)
+	stream := buf.Bytes()
+
+	src, dst := slowPipe(t, false)
+	r := NewReader(src, WithReadTimeout(20*time.Millisecond))
+
+	tail := make(chan struct{})
+	go func() {
+		dst.Write(stream[:9])
+		<-tail
+		dst.Write(stream[9:])
+		dst.Close()
+	}()
+
+	rr, err := r.NextReader()
+	require.NoError(t, err)
+	got := make([]byte, 11)
+	n, err := io.ReadFull(rr, got)
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	require.Equal(t, 5, n)
+	close(tail)
+
+	// the record's reader carries on where it stopped
+	_, err = io.ReadFull(rr, got[n:])
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(got))
+
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestReadTimeoutBuffered(t *testing.T) {
+	src, dst := slowPipe(t, true)
+	r := NewReader(src, WithReadBuffer(64), WithReadTimeout(20*time.Millisecond))
+
+	go dst.Write([]byte{5, 0, 0, 0, 'a', 'b'})
+	_, err := r.ReadRecord()
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	// what was read is kept for the next attempt
+	require.Equal(t, 6, r.Buffered())
+	require.False(t, r.HasRecord())
+}
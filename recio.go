@@ -1,67 +1,1461 @@
 package recio
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash"
+	"hash/crc32"
 	"io"
+	"math"
+	"time"
 )
 
-type recordWriter struct {
-	writer io.Writer
+// Writer writes length prefixed records to an underlying io.Writer. A Writer
+// is not safe for concurrent use, use SyncWriter for that.
+type Writer struct {
+	writer  io.Writer
+	dst     io.Writer
+	opts    options
+	guard   uint8
+	hasher  hash.Hash
+	chain   [merkleHashSize]byte
+	frame   []byte
+	str     []byte
+	prevCRC uint32
+	tag     byte
+	ring    *crashRing
+	limiter *rateLimiter
+	now     func() time.Time
+	closer  io.Closer
+	closed  bool
+	buffer  *bufio.Writer
+	footer  *footerWriter
+
+	// footerDone is set once the footers have been written.
+	footerDone bool
+
+	// open is the record begun by BeginRecord, if any.
+	open *openRecord
+
+	// offset is the position in the underlying stream, offsets the start
+	// of every record written when WithIndex is in use.
+	offset  int64
+	offsets []int64
+
+	stats Stats
+
+	// err is an error from setting up the writer, returned by every Write.
+	err error
+
+	// ctx is the context of the WriteContext call in progress, if any.
+	ctx context.Context
+
+	// continued is the chunk flag of the frame being written, see
+	// WithChunking.
+	continued byte
 }
 
-type recordReader struct {
-	reader io.Reader
+// Reader reads length prefixed records from an underlying io.Reader.
+type Reader struct {
+	src     io.Reader
+	br      *bufio.Reader
+	reader  io.Reader
+	body    io.Reader
+	opts    options
+	closer  io.Closer
+	closed  bool
+	current *recordReader
+	guard   uint8
+	buf     []byte
+	index   int64
+	hasher  hash.Hash
+	chain   [merkleHashSize]byte
+	digest  hash.Hash
+
+	// frameCRC sees every byte of the current frame when WithPrevFrameCRC
+	// is in use, prevCRC is the CRC of the previous frame.
+	frameCRC hash.Hash32
+	prevCRC  uint32
+
+	// count counts the bytes consumed from the stream, recordOffset is the
+	// offset of the current record.
+	count        *countingReader
+	recordOffset int64
+	checksum     hash.Hash32
+
+	// progressNext is the offset at which the callback of
+	// WithProgressCallback is called next, progressTotal the stream size
+	// it reports.
+	progressNext  int64
+	progressTotal int64
+
+	// ignoreChecksums makes the reader accept records whose checksum does
+	// not match, see RecomputeChecksums.
+	ignoreChecksums bool
+
+	// pending holds a length prefix that has been read but whose record has
+	// not been consumed yet.
+	pending    uint64
+	hasPending bool
+
+	// tag is the type tag of the last record read, see WithTypeTag.
+	tag byte
+
+	// continued is set when the last chunk read is followed by more chunks
+	// of the same record, which are reassembled in chunks. See WithChunking.
+	continued bool
+	chunks    []byte
+
+	// extType and extFlags belong to the last extended header read.
+	extType  uint32
+	extFlags byte
+
+	oneByte [1]byte
+	prefix  [8]byte
+
+	// headerDone is set once the stream header has been validated.
+	headerDone bool
+
+	// markerSeen is set when Resync has consumed the sync marker of the
+	// next record.
+	markerSeen bool
+
+	// recordLength is the payload length of the current record and
+	// skipping is set while it is being skipped.
+	recordLength uint64
+	skipping     bool
+	stats        Stats
+
+	// replay keeps the record being read for WithReadTimeout, which reads
+	// through pump if src has no read deadline.
+	replay       *replayReader
+	pump         *pumpReader
+	timeoutState timeoutState
+
+	// tee mirrors the frames read to another writer, see NewTeeReader.
+	tee *frameTee
+
+	// footer checks the footers written by WithCountFooter and
+	// WithFooterChecksum, and finds them with WithTrailerAware.
+	footer *footerReader
+
+	// reserved is the memory held for the last record, see WithLimiter.
+	reserved int64
+
+	// invalid is the error of options that can't be combined, which every
+	// read returns.
+	invalid error
 }
 
 var (
-	ErrTargetBufferTooSmall = errors.New("target buffer is too small to hold message, skipping message")
+	ErrTargetBufferTooSmall   = errors.New("target buffer is too small to hold message, skipping message")
+	ErrMissingSchemaVersion   = errors.New("record is too short to hold a schema version")
+	ErrStreamDesync           = errors.New("stream guard mismatch, stream is out of sync")
+	ErrChainBroken            = errors.New("record does not match hash chain")
+	ErrFrameChainBroken       = errors.New("record does not match previous frame CRC")
+	ErrNotSeekable            = errors.New("underlying reader does not implement io.Seeker")
+	ErrRecordTooLarge         = errors.New("record exceeds maximum record size")
+	ErrInvalidLengthFieldSize = errors.New("length field size must be 1, 2, 4 or 8 bytes")
+	ErrInvalidOptions         = errors.New("options can't be combined")
 )
 
-func NewWriter(w io.Writer) io.Writer {
-	return &recordWriter{
-		writer: w,
+func NewWriter(w io.Writer, opts ...Option) *Writer {
+	o := newOptions(opts)
+
+	writer := &Writer{
+		opts: o,
+		now:  time.Now,
+	}
+	if o.merkleChain {
+		writer.hasher = sha256.New()
+	}
+	if o.crashRing > 0 {
+		writer.ring = newCrashRing(o.crashRing)
+	}
+	if o.rateLimit > 0 {
+		writer.limiter = newRateLimiter(o.rateLimit, writer.now())
+	}
+	if o.footerChecksum {
+		writer.footer = &footerWriter{digest: sha256.New()}
+	}
+	writer.bind(w)
+	return writer
+}
+
+// NewWriterSize returns a Writer that buffers writes to w with a buffer of
+// size bytes, like bufio.NewWriterSize, and is otherwise configured by
+// opts. It is the same as NewWriter with WithWriteBuffer(size). Records are
+// written to w when the buffer fills up, so callers must call Flush or
+// Close when done. A record that is larger than the buffer is written
+// straight through to w instead of being copied into the buffer. A size
+// of 0 or less gives an unbuffered Writer, which is what NewWriter returns
+// unless WithWriteBuffer is used.
+func NewWriterSize(w io.Writer, size int, opts ...Option) *Writer {
+	return NewWriter(w, append(opts[:len(opts):len(opts)], WithWriteBuffer(size))...)
+}
+
+// Reset discards any unflushed records and the state kept about the stream
+// and makes the writer start a new stream on dst, keeping its options and
+// buffers. This allows writers to be reused, for example with a sync.Pool.
+// The records kept by WithCrashRing are not discarded.
+func (w *Writer) Reset(dst io.Writer) {
+	w.guard = 0
+	w.chain = [merkleHashSize]byte{}
+	w.prevCRC = 0
+	w.offsets = w.offsets[:0]
+	w.stats = Stats{}
+	w.bind(dst)
+}
+
+// bind makes the writer write a new stream to dst.
+func (w *Writer) bind(dst io.Writer) {
+	w.writer = dst
+	w.dst = dst
+	w.closer = nil
+	w.closed = false
+	w.footerDone = false
+	w.open = nil
+	if c, ok := dst.(io.Closer); ok {
+		w.closer = c
+	}
+
+	w.offset = 0
+	w.err = nil
+	w.err = w.opts.validate()
+	if w.opts.appendOnly && w.err == nil {
+		w.offset, w.err = w.seekToEnd()
+	}
+
+	if w.footer != nil {
+		w.footer.reset(dst)
+		w.writer = w.footer
+	}
+
+	if w.opts.writeBufferSize > 0 {
+		if w.buffer == nil {
+			w.buffer = bufio.NewWriterSize(w.writer, w.opts.writeBufferSize)
+		} else {
+			w.buffer.Reset(w.writer)
+		}
+		w.writer = w.buffer
+	}
+
+	if w.opts.header && w.err == nil && w.offset == 0 {
+		w.err = w.writeHeader()
 	}
 }
 
-func (w *recordWriter) Write(p []byte) (int, error) {
-	l := uint32(len(p))
+// WriteString writes s as a record. It behaves exactly like
+// Write([]byte(s)), but copies s into a buffer owned by the writer instead of
+// allocating a new slice on every call.
+func (w *Writer) WriteString(s string) (int, error) {
+	w.str = append(w.str[:0], s...)
+	return w.Write(w.str)
+}
+
+// Write writes p as a record. The whole frame is assembled in memory first
+// and handed to the underlying writer in a single write, so nothing is
+// written if the record can't be framed. If the write fails part way
+// through, the partial frame is truncated away when the underlying writer
+// is an io.Seeker with a Truncate method, such as *os.File, so the stream
+// only ever grows by whole frames. On other writers the partial frame
+// stays behind; OpenForAppend finds where it starts. Either way the error
+// of the failed write is returned.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	if w.opts.extendedHeader {
+		err := w.WriteFrameExt(0, 0, p)
+		if err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
 
-	err := binary.Write(w.writer, binary.LittleEndian, l)
+	if w.opts.chunkSize > 0 {
+		return w.writeChunked(p)
+	}
+
+	payload, err := w.encodePayload(p)
 	if err != nil {
 		return 0, err
 	}
 
-	return w.writer.Write(p)
+	var chain [merkleHashSize]byte
+	if w.opts.merkleChain {
+		chain = w.nextChainHash(payload)
+	}
+
+	// the frame is assembled in a buffer owned by the writer rather than on
+	// the stack: a stack buffer passed to the underlying io.Writer escapes to
+	// the heap, whereas reusing w.frame makes writes allocation free.
+	w.frame = w.appendFrame(w.frame[:0], payload, chain[:])
+	frameEnd := len(w.frame)
+	w.frame = w.appendPadding(w.frame, w.offset)
+
+	_, err = w.writeFrame(w.frame)
+	if err != nil {
+		return 0, w.flushOnError(err)
+	}
+
+	w.guard++
+	w.chain = chain
+	if w.opts.prevFrameCRC {
+		w.prevCRC = crc32.Checksum(w.frame[:frameEnd], castagnoli)
+	}
+	return len(p), w.recordWritten(p, len(w.frame))
+}
+
+// encodePayload compresses and encrypts p as configured and checks that the
+// result fits in the length prefix.
+func (w *Writer) encodePayload(p []byte) ([]byte, error) {
+	payload, err := w.transform(p)
+	if err != nil {
+		return nil, w.observeError(err)
+	}
+
+	// refuse records that would overflow the length prefix
+	if w.opts.frameLength(len(payload)) > w.opts.maxLength() {
+		return nil, w.observeError(fmt.Errorf("%w: %d byte payload does not fit in the length prefix", ErrRecordTooLarge, len(payload)))
+	}
+	return payload, nil
+}
+
+// recordWritten updates the state that is kept about written records once
+// the frameSize byte frame for p has been written.
+func (w *Writer) recordWritten(p []byte, frameSize int) error {
+	if w.ring != nil {
+		w.ring.add(p)
+	}
+	return w.frameWritten(len(p), frameSize)
+}
+
+// frameWritten updates the state that is kept about written records once
+// the frameSize byte frame for an n byte record has been written.
+func (w *Writer) frameWritten(n int, frameSize int) error {
+	start := w.offset
+	if w.opts.index {
+		w.offsets = append(w.offsets, start)
+	}
+	w.offset += int64(frameSize)
+	w.stats.RecordsWritten++
+	w.stats.BytesWritten += int64(n)
+	if w.opts.observer != nil {
+		w.opts.observer.OnWrite(n)
+	}
+
+	if w.opts.indexWriter != nil {
+		err := w.indexRecord(start)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.opts.timestampIndex != nil {
+		return w.writeTimestamp()
+	}
+	return nil
+}
+
+// Flush writes any records buffered by WithWriteBuffer, or by an underlying
+// *bufio.Writer, to the underlying writer.
+func (w *Writer) Flush() error {
+	bw, ok := w.writer.(*bufio.Writer)
+	if !ok {
+		return nil
+	}
+
+	err := bw.Flush()
+	if err == nil && w.opts.indexWriter != nil {
+		err = w.opts.indexWriter.flush()
+	}
+	return err
+}
+
+// Close writes the footers of WithCountFooter and WithFooterChecksum,
+// flushes any buffered records, see Flush, and closes the underlying writer
+// if it implements io.Closer. Closing a closed Writer does nothing and
+// returns nil.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	err := w.writeFooter()
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	if w.closer != nil {
+		closeErr := w.closer.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// flushOnError makes a last attempt to flush records that are buffered in an
+// underlying *bufio.Writer after a write has failed with err, so they are
+// not lost if the caller gives up on the stream. A flush error other than
+// err itself is joined with err. The resulting error is reported to the
+// observer.
+func (w *Writer) flushOnError(err error) error {
+	bw, ok := w.writer.(*bufio.Writer)
+	if ok {
+		flushErr := bw.Flush()
+		if flushErr != nil && flushErr != err {
+			err = errors.Join(err, flushErr)
+		}
+	}
+	return w.observeError(err)
+}
+
+// observeError reports err to the observer and returns it.
+func (w *Writer) observeError(err error) error {
+	if w.opts.observer != nil {
+		w.opts.observer.OnError(err)
+	}
+	return err
+}
+
+// readPrefix reads a fixed size length prefix. It returns io.EOF only when
+// the stream ends before the frame starts. When it ends within the prefix,
+// or after the sync marker or guard byte, the error wraps
+// io.ErrUnexpectedEOF and says how many bytes of the prefix were read.
+func (r *Reader) readPrefix(size int) (uint64, error) {
+	if !r.opts.validLengthSize() {
+		return 0, ErrInvalidLengthFieldSize
+	}
+
+	prefix := r.prefix[:size]
+	n, err := io.ReadFull(r.reader, prefix)
+	if err == io.EOF && r.midFrame() {
+		err = io.ErrUnexpectedEOF
+	}
+	if err == io.ErrUnexpectedEOF {
+		return 0, fmt.Errorf("%w: stream ends after %d of %d length prefix bytes", io.ErrUnexpectedEOF, n, size)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return decodeLength(r.opts.byteOrder, prefix), nil
+}
+
+// appendLength appends l to dst as a size byte length prefix.
+func appendLength(dst []byte, order binary.ByteOrder, size int, l uint64) []byte {
+	n := len(dst)
+	dst = append(dst, 0, 0, 0, 0, 0, 0, 0, 0)[:n+size]
+	switch size {
+	case 1:
+		dst[n] = byte(l)
+	case 2:
+		order.PutUint16(dst[n:], uint16(l))
+	case 8:
+		order.PutUint64(dst[n:], l)
+	default:
+		order.PutUint32(dst[n:], uint32(l))
+	}
+	return dst
+}
+
+// decodeLength decodes a length prefix of len(b) bytes.
+func decodeLength(order binary.ByteOrder, b []byte) uint64 {
+	switch len(b) {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(order.Uint16(b))
+	case 8:
+		return order.Uint64(b)
+	default:
+		return uint64(order.Uint32(b))
+	}
+}
+
+// midFrame reports whether part of the current frame has been read.
+func (r *Reader) midFrame() bool {
+	return r.count.n > r.recordOffset
 }
 
-func NewReader(r io.Reader) io.Reader {
-	return &recordReader{
-		reader: r,
+// frameLength returns the value of the length prefix for a payload of n
+// bytes, without the checksum flag.
+func (o *options) frameLength(n int) uint64 {
+	l := uint64(n)
+	if o.typeTag {
+		l++
+	}
+	if o.chunkSize > 0 {
+		l++
+	}
+	if o.hasSchemaVersion {
+		l++
 	}
+	if o.merkleChain {
+		l += merkleHashSize
+	}
+	if o.prevFrameCRC {
+		l += frameCRCSize
+	}
+	if o.checksum {
+		l += checksumSize
+	}
+	return l
 }
 
-func (r recordReader) Read(p []byte) (int, error) {
-	var length uint32
+// appendFrame appends the complete frame for the payload p to dst. chain is
+// the chain hash of the record when WithMerkleChain is in use.
+func (w *Writer) appendFrame(dst []byte, p []byte, chain []byte) []byte {
+	dst, bodyStart := w.appendFrameHead(dst, len(p))
+	dst = append(dst, p...)
+
+	if w.opts.merkleChain {
+		dst = append(dst, chain...)
+	}
+
+	if w.opts.checksum {
+		dst = appendChecksum(dst, dst[bodyStart:])
+	}
+	return dst
+}
+
+// appendFrameHead appends the part of the frame for an n byte payload that
+// precedes the payload to dst. It also returns where in dst the part of the
+// frame that is covered by the checksum starts.
+func (w *Writer) appendFrameHead(dst []byte, n int) ([]byte, int) {
+	l := w.opts.frameLength(n)
+	if w.opts.checksum {
+		l |= w.opts.checksumFlag()
+	}
+
+	if w.opts.syncMarkers {
+		dst = append(dst, syncMarker[:]...)
+	}
+
+	if w.opts.streamGuard {
+		dst = append(dst, guardByte(w.guard))
+	}
+
+	switch {
+	case w.opts.varintLength:
+		dst = binary.AppendUvarint(dst, l)
+	default:
+		dst = appendLength(dst, w.opts.byteOrder, w.opts.lengthSize, l)
+	}
+	bodyStart := len(dst)
+
+	if w.opts.prevFrameCRC {
+		dst = binary.LittleEndian.AppendUint32(dst, w.prevCRC)
+	}
+
+	if w.opts.typeTag {
+		dst = append(dst, w.tag)
+	}
+
+	if w.opts.chunkSize > 0 {
+		dst = append(dst, w.continued)
+	}
+
+	if w.opts.hasSchemaVersion {
+		dst = append(dst, w.opts.schemaVersion)
+	}
+	return dst, bodyStart
+}
+
+func NewReader(r io.Reader, opts ...Option) *Reader {
+	o := newOptions(opts)
+
+	reader := &Reader{
+		src:     r,
+		opts:    o,
+		invalid: o.validate(),
+	}
+	if c, ok := r.(io.Closer); ok {
+		reader.closer = c
+	}
+	var deadliner readDeadliner
+	if o.readTimeout > 0 {
+		r, deadliner = reader.bindTimeout(r)
+	}
+	if o.footerChecksum || o.countFooter || o.trailerAware {
+		reader.footer = newFooterReader(r, &o)
+		r = reader.footer
+	}
+	if o.readBufferSize > 0 {
+		reader.br = bufio.NewReaderSize(r, o.readBufferSize)
+		r = reader.br
+	}
+	if o.readTimeout > 0 {
+		reader.replay = &replayReader{r: r, d: deadliner, timeout: o.readTimeout}
+		r = reader.replay
+	}
+	reader.count = &countingReader{r: r}
+	r = reader.count
+
+	if o.prevFrameCRC {
+		reader.frameCRC = crc32.New(castagnoli)
+		r = io.TeeReader(r, reader.frameCRC)
+	}
+	if o.checksum {
+		reader.checksum = crc32.New(castagnoli)
+		r = io.TeeReader(r, reader.checksum)
+	}
+	reader.reader = r
+	reader.body = r
+
+	if o.merkleChain {
+		reader.hasher = sha256.New()
+		reader.body = io.TeeReader(r, reader.hasher)
+	}
+	return reader
+}
+
+// NewReadCloser returns a Reader that closes rc when the Reader is closed.
+// NewReader does the same for readers that implement io.Closer, so this is
+// only needed to close something other than what the Reader reads from.
+func NewReadCloser(rc io.ReadCloser, opts ...Option) *Reader {
+	r := NewReader(rc, opts...)
+	r.closer = rc
+	return r
+}
+
+// Close closes the underlying reader if it implements io.Closer, or the
+// closer given to NewReadCloser. If WithDrainOnClose was given, the remainder
+// of the underlying stream is discarded before it is closed. Closing a closed
+// Reader does nothing and returns nil.
+func (r *Reader) Close() error {
+	r.releaseReserved()
+	if r.closer == nil || r.closed {
+		return nil
+	}
+	r.closed = true
+
+	var drainErr error
+	if r.opts.drainOnClose {
+		if r.replay != nil {
+			r.resetTimed()
+		}
+		r.current = nil
+		_, drainErr = io.Copy(io.Discard, r.reader)
+	}
+
+	err := r.closer.Close()
+	if drainErr != nil {
+		return drainErr
+	}
+	return err
+}
 
-	err := binary.Read(r.reader, binary.LittleEndian, &length)
+// Seek sets the position of the underlying reader, which must implement
+// io.Seeker, and resets the state the Reader keeps about the current
+// record. The offset must be the start of a record; reading after seeking to
+// any other offset returns garbage until the stream is resynchronized.
+// Seek(0, io.SeekCurrent) only reports the current position. With
+// WithHeader, seeking to offset 0 makes the reader validate the header again.
+//
+// Options that carry state from one record to the next, such as
+// WithStreamGuard, WithMerkleChain and WithPrevFrameCRC, start over as if
+// at the beginning of the stream, so with those only seeking to the start of
+// the stream is meaningful.
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := r.src.(io.Seeker)
+	if !ok {
+		return 0, ErrNotSeekable
+	}
+
+	// the position of the underlying reader is ahead of ours by what has
+	// been buffered but not consumed
+	buffered := int64(0)
+	if r.br != nil {
+		buffered = int64(r.br.Buffered())
+	}
+	if r.footer != nil {
+		buffered += int64(r.footer.end - r.footer.start)
+	}
+	if r.replay != nil {
+		buffered += int64(r.replay.pending())
+	}
+
+	// only report the position, leaving the stream as it is
+	if whence == io.SeekCurrent && offset == 0 {
+		pos, err := seeker.Seek(0, io.SeekCurrent)
+		return pos - buffered, err
+	}
+
+	if whence == io.SeekCurrent {
+		offset -= buffered
+	}
+
+	pos, err := seeker.Seek(offset, whence)
+	if err != nil {
+		return pos, err
+	}
+
+	if r.pump != nil {
+		r.pump.discard()
+	}
+	if r.footer != nil {
+		r.footer.reset(r.underlying(), pos == 0)
+	}
+	if r.br != nil {
+		r.br.Reset(r.source())
+	}
+	if r.replay != nil {
+		r.resetTimed()
+	}
+	r.count.n = pos
+	r.headerDone = pos > 0
+	r.resetState()
+	return pos, nil
+}
+
+// Reset discards the state kept about the current stream and makes the
+// reader read a new stream from src, keeping its options and buffers. This
+// allows readers to be reused, for example with a sync.Pool. Close closes
+// src if it implements io.Closer, and no longer the old stream.
+func (r *Reader) Reset(src io.Reader) {
+	r.src = src
+	r.closer = nil
+	r.closed = false
+	if c, ok := src.(io.Closer); ok {
+		r.closer = c
+	}
+	if r.replay != nil {
+		r.resetTimed()
+		src, r.replay.d = r.bindTimeout(src)
+	}
+	if r.footer != nil {
+		r.footer.reset(src, true)
+		src = r.footer
+	}
+	if r.br != nil {
+		r.br.Reset(src)
+	} else if r.replay != nil {
+		r.replay.r = src
+	} else if r.tee != nil {
+		r.tee.r = src
+	} else {
+		r.count.r = src
+	}
+
+	r.count.n = 0
+	r.headerDone = false
+	r.markerSeen = false
+	r.stats = Stats{}
+	r.resetState()
+}
+
+// source returns the reader that the Reader's buffering reads from.
+func (r *Reader) source() io.Reader {
+	if r.footer != nil {
+		return r.footer
+	}
+	return r.underlying()
+}
+
+// underlying returns the reader that the Reader reads the stream from.
+func (r *Reader) underlying() io.Reader {
+	if r.pump != nil {
+		return r.pump
+	}
+	return r.src
+}
+
+// resetState forgets everything the Reader knows about the stream position.
+func (r *Reader) resetState() {
+	r.releaseReserved()
+	r.current = nil
+	r.hasPending = false
+	r.guard = 0
+	r.index = 0
+	r.chain = [merkleHashSize]byte{}
+	r.prevCRC = 0
+	r.progressNext = 0
+}
+
+// Read reads the payload of the next record into p and returns its length.
+// It returns io.EOF if, and only if, the stream ends where a record would
+// start. A stream that ends with bytes that do not make up a whole record
+// fails with a FramingError wrapping io.ErrUnexpectedEOF. The same holds for
+// every other method that reads records. Note that trailing bytes which
+// happen to frame a complete record, such as four zero bytes making up an
+// empty record, are read as a record. What Read does with a record that
+// is larger than p is set by WithOversizePolicy.
+func (r *Reader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.read(p)
+		retry, err := r.skipCorrupt(err)
+		if !retry {
+			return n, err
+		}
+	}
+}
+
+func (r *Reader) read(p []byte) (int, error) {
+	if r.opts.readsWhole() {
+		return r.readDecoded(p)
+	}
+
+	length, err := r.readLength()
 	if err != nil {
 		return 0, err
 	}
 
-	if uint32(len(p)) < length {
-		// discard records that we can't return fully
-		_, err := io.CopyN(io.Discard, r.reader, int64(length))
+	// an empty record is a record in its own right, not the end of the
+	// stream
+	if length == 0 {
+		return 0, r.finishRecord()
+	}
+
+	if uint64(len(p)) < length {
+		if r.opts.oversizePolicy == OversizeError {
+			// leave the record in the stream so it can be read again
+			r.pending = length
+			r.hasPending = true
+			return 0, r.framingError(ErrTargetBufferTooSmall)
+		}
+
+		// discard what we can't return, reporting the position of the record
+		// rather than of the one that follows it
+		tooSmall := &FramingError{Offset: r.recordOffset, Index: r.index, Err: ErrTargetBufferTooSmall}
+		n := 0
+		if r.opts.oversizePolicy == OversizeTruncate {
+			var err error
+			n, err = io.ReadFull(r.body, p)
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return 0, r.framingError(io.ErrUnexpectedEOF)
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+		err := r.discardPayload(length - uint64(n))
 		if err != nil {
 			return 0, err
 		}
-		return 0, ErrTargetBufferTooSmall
+		return n, r.observeError(tooSmall)
+	}
+
+	// a single Read may return less than the whole payload on anything but
+	// in-memory readers, so keep reading until the payload is complete
+	n, err := io.ReadFull(r.body, p[:length])
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return 0, r.framingError(io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, r.finishRecord()
+}
+
+// NextReader returns an io.Reader limited to the payload of the next record,
+// which makes it possible to process large records without holding them in
+// memory. Any unread part of the previous record's payload is discarded
+// before the next record is read.
+func (r *Reader) NextReader() (io.Reader, error) {
+	if r.opts.readsWhole() {
+		body, err := r.readRecord()
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(body), nil
 	}
 
-	n, err := r.reader.Read(p[:length])
-	if n != int(length) {
-		return 0, fmt.Errorf("read wrong length: %d, wanted %d", n, length)
+	length, err := r.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	r.current = &recordReader{
+		reader:  r,
+		payload: io.LimitedReader{R: r.body, N: int64(length)},
+	}
+	return r.current, nil
+}
+
+// recordReader is the io.Reader returned by NextReader. It finishes the
+// record as soon as the payload has been read, so that errors detected by
+// reading what follows the payload are returned by the read that reaches
+// the end of the payload.
+type recordReader struct {
+	reader  *Reader
+	payload io.LimitedReader
+	done    bool
+}
+
+func (rr *recordReader) Read(p []byte) (int, error) {
+	if rr.done {
+		return 0, io.EOF
+	}
+
+	n, err := rr.payload.Read(p)
+	if err == io.EOF && rr.payload.N > 0 {
+		return n, rr.reader.framingError(io.ErrUnexpectedEOF)
+	}
+
+	if rr.payload.N == 0 {
+		rr.done = true
+		if rr.reader.current == rr {
+			rr.reader.current = nil
+		}
+
+		finishErr := rr.reader.finishRecord()
+		if finishErr != nil {
+			return n, finishErr
+		}
+		if n == 0 {
+			return 0, io.EOF
+		}
 	}
 	return n, err
 }
+
+// PeekLength returns the payload length of the next record without
+// consuming the record, so the next read returns it. Calling it again
+// before the record is read returns the same length. It returns io.EOF at
+// the end of the stream. With WithCodec the length is that of the encoded
+// payload.
+func (r *Reader) PeekLength() (uint64, error) {
+	length, err := r.readLength()
+	if err != nil {
+		return 0, err
+	}
+
+	r.pending = length
+	r.hasPending = true
+	return length, nil
+}
+
+// readLength reads the framing that precedes the next record's payload and
+// returns the payload length.
+func (r *Reader) readLength() (uint64, error) {
+	// the rest of a chunked record can't be resynchronized to, so that is
+	// left to the methods reading whole records
+	if r.continued {
+		return r.readFrameLength()
+	}
+	for {
+		length, err := r.readFrameLength()
+		retry, err := r.skipCorrupt(err)
+		if !retry {
+			return length, err
+		}
+	}
+}
+
+func (r *Reader) readFrameLength() (uint64, error) {
+	if r.invalid != nil {
+		return 0, r.invalid
+	}
+	if r.hasPending {
+		r.hasPending = false
+		return r.pending, nil
+	}
+
+	// moving on to the next record releases the memory held for the last
+	r.releaseReserved()
+
+	declared, length, err := r.readHeader()
+	if err != nil {
+		return 0, r.framingError(err)
+	}
+	r.recordLength = length
+	r.skipping = false
+
+	// refuse to read or skip a record that is too large, since the length
+	// is most likely corrupt
+	if r.opts.maxRecordSize > 0 && length > uint64(r.opts.maxRecordSize) {
+		return 0, r.framingError(fmt.Errorf("%w: length %d, maximum is %d", ErrRecordTooLarge, length, r.opts.maxRecordSize))
+	}
+	if length > math.MaxInt {
+		return 0, r.framingError(fmt.Errorf("%w: length %d does not fit in memory", ErrRecordTooLarge, length))
+	}
+
+	if r.opts.preReadHook != nil {
+		// the hook only takes 32 bit lengths
+		if declared > math.MaxUint32 {
+			declared = math.MaxUint32
+		}
+		hookErr := r.opts.preReadHook(uint32(declared))
+		if hookErr != nil {
+			err := r.discardPayload(length)
+			if err != nil {
+				return 0, err
+			}
+			return 0, hookErr
+		}
+	}
+	return length, nil
+}
+
+// readHeader reads everything that precedes the next record's payload and
+// returns both the length found in the length prefix and the length of the
+// payload.
+func (r *Reader) readHeader() (uint64, uint64, error) {
+	err := r.discardCurrent()
+	if err != nil {
+		return 0, 0, err
+	}
+	if r.replay != nil {
+		r.beginTimed()
+	}
+
+	if r.opts.header && !r.headerDone {
+		err := r.readStreamHeader()
+		if err != nil {
+			return 0, 0, err
+		}
+		if r.tee != nil {
+			err := r.tee.flush()
+			if err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+	if r.tee != nil {
+		r.tee.begin(r.markerSeen)
+	}
+	if r.opts.alignment > 1 && !r.markerSeen {
+		err := r.skipPadding()
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	r.recordOffset = r.count.n
+
+	// the frame CRC covers the whole frame, sync marker included
+	if r.opts.prevFrameCRC {
+		r.frameCRC.Reset()
+	}
+
+	if r.opts.syncMarkers {
+		if r.markerSeen {
+			r.recordOffset -= int64(len(syncMarker))
+			if r.opts.prevFrameCRC {
+				r.frameCRC.Write(syncMarker[:])
+			}
+		}
+		err := r.readSyncMarker()
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if r.opts.extendedHeader {
+		length, err := r.readExtHeader()
+		return uint64(length), uint64(length), err
+	}
+
+	if r.opts.streamGuard {
+		var guard [1]byte
+		_, err := io.ReadFull(r.reader, guard[:])
+		if err == io.EOF && r.midFrame() {
+			return 0, 0, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+
+		expected := guardByte(r.guard)
+		if guard[0] != expected {
+			return 0, 0, fmt.Errorf("%w: expected 0x%02x, got 0x%02x", ErrStreamDesync, expected, guard[0])
+		}
+		r.guard++
+	}
+
+	var declared uint64
+	switch {
+	case r.opts.varintLength:
+		var d uint32
+		d, err = r.readVarintLength()
+		declared = uint64(d)
+	default:
+		declared, err = r.readPrefix(r.opts.lengthSize)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	if !r.opts.varintLength && declared == r.opts.checksumFlag() {
+		return 0, 0, ErrAbandonedRecord
+	}
+
+	declared, err = r.checkChecksumFlag(declared)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	length := declared
+	if r.opts.checksum {
+		length -= checksumSize
+	}
+	if r.opts.prevFrameCRC {
+		length, err = r.readPrevFrameCRC(length)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if r.opts.typeTag {
+		length, err = r.readTypeTag(length)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if r.opts.chunkSize > 0 {
+		length, err = r.readChunkFlag(length)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if r.opts.merkleChain {
+		length, err = r.beginChainRecord(length)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return declared, length, nil
+}
+
+// finishRecord is called once the payload of a record has been consumed and
+// reads whatever follows the payload.
+func (r *Reader) finishRecord() error {
+	if r.opts.merkleChain {
+		err := r.verifyChainRecord()
+		if err != nil {
+			return r.framingError(err)
+		}
+	}
+
+	if r.opts.checksum {
+		err := r.verifyChecksum()
+		if err != nil {
+			return r.framingError(err)
+		}
+	}
+
+	if r.opts.prevFrameCRC {
+		r.prevCRC = r.frameCRC.Sum32()
+	}
+
+	if r.skipping {
+		r.skipping = false
+		r.stats.RecordsSkipped++
+		if r.opts.observer != nil {
+			r.opts.observer.OnSkip(int(r.recordLength))
+		}
+	} else {
+		r.stats.RecordsRead++
+		r.stats.BytesRead += int64(r.recordLength)
+		if r.opts.observer != nil {
+			r.opts.observer.OnRead(int(r.recordLength))
+		}
+	}
+
+	r.index++
+	if r.replay != nil {
+		r.endTimed()
+	}
+	if r.tee != nil {
+		return r.tee.flush()
+	}
+	return nil
+}
+
+// guardByte returns the stream guard for the record with the given sequence
+// number. The high nibble is fixed and the low nibble rotates.
+func guardByte(seq uint8) byte {
+	return 0xa0 | (seq & 0x0f)
+}
+
+// discardCurrent skips whatever remains of the record handed out by
+// NextReader.
+func (r *Reader) discardCurrent() error {
+	if r.current == nil {
+		return nil
+	}
+
+	// reading the rest of the record finishes it
+	current := r.current
+	r.current = nil
+
+	_, err := io.Copy(io.Discard, current)
+	return err
+}
+
+// ReadRecord reads the next record into a newly allocated slice of exactly
+// the record's size, so the caller does not have to guess the size of the
+// largest record. It returns io.EOF when the stream ends at a record
+// boundary and io.ErrUnexpectedEOF when it ends within a record. The slice
+// is obtained from the Allocator given with WithAllocator, if any.
+func (r *Reader) ReadRecord() ([]byte, error) {
+	return r.readRecord()
+}
+
+// Next returns the next record without copying it into memory owned by the
+// caller.
+//
+// The returned slice points into the Reader's own buffer and is only valid
+// until the next call to a read method. It must not be modified or kept;
+// copy it to keep it.
+//
+// With WithReadBuffer, a record that fits in the read buffer is returned
+// straight from it, without any copying, provided that no option needs to
+// see the payload on its way, such as WithChecksum or WithMerkleChain. In
+// all other cases the record is read into a buffer that is reused from call
+// to call.
+func (r *Reader) Next() ([]byte, error) {
+	for {
+		body, err := r.next()
+		retry, err := r.skipCorrupt(err)
+		if !retry {
+			return body, err
+		}
+	}
+}
+
+func (r *Reader) next() ([]byte, error) {
+	if r.br == nil || r.opts.readsWhole() || r.reader != r.count || r.body != r.reader || r.tee != nil || r.replay != nil {
+		return r.readBuffered()
+	}
+
+	length, err := r.readLength()
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(r.br.Size()) {
+		return r.readPayload(length)
+	}
+	err = r.reserve(length)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := r.br.Peek(int(length))
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = r.framingError(io.ErrUnexpectedEOF)
+	}
+	if err == nil {
+		r.br.Discard(len(body))
+		r.count.n += int64(len(body))
+		err = r.finishRecord()
+	}
+	if err != nil {
+		r.releaseReserved()
+		return nil, err
+	}
+	return r.decode(body)
+}
+
+// ReadInto reads the next record into buf and returns the slice holding it.
+// Like append, it reuses buf when the record fits in its capacity and
+// allocates a larger slice when it does not, so records are never dropped
+// for being larger than the buffer.
+func (r *Reader) ReadInto(buf []byte) ([]byte, error) {
+	if r.opts.readsWhole() {
+		body, err := r.readBuffered()
+		if err != nil {
+			return buf[:0], err
+		}
+		return append(buf[:0], body...), nil
+	}
+
+	length, err := r.PeekLength()
+	if err != nil {
+		return buf[:0], err
+	}
+
+	if uint64(cap(buf)) < length {
+		buf = make([]byte, length)
+	}
+
+	n, err := r.Read(buf[:cap(buf)])
+	return buf[:n], err
+}
+
+// ReadVersioned reads the next record and splits it into the schema version
+// byte written by a writer using WithSchemaVersion and the payload.
+func (r *Reader) ReadVersioned() (uint8, []byte, error) {
+	body, err := r.readRecord()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if len(body) < 1 {
+		return 0, nil, ErrMissingSchemaVersion
+	}
+	return body[0], body[1:], nil
+}
+
+// readRecord reads the next record into a slice obtained from the configured
+// Allocator.
+func (r *Reader) readRecord() ([]byte, error) {
+	return r.readRecordFrom(r.opts.allocator)
+}
+
+// readRecordFrom reads the next record into a slice obtained from a.
+func (r *Reader) readRecordFrom(a Allocator) ([]byte, error) {
+	for {
+		body, err := r.readRecordOnce(a)
+		retry, err := r.skipCorrupt(err)
+		if !retry {
+			return body, err
+		}
+	}
+}
+
+func (r *Reader) readRecordOnce(a Allocator) ([]byte, error) {
+	if r.opts.chunkSize > 0 {
+		chunks, err := r.readChunks()
+		if err != nil {
+			return nil, err
+		}
+		return append(a.Get(len(chunks))[:0], chunks...), nil
+	}
+
+	length, err := r.readLength()
+	if err != nil {
+		return nil, err
+	}
+	err = r.reserve(length)
+	if err != nil {
+		return nil, err
+	}
+
+	body := a.Get(int(length))
+	_, err = io.ReadFull(r.body, body)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = r.framingError(io.ErrUnexpectedEOF)
+	}
+	if err == nil {
+		err = r.finishRecord()
+	}
+	if err != nil {
+		a.Put(body)
+		r.releaseReserved()
+		return nil, err
+	}
+
+	if !r.opts.transformsPayload() {
+		return body, nil
+	}
+	decoded, err := r.decode(body)
+	a.Put(body)
+	return decoded, err
+}
+
+// readBuffered reads the next record into the Reader's internal buffer. The
+// returned slice is only valid until the next call.
+func (r *Reader) readBuffered() ([]byte, error) {
+	for {
+		body, err := r.readBufferedOnce()
+		retry, err := r.skipCorrupt(err)
+		if !retry {
+			return body, err
+		}
+	}
+}
+
+func (r *Reader) readBufferedOnce() ([]byte, error) {
+	if r.opts.chunkSize > 0 {
+		return r.readChunks()
+	}
+
+	length, err := r.readLength()
+	if err != nil {
+		return nil, err
+	}
+	return r.readPayload(length)
+}
+
+// readPayload reads the length byte payload of the current record into the
+// Reader's internal buffer.
+func (r *Reader) readPayload(length uint64) ([]byte, error) {
+	err := r.reserve(length)
+	if err != nil {
+		return nil, err
+	}
+
+	// make sure that empty records are returned as empty, not nil, slices
+	if r.buf == nil || uint64(cap(r.buf)) < length {
+		r.buf = make([]byte, length)
+	}
+	body := r.buf[:length]
+
+	_, err = io.ReadFull(r.body, body)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = r.framingError(io.ErrUnexpectedEOF)
+	}
+	if err == nil {
+		err = r.finishRecord()
+	}
+	if err != nil {
+		r.releaseReserved()
+		return nil, err
+	}
+	return r.decode(body)
+}
+
+// Skip advances past the next record without reading its payload into
+// memory. It returns io.EOF at the end of the stream.
+func (r *Reader) Skip() error {
+	for {
+		err := r.skip()
+		retry, err := r.skipCorrupt(err)
+		if !retry {
+			return err
+		}
+	}
+}
+
+func (r *Reader) skip() error {
+	length, err := r.readLength()
+	if err != nil {
+		return err
+	}
+
+	err = r.discardPayload(length)
+	if err != nil {
+		return err
+	}
+	return r.skipChunks()
+}
+
+// CountRecords returns the number of records in r, which are skipped rather
+// than read into memory.
+func CountRecords(r io.Reader, opts ...Option) (int, error) {
+	reader := NewReader(r, opts...)
+	count := 0
+	for {
+		err := reader.Skip()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+// discardPayload skips a payload of the given length and finishes the record.
+func (r *Reader) discardPayload(length uint64) error {
+	r.skipping = true
+	n, err := io.CopyN(io.Discard, r.body, int64(length))
+	if n < int64(length) && err == io.EOF {
+		return r.framingError(fmt.Errorf("%w: stream ends %d bytes into a %d byte payload", io.ErrUnexpectedEOF, n, length))
+	}
+	if err != nil {
+		return err
+	}
+	return r.finishRecord()
+}
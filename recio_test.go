@@ -4,14 +4,20 @@ import (
 	"bufio"
 	"bytes"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"unsafe"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -114,6 +120,48 @@ func BenchmarkWriter(b *testing.B) {
 	}
 }
 
+func TestWriteSmallAllocs(t *testing.T) {
+	w := NewWriter(io.Discard)
+	p := []byte("this is a test")
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		w.Write(p)
+	})
+	require.Zero(t, allocs)
+}
+
+func TestReadSmallAllocs(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for i := 0; i < 1001; i++ {
+		_, err := w.Write([]byte("this is a test"))
+		require.NoError(t, err)
+	}
+
+	r := NewReader(bytes.NewReader(buf.Bytes()))
+	p := make([]byte, 100)
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		r.Read(p)
+	})
+	require.Zero(t, allocs)
+}
+
+func BenchmarkWriterSmall(b *testing.B) {
+	w := NewWriter(io.Discard)
+	p := make([]byte, 32)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// not using require here since it dominates the cost of a small write
+		_, err := w.Write(p)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func BenchmarkReader(b *testing.B) {
 	writer := bytes.NewBuffer([]byte{})
 
@@ -127,6 +175,7 @@ func BenchmarkReader(b *testing.B) {
 	readBuffer := make([]byte, 500)
 	r := NewReader(bytes.NewReader(writer.Bytes()))
 
+	b.ReportAllocs()
 	b.ResetTimer()
 	for {
 		_, err := r.Read(readBuffer)
@@ -292,3 +341,1571 @@ func BenchmarkDiskRead(b *testing.B) {
 		require.NotZero(b, n)
 	}
 }
+
+func TestNextReader(t *testing.T) {
+	writer := bytes.NewBuffer([]byte{})
+	w := NewWriter(writer)
+
+	payloads := make([][]byte, 3)
+	for i := range payloads {
+		payloads[i] = make([]byte, (i+1)*1024*1024)
+		_, err := rand.Read(payloads[i])
+		require.NoError(t, err)
+		_, err = w.Write(payloads[i])
+		require.NoError(t, err)
+	}
+
+	r := NewReader(bytes.NewReader(writer.Bytes()))
+
+	// stream the first record fully
+	rr, err := r.NextReader()
+	require.NoError(t, err)
+	h := sha256.New()
+	n, err := io.Copy(h, rr)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(payloads[0])), n)
+	sum := sha256.Sum256(payloads[0])
+	require.Equal(t, sum[:], h.Sum(nil))
+
+	// only consume part of the second record
+	rr, err = r.NextReader()
+	require.NoError(t, err)
+	head := make([]byte, 16)
+	_, err = io.ReadFull(rr, head)
+	require.NoError(t, err)
+	require.Equal(t, payloads[1][:16], head)
+
+	// the rest of the second record should be skipped automatically
+	rr, err = r.NextReader()
+	require.NoError(t, err)
+	data, err := io.ReadAll(rr)
+	require.NoError(t, err)
+	require.Equal(t, payloads[2], data)
+
+	_, err = r.NextReader()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestSchemaVersion(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+
+	v1 := NewWriter(buf, WithSchemaVersion(1))
+	v2 := NewWriter(buf, WithSchemaVersion(2))
+
+	n, err := v1.Write([]byte("old format"))
+	require.NoError(t, err)
+	require.Equal(t, len("old format"), n)
+	_, err = v2.Write([]byte("new format"))
+	require.NoError(t, err)
+	_, err = v1.Write([]byte{})
+	require.NoError(t, err)
+
+	r := NewReader(bytes.NewReader(buf.Bytes()))
+
+	version, payload, err := r.ReadVersioned()
+	require.NoError(t, err)
+	require.Equal(t, uint8(1), version)
+	require.Equal(t, "old format", string(payload))
+
+	version, payload, err = r.ReadVersioned()
+	require.NoError(t, err)
+	require.Equal(t, uint8(2), version)
+	require.Equal(t, "new format", string(payload))
+
+	version, payload, err = r.ReadVersioned()
+	require.NoError(t, err)
+	require.Equal(t, uint8(1), version)
+	require.Empty(t, payload)
+
+	_, _, err = r.ReadVersioned()
+	require.ErrorIs(t, err, io.EOF)
+
+	// records without a version byte can't be read as versioned records
+	buf.Reset()
+	_, err = NewWriter(buf).Write([]byte{})
+	require.NoError(t, err)
+	_, _, err = NewReader(buf).ReadVersioned()
+	require.ErrorIs(t, err, ErrMissingSchemaVersion)
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestDrainOnClose(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for i := 0; i < 10; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("this is test string %d", i)))
+		require.NoError(t, err)
+	}
+	data := buf.Bytes()
+
+	readBuffer := make([]byte, 100)
+
+	// without draining the rest of the stream is left unread
+	underlying := &closeRecorder{Reader: bytes.NewReader(data)}
+	r := NewReadCloser(underlying)
+	_, err := r.Read(readBuffer)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.True(t, underlying.closed)
+	n, _ := underlying.Read(readBuffer)
+	require.NotZero(t, n)
+
+	// with draining the underlying reader is fully consumed
+	underlying = &closeRecorder{Reader: bytes.NewReader(data)}
+	r = NewReadCloser(underlying, WithDrainOnClose())
+	_, err = r.Read(readBuffer)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.True(t, underlying.closed)
+	_, err = underlying.Read(readBuffer)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestStreamGuard(t *testing.T) {
+	pr, pw := io.Pipe()
+
+	go func() {
+		w := NewWriter(pw, WithStreamGuard())
+		w.Write([]byte("first"))
+		w.Write([]byte("second"))
+		// inject a stray byte that desynchronizes the stream
+		pw.Write([]byte{0x42})
+		w.Write([]byte("third"))
+		pw.Close()
+	}()
+
+	r := NewReader(pr, WithStreamGuard())
+	readBuffer := make([]byte, 100)
+
+	n, err := r.Read(readBuffer)
+	require.NoError(t, err)
+	require.Equal(t, "first", string(readBuffer[:n]))
+
+	n, err = r.Read(readBuffer)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(readBuffer[:n]))
+
+	_, err = r.Read(readBuffer)
+	require.ErrorIs(t, err, ErrStreamDesync)
+	require.Contains(t, err.Error(), "expected 0xa2, got 0x42")
+
+	pr.Close()
+}
+
+type readCounter struct {
+	reader io.Reader
+	reads  int
+}
+
+func (c *readCounter) Read(p []byte) (int, error) {
+	c.reads++
+	return c.reader.Read(p)
+}
+
+func TestReadBuffer(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for i := 0; i < 100; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("this is test string %d", i)))
+		require.NoError(t, err)
+	}
+
+	counter := &readCounter{reader: bytes.NewReader(buf.Bytes())}
+	r := NewReader(counter, WithReadBuffer(4096))
+
+	readBuffer := make([]byte, 100)
+	for i := 0; ; i++ {
+		n, err := r.Read(readBuffer)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("this is test string %d", i), string(readBuffer[:n]))
+	}
+	require.Less(t, counter.reads, 5)
+}
+
+func benchmarkRawFileRead(b *testing.B, opts ...Option) {
+	filename := filepath.Join(b.TempDir(), "raw.seq")
+
+	writeFile, err := os.Create(filename)
+	require.NoError(b, err)
+
+	w := NewWriter(writeFile)
+	buffer := make([]byte, 100)
+	for i := 0; i < b.N; i++ {
+		_, err := w.Write(buffer)
+		require.NoError(b, err)
+	}
+	writeFile.Close()
+
+	readFile, err := os.Open(filename)
+	require.NoError(b, err)
+	defer readFile.Close()
+
+	counter := &readCounter{reader: readFile}
+	r := NewReader(counter, opts...)
+
+	b.ResetTimer()
+	for {
+		_, err := r.Read(buffer)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(b, err)
+	}
+	b.ReportMetric(float64(counter.reads)/float64(b.N), "reads/op")
+}
+
+func BenchmarkRawFileRead(b *testing.B) {
+	benchmarkRawFileRead(b)
+}
+
+func BenchmarkRawFileReadBuffered(b *testing.B) {
+	benchmarkRawFileRead(b, WithReadBuffer(64*1024))
+}
+
+func TestNoSkipOnTooSmall(t *testing.T) {
+	writer := bytes.NewBuffer([]byte{})
+	w := NewWriter(writer)
+	w.Write([]byte("this is test string 0"))
+	w.Write([]byte("short"))
+
+	r := NewReader(bytes.NewReader(writer.Bytes()), WithNoSkipOnTooSmall())
+
+	// the record stays in the stream however many times we retry
+	_, err := r.Read(make([]byte, 10))
+	require.ErrorIs(t, err, ErrTargetBufferTooSmall)
+	_, err = r.Read(make([]byte, 10))
+	require.ErrorIs(t, err, ErrTargetBufferTooSmall)
+
+	readBuffer := make([]byte, 100)
+	n, err := r.Read(readBuffer)
+	require.NoError(t, err)
+	require.Equal(t, "this is test string 0", string(readBuffer[:n]))
+
+	n, err = r.Read(readBuffer)
+	require.NoError(t, err)
+	require.Equal(t, "short", string(readBuffer[:n]))
+
+	_, err = r.Read(readBuffer)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestOversizePolicy(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithCodec(GzipCodec{})}} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, opts...)
+		for _, rec := range []string{"a record that is too large", "short"} {
+			_, err := w.WriteString(rec)
+			require.NoError(t, err)
+		}
+
+		// skip and truncate both leave the stream at the next record
+		for _, c := range []struct {
+			policy OversizePolicy
+			want   string
+		}{
+			{OversizeSkip, ""},
+			{OversizeTruncate, "a record"},
+		} {
+			r := NewReader(bytes.NewReader(buf.Bytes()), append(opts, WithOversizePolicy(c.policy))...)
+			p := make([]byte, 8)
+			n, err := r.Read(p)
+			require.ErrorIs(t, err, ErrTargetBufferTooSmall)
+			require.Equal(t, c.want, string(p[:n]))
+
+			n, err = r.Read(p)
+			require.NoError(t, err)
+			require.Equal(t, "short", string(p[:n]))
+			require.EqualValues(t, 2, r.Stats().RecordsRead+r.Stats().RecordsSkipped)
+		}
+	}
+
+	// error leaves the stream at the record itself
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	_, err := w.WriteString("a record that is too large")
+	require.NoError(t, err)
+
+	r := NewReader(bytes.NewReader(buf.Bytes()), WithOversizePolicy(OversizeError))
+	n, err := r.Read(make([]byte, 8))
+	require.ErrorIs(t, err, ErrTargetBufferTooSmall)
+	require.Zero(t, n)
+
+	p := make([]byte, 100)
+	n, err = r.Read(p)
+	require.NoError(t, err)
+	require.Equal(t, "a record that is too large", string(p[:n]))
+	pos, err := r.Seek(0, io.SeekCurrent)
+	require.NoError(t, err)
+	require.EqualValues(t, buf.Len(), pos)
+
+	_, err = r.Read(p)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+// limitWriter fails every write larger than limit bytes.
+type limitWriter struct {
+	io.Writer
+	limit int
+}
+
+var errWriteTooLarge = errors.New("write too large")
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if len(p) > l.limit {
+		return 0, errWriteTooLarge
+	}
+	return l.Writer.Write(p)
+}
+
+func TestBufioFlushOnError(t *testing.T) {
+	underlying := bytes.NewBuffer([]byte{})
+	bw := bufio.NewWriterSize(&limitWriter{Writer: underlying, limit: 5000}, 4096)
+
+	w := NewWriter(bw)
+	for i := 0; i < 3; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("this is test string %d", i)))
+		require.NoError(t, err)
+	}
+
+	// this record needs a write larger than the underlying writer accepts
+	_, err := w.Write(make([]byte, 10000))
+	require.ErrorIs(t, err, errWriteTooLarge)
+	require.ErrorIs(t, w.Close(), errWriteTooLarge)
+
+	// the records written before the error made it to the underlying writer
+	r := NewReader(bytes.NewReader(underlying.Bytes()))
+	readBuffer := make([]byte, 100)
+	for i := 0; i < 3; i++ {
+		n, err := r.Read(readBuffer)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("this is test string %d", i), string(readBuffer[:n]))
+	}
+}
+
+func TestCloseFlushesBufio(t *testing.T) {
+	underlying := bytes.NewBuffer([]byte{})
+	w := NewWriter(bufio.NewWriter(underlying))
+
+	_, err := w.Write([]byte("buffered"))
+	require.NoError(t, err)
+	require.Zero(t, underlying.Len())
+
+	require.NoError(t, w.Close())
+
+	readBuffer := make([]byte, 100)
+	n, err := NewReader(underlying).Read(readBuffer)
+	require.NoError(t, err)
+	require.Equal(t, "buffered", string(readBuffer[:n]))
+}
+
+// closeCounter counts the calls to Close.
+type closeCounter struct {
+	bytes.Buffer
+	closes int
+}
+
+func (c *closeCounter) Close() error {
+	c.closes++
+	if c.closes > 1 {
+		return errors.New("already closed")
+	}
+	return nil
+}
+
+func TestCloseDelegates(t *testing.T) {
+	dst := &closeCounter{}
+	w := NewWriter(dst, WithWriteBuffer(64))
+	_, err := w.WriteString("record")
+	require.NoError(t, err)
+
+	var wc io.WriteCloser = w
+	require.NoError(t, wc.Close())
+	require.NoError(t, wc.Close())
+	require.Equal(t, 1, dst.closes)
+	require.NotZero(t, dst.Len())
+
+	src := &closeCounter{}
+	src.Write(dst.Bytes())
+	var rc io.ReadCloser = NewReader(src)
+	require.NoError(t, rc.Close())
+	require.NoError(t, rc.Close())
+	require.Equal(t, 1, src.closes)
+
+	// readers and writers of anything else close nothing
+	var buf bytes.Buffer
+	w = NewWriter(&buf)
+	_, err = w.WriteString("record")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, w.Close())
+
+	r := NewReader(&buf)
+	require.NoError(t, r.Close())
+	require.NoError(t, r.Close())
+}
+
+func TestPreReadHook(t *testing.T) {
+	writer := bytes.NewBuffer([]byte{})
+	w := NewWriter(writer)
+	w.Write([]byte("short"))
+	w.Write([]byte("this record is too long"))
+	w.Write([]byte("tiny"))
+
+	errTooLong := errors.New("too long")
+	var seen []uint32
+	hook := func(declaredLen uint32) error {
+		seen = append(seen, declaredLen)
+		if declaredLen > 10 {
+			return errTooLong
+		}
+		return nil
+	}
+
+	r := NewReader(bytes.NewReader(writer.Bytes()), WithPreReadHook(hook))
+	readBuffer := make([]byte, 100)
+
+	n, err := r.Read(readBuffer)
+	require.NoError(t, err)
+	require.Equal(t, "short", string(readBuffer[:n]))
+
+	_, err = r.Read(readBuffer)
+	require.ErrorIs(t, err, errTooLong)
+
+	// the rejected record has been skipped
+	n, err = r.Read(readBuffer)
+	require.NoError(t, err)
+	require.Equal(t, "tiny", string(readBuffer[:n]))
+
+	require.Equal(t, []uint32{5, 23, 4}, seen)
+}
+
+func TestSeek(t *testing.T) {
+	writer := bytes.NewBuffer([]byte{})
+	w := NewWriter(writer, WithStreamGuard())
+	for i := 0; i < 10; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("this is test string %d", i)))
+		require.NoError(t, err)
+	}
+
+	for _, opts := range [][]Option{{}, {WithReadBuffer(16)}} {
+		opts = append(opts, WithStreamGuard())
+		r := NewReader(bytes.NewReader(writer.Bytes()), opts...)
+
+		readBuffer := make([]byte, 100)
+		for i := 0; i < 5; i++ {
+			_, err := r.Read(readBuffer)
+			require.NoError(t, err)
+		}
+
+		// the reader only got part of the way through the stream
+		frameSize := int64(1 + 4 + len("this is test string 0"))
+		pos, err := r.Seek(0, io.SeekCurrent)
+		require.NoError(t, err)
+		require.Equal(t, 5*frameSize, pos)
+
+		// asking for the position does not disturb reading
+		n, err := r.Read(readBuffer)
+		require.NoError(t, err)
+		require.Equal(t, "this is test string 5", string(readBuffer[:n]))
+
+		pos, err = r.Seek(0, io.SeekStart)
+		require.NoError(t, err)
+		require.Zero(t, pos)
+
+		for i := 0; i < 10; i++ {
+			n, err := r.Read(readBuffer)
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("this is test string %d", i), string(readBuffer[:n]))
+		}
+		_, err = r.Read(readBuffer)
+		require.ErrorIs(t, err, io.EOF)
+	}
+
+	_, err := NewReader(writer).Seek(0, io.SeekStart)
+	require.ErrorIs(t, err, ErrNotSeekable)
+}
+
+func TestShortReads(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	records := [][]byte{[]byte("first"), bytes.Repeat([]byte{'x'}, 1000), []byte("third")}
+	for _, rec := range records {
+		_, err := w.Write(rec)
+		require.NoError(t, err)
+	}
+
+	r := NewReader(iotest.OneByteReader(bytes.NewReader(buf.Bytes())))
+	p := make([]byte, 2000)
+	for _, rec := range records {
+		n, err := r.Read(p)
+		require.NoError(t, err)
+		require.Equal(t, rec, p[:n])
+	}
+	_, err := r.Read(p)
+	require.Equal(t, io.EOF, err)
+
+	// the stream ending mid-payload is not a clean EOF
+	truncated := buf.Bytes()[:buf.Len()-2]
+	r = NewReader(iotest.OneByteReader(bytes.NewReader(truncated)))
+	for i := 0; i < 2; i++ {
+		_, err := r.Read(p)
+		require.NoError(t, err)
+	}
+	_, err = r.Read(p)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+// chunkReader returns at most size bytes from every Read, like a network
+// connection delivering a stream in small segments.
+type chunkReader struct {
+	r    io.Reader
+	size int
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if len(p) > c.size {
+		p = p[:c.size]
+	}
+	return c.r.Read(p)
+}
+
+func TestChunkedReads(t *testing.T) {
+	records := [][]byte{[]byte("first"), {}, bytes.Repeat([]byte{'x'}, 1000), []byte("last")}
+
+	for _, opts := range [][]Option{
+		nil,
+		{WithHeader(), WithChecksum(), WithStreamGuard(), WithSyncMarkers()},
+		{WithVarintLength(), WithMerkleChain(), WithPrevFrameCRC()},
+		{WithLength64(), WithTypeTag()},
+		{WithVarintLength(), WithLength64(), WithChecksum()},
+		{WithExtendedHeader()},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, opts...)
+		for _, rec := range records {
+			_, err := w.Write(rec)
+			require.NoError(t, err)
+		}
+
+		for _, size := range []int{1, 3, 7} {
+			// frames, length prefixes included, arrive split over many
+			// reads and are reassembled before they are returned
+			r := NewReader(&chunkReader{r: bytes.NewReader(buf.Bytes()), size: size}, opts...)
+			p := make([]byte, 2000)
+			for _, rec := range records {
+				n, err := r.Read(p)
+				require.NoError(t, err)
+				require.Equal(t, rec, p[:n])
+			}
+			_, err := r.Read(p)
+			require.Equal(t, io.EOF, err)
+
+			r = NewReader(&chunkReader{r: bytes.NewReader(buf.Bytes()), size: size}, opts...)
+			for _, rec := range records {
+				got, err := r.ReadRecord()
+				require.NoError(t, err)
+				require.Equal(t, rec, got)
+			}
+			_, err = r.ReadRecord()
+			require.Equal(t, io.EOF, err)
+		}
+	}
+}
+
+// splitRecords splits data into records, taking the length of each from
+// the next two bytes of lengths, where a length of 0xffff means the rest of
+// data. Whatever is left once lengths is used up makes up the last record.
+func splitRecords(data, lengths []byte) [][]byte {
+	var records [][]byte
+	for len(lengths) >= 2 {
+		n := int(binary.LittleEndian.Uint16(lengths))
+		lengths = lengths[2:]
+		if n > len(data) || n == 0xffff {
+			n = len(data)
+		}
+		records = append(records, data[:n])
+		data = data[n:]
+	}
+	return append(records, data)
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	f.Add([]byte{}, []byte{}, uint8(0))
+	f.Add([]byte{}, []byte{0, 0, 0, 0, 0, 0}, uint8(2))
+	f.Add([]byte{'x'}, []byte{1, 0}, uint8(0))
+	f.Add([]byte("hello world"), []byte{5, 0, 0, 0, 1, 0}, uint8(3))
+	f.Add(bytes.Repeat([]byte{0xab}, 2<<20), []byte{0, 0, 0xff, 0xff, 0, 0}, uint8(255))
+
+	f.Fuzz(func(t *testing.T, data []byte, lengths []byte, readSize uint8) {
+		records := splitRecords(data, lengths)
+
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		for _, rec := range records {
+			n, err := w.Write(rec)
+			require.NoError(t, err)
+			require.Equal(t, len(rec), n)
+		}
+
+		// reads of any size, down to a byte at a time, make no difference
+		r := NewReader(&chunkReader{r: &buf, size: int(readSize) + 1})
+		for i, want := range records {
+			got, err := r.ReadRecord()
+			require.NoError(t, err, "record %d", i)
+			require.True(t, bytes.Equal(want, got), "record %d", i)
+		}
+		_, err := r.ReadRecord()
+		require.ErrorIs(t, err, io.EOF)
+	})
+}
+
+func TestByteOrder(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithByteOrder(binary.BigEndian))
+	_, err := w.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{0, 0, 0, 5, 'h', 'e', 'l', 'l', 'o'}, buf.Bytes())
+
+	p := make([]byte, 10)
+	n, err := NewReader(bytes.NewReader(buf.Bytes()), WithByteOrder(binary.BigEndian)).Read(p)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), p[:n])
+
+	// the default is little endian
+	buf.Reset()
+	_, err = NewWriter(buf).Write([]byte("hello"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{5, 0, 0, 0, 'h', 'e', 'l', 'l', 'o'}, buf.Bytes())
+}
+
+func TestMaxRecordSize(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	_, err := w.Write([]byte("small"))
+	require.NoError(t, err)
+
+	// a corrupt length prefix claiming close to 2GiB follows
+	data := append(buf.Bytes(), 0xf0, 0xff, 0xff, 0x7f, 'x')
+
+	r := NewReader(bytes.NewReader(data), WithMaxRecordSize(1024))
+	p := make([]byte, 100)
+	n, err := r.Read(p)
+	require.NoError(t, err)
+	require.Equal(t, "small", string(p[:n]))
+
+	_, err = r.Read(p)
+	require.ErrorIs(t, err, ErrRecordTooLarge)
+	require.Contains(t, err.Error(), "offset 9")
+	require.Contains(t, err.Error(), "length 2147483632")
+
+	// the allocating read methods fail without allocating the record
+	alloc := &trackingAllocator{}
+	_, _, err = NewReader(bytes.NewReader(data[9:]), WithMaxRecordSize(1024), WithAllocator(alloc)).NextWithDigest()
+	require.ErrorIs(t, err, ErrRecordTooLarge)
+	require.Zero(t, alloc.gets)
+}
+
+func TestReadRecord(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	sizes := []int{0, 1, 10, 1000, 100000}
+	for _, size := range sizes {
+		_, err := w.Write(bytes.Repeat([]byte{'x'}, size))
+		require.NoError(t, err)
+	}
+	data := buf.Bytes()
+
+	r := NewReader(bytes.NewReader(data))
+	for _, size := range sizes {
+		rec, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.Len(t, rec, size)
+		require.Equal(t, size, cap(rec))
+	}
+	_, err := r.ReadRecord()
+	require.Equal(t, io.EOF, err)
+
+	// ending within the payload
+	r = NewReader(bytes.NewReader(data[:len(data)-1]))
+	for range sizes[:len(sizes)-1] {
+		_, err := r.ReadRecord()
+		require.NoError(t, err)
+	}
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// ending within the length prefix
+	r = NewReader(bytes.NewReader(data[:2]))
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+type writeCounter struct {
+	writer io.Writer
+	writes int
+}
+
+func (c *writeCounter) Write(p []byte) (int, error) {
+	c.writes++
+	return c.writer.Write(p)
+}
+
+func TestWriteBuffer(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "buffered.seq")
+	f, err := os.Create(filename)
+	require.NoError(t, err)
+
+	w := NewWriter(f, WithWriteBuffer(4096))
+	for i := 0; i < 3; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Empty(t, data)
+
+	// Flush makes the records visible to readers
+	require.NoError(t, w.Flush())
+	data, err = os.ReadFile(filename)
+	require.NoError(t, err)
+
+	r := NewReader(bytes.NewReader(data))
+	p := make([]byte, 100)
+	for i := 0; i < 3; i++ {
+		n, err := r.Read(p)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(p[:n]))
+	}
+
+	// Close flushes and closes the file
+	_, err = w.Write([]byte("last"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.ErrorIs(t, f.Close(), os.ErrClosed)
+
+	data, err = os.ReadFile(filename)
+	require.NoError(t, err)
+	records, _, err := Decode(data)
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+}
+
+func BenchmarkWriteBuffer(b *testing.B) {
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{"unbuffered", nil},
+		{"buffered", []Option{WithWriteBuffer(64 * 1024)}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			counter := &writeCounter{writer: io.Discard}
+			w := NewWriter(counter, bench.opts...)
+			p := make([]byte, 100)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := w.Write(p)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			err := w.Flush()
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportMetric(float64(counter.writes)/float64(b.N), "writes/record")
+		})
+	}
+}
+
+func TestSingleWritePerRecord(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithStreamGuard(), WithSchemaVersion(1)},
+		{WithChecksum(), WithMerkleChain(), WithPrevFrameCRC()},
+		{WithVarintLength()},
+	} {
+		counter := &writeCounter{writer: io.Discard}
+		w := NewWriter(counter, opts...)
+		for i := 0; i < 10; i++ {
+			p := []byte(fmt.Sprintf("record %d", i))
+			n, err := w.Write(p)
+			require.NoError(t, err)
+			require.Equal(t, len(p), n)
+		}
+		require.Equal(t, 10, counter.writes)
+	}
+}
+
+func TestZeroLengthRecords(t *testing.T) {
+	records := [][]byte{{}, []byte("one"), {}, {}, []byte("two"), {}}
+
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for _, rec := range records {
+		n, err := w.Write(rec)
+		require.NoError(t, err)
+		require.Equal(t, len(rec), n)
+	}
+	data := buf.Bytes()
+
+	r := NewReader(bytes.NewReader(data))
+	p := make([]byte, 10)
+	for _, rec := range records {
+		n, err := r.Read(p)
+		require.NoError(t, err)
+		require.Equal(t, rec, p[:n])
+	}
+	_, err := r.Read(p)
+	require.Equal(t, io.EOF, err)
+
+	r = NewReader(bytes.NewReader(data))
+	for _, rec := range records {
+		got, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		require.Equal(t, rec, got)
+	}
+	_, err = r.ReadRecord()
+	require.Equal(t, io.EOF, err)
+
+	// an empty record at the start of a scan is not nil either
+	for rec, err := range NewReader(bytes.NewReader(data)).Records() {
+		require.NoError(t, err)
+		require.NotNil(t, rec)
+	}
+}
+
+func TestLength64(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithLength64(), WithHeader())
+	for i := 0; i < 3; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+	require.Equal(t, []byte{8, 0, 0, 0, 0, 0, 0, 0}, buf.Bytes()[headerSize:headerSize+8])
+
+	r := NewReader(bytes.NewReader(buf.Bytes()), WithLength64(), WithHeader())
+	for i := 0; i < 3; i++ {
+		rec, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(rec))
+	}
+
+	// the header tells readers with a different prefix apart
+	_, err := NewReader(bytes.NewReader(buf.Bytes()), WithHeader()).ReadRecord()
+	require.ErrorIs(t, err, ErrFramingMismatch)
+
+	// a length beyond 32 bits, without the payload
+	prefix := binary.LittleEndian.AppendUint64(nil, 5<<30)
+	length, err := NewReader(bytes.NewReader(prefix), WithLength64()).PeekLength()
+	require.NoError(t, err)
+	require.Equal(t, uint64(5<<30), length)
+}
+
+func TestLengthFieldSize(t *testing.T) {
+	// the highest bit of the prefix marks checksummed records, so a prefix
+	// holds half the lengths its size would allow
+	for _, tc := range []struct {
+		size    int
+		largest int64
+	}{
+		{1, 127},
+		{2, 32767},
+		{4, 1<<31 - 1},
+	} {
+		t.Run(fmt.Sprintf("%d bytes", tc.size), func(t *testing.T) {
+			size := tc.size
+			largest := int(tc.largest)
+			if size == 4 {
+				// don't allocate 2GiB, the boundary is checked without
+				// writing the payload below
+				largest = 1 << 20
+			}
+			sizes := []int{0, 1, largest}
+
+			buf := bytes.NewBuffer([]byte{})
+			w := NewWriter(buf, WithLengthFieldSize(size), WithHeader())
+			for _, n := range sizes {
+				_, err := w.Write(make([]byte, n))
+				require.NoError(t, err)
+			}
+			require.Equal(t, headerSize+len(sizes)*size+largest+1, buf.Len())
+
+			if size < 4 {
+				_, err := w.Write(make([]byte, largest+1))
+				require.ErrorIs(t, err, ErrRecordTooLarge)
+			} else if tc.largest < math.MaxInt {
+				// WriteLen checks the length before reading the payload
+				_, err := NewWriter(io.Discard, WithLengthFieldSize(size)).WriteLen(int(tc.largest), eofReader{})
+				require.NotErrorIs(t, err, ErrRecordTooLarge)
+				_, err = NewWriter(io.Discard, WithLengthFieldSize(size)).WriteLen(int(tc.largest)+1, eofReader{})
+				require.ErrorIs(t, err, ErrRecordTooLarge)
+			}
+
+			r := NewReader(bytes.NewReader(buf.Bytes()), WithLengthFieldSize(size), WithHeader())
+			for _, n := range sizes {
+				rec, err := r.ReadRecord()
+				require.NoError(t, err)
+				require.Len(t, rec, n)
+			}
+			_, err := r.ReadRecord()
+			require.ErrorIs(t, err, io.EOF)
+
+			// the header tells readers with a different prefix apart
+			_, err = NewReader(bytes.NewReader(buf.Bytes()), WithLengthFieldSize(8), WithHeader()).ReadRecord()
+			require.ErrorIs(t, err, ErrFramingMismatch)
+		})
+	}
+
+	t.Run("checksum", func(t *testing.T) {
+		buf := bytes.NewBuffer([]byte{})
+		w := NewWriter(buf, WithLengthFieldSize(1), WithChecksum())
+
+		// the checksum takes 4 bytes of the length
+		_, err := w.Write(make([]byte, 127-checksumSize))
+		require.NoError(t, err)
+		_, err = w.Write(make([]byte, 128-checksumSize))
+		require.ErrorIs(t, err, ErrRecordTooLarge)
+
+		rec, err := NewReader(buf, WithLengthFieldSize(1), WithChecksum()).ReadRecord()
+		require.NoError(t, err)
+		require.Len(t, rec, 127-checksumSize)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewWriter(io.Discard, WithLengthFieldSize(3)).Write([]byte("x"))
+		require.ErrorIs(t, err, ErrInvalidLengthFieldSize)
+
+		_, err = NewReader(bytes.NewReader([]byte{1, 0, 0, 'x'}), WithLengthFieldSize(3)).ReadRecord()
+		require.ErrorIs(t, err, ErrInvalidLengthFieldSize)
+	})
+}
+
+func TestWriteRecordTooLarge(t *testing.T) {
+	// a slice that claims to be 5GiB without the memory to back it, which
+	// is fine as long as the writer refuses it before reading it
+	size := uint64(5 << 30)
+	if size > math.MaxInt {
+		t.Skip("slices can't be that large on this platform")
+	}
+	if raceEnabled {
+		t.Skip("the race detector refuses slices that outgrow their memory")
+	}
+	b := make([]byte, 1)
+	huge := unsafe.Slice(&b[0], int(size))
+
+	n, err := NewWriter(io.Discard).Write(huge)
+	require.ErrorIs(t, err, ErrRecordTooLarge)
+	require.Zero(t, n)
+
+	_, err = NewWriter(io.Discard, WithChecksum()).Write(huge[:size*3/5])
+	require.ErrorIs(t, err, ErrRecordTooLarge)
+}
+
+func TestPeekLength(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithChecksum())
+	for _, rec := range []string{"first", "", "third record"} {
+		_, err := w.Write([]byte(rec))
+		require.NoError(t, err)
+	}
+
+	r := NewReader(bytes.NewReader(buf.Bytes()), WithChecksum())
+	for _, rec := range []string{"first", "", "third record"} {
+		// peeking is idempotent until the record is read
+		for i := 0; i < 2; i++ {
+			length, err := r.PeekLength()
+			require.NoError(t, err)
+			require.Equal(t, uint64(len(rec)), length)
+		}
+
+		got, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.Equal(t, rec, string(got))
+	}
+
+	_, err := r.PeekLength()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestNextReaderFinishesRecord(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithChecksum(), WithIndex())
+	for _, rec := range []string{"first", "second", ""} {
+		_, err := w.Write([]byte(rec))
+		require.NoError(t, err)
+	}
+	offsets := w.Offsets()
+
+	// draining the record leaves the stream at the next record
+	r := NewReader(bytes.NewReader(buf.Bytes()), WithChecksum())
+	rr, err := r.NextReader()
+	require.NoError(t, err)
+	data, err := io.ReadAll(rr)
+	require.NoError(t, err)
+	require.Equal(t, "first", string(data))
+
+	pos, err := r.Seek(0, io.SeekCurrent)
+	require.NoError(t, err)
+	require.Equal(t, offsets[1], pos)
+
+	// the checksum is verified by the read that reaches the end
+	corrupt := append([]byte{}, buf.Bytes()...)
+	corrupt[offsets[1]+4] ^= 0xff
+
+	r = NewReader(bytes.NewReader(corrupt), WithChecksum())
+	_, err = r.ReadRecord()
+	require.NoError(t, err)
+	rr, err = r.NextReader()
+	require.NoError(t, err)
+	_, err = io.ReadAll(rr)
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+
+	// a stream ending within the payload
+	r = NewReader(bytes.NewReader(buf.Bytes()[:offsets[1]+6]), WithChecksum())
+	_, err = r.ReadRecord()
+	require.NoError(t, err)
+	rr, err = r.NextReader()
+	require.NoError(t, err)
+	_, err = io.ReadAll(rr)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// empty records
+	r = NewReader(bytes.NewReader(buf.Bytes()[offsets[2]:]), WithChecksum())
+	rr, err = r.NextReader()
+	require.NoError(t, err)
+	data, err = io.ReadAll(rr)
+	require.NoError(t, err)
+	require.Empty(t, data)
+	_, err = r.NextReader()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestReset(t *testing.T) {
+	opts := []Option{WithStreamGuard(), WithHeader(), WithWriteBuffer(1024)}
+
+	first := bytes.NewBuffer([]byte{})
+	second := bytes.NewBuffer([]byte{})
+	w := NewWriter(first, opts...)
+	for i := 0; i < 3; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("first %d", i)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Flush())
+
+	// the second stream starts over with its own header and guard
+	w.Reset(second)
+	_, err := w.Write([]byte("second 0"))
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+
+	r := NewReader(bytes.NewReader(first.Bytes()), opts...)
+	length, err := r.PeekLength()
+	require.NoError(t, err)
+	require.Equal(t, uint64(len("first 0")), length)
+
+	// the peeked length does not leak into the next stream
+	r.Reset(bytes.NewReader(second.Bytes()))
+	rec, err := r.ReadRecord()
+	require.NoError(t, err)
+	require.Equal(t, "second 0", string(rec))
+	_, err = r.ReadRecord()
+	require.Equal(t, io.EOF, err)
+}
+
+func BenchmarkReaderReset(b *testing.B) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	_, err := w.Write([]byte("record"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := buf.Bytes()
+	src := bytes.NewReader(data)
+
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			src.Reset(data)
+			r := NewReader(src, WithReadBuffer(4096))
+			_, err := r.readBuffered()
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("reset", func(b *testing.B) {
+		b.ReportAllocs()
+		r := NewReader(src, WithReadBuffer(4096))
+		for i := 0; i < b.N; i++ {
+			src.Reset(data)
+			r.Reset(src)
+			_, err := r.readBuffered()
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestCountRecords(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for i := 0; i < 1000; i++ {
+		_, err := w.Write(make([]byte, i))
+		require.NoError(t, err)
+	}
+
+	count, err := CountRecords(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	manual := 0
+	r := NewReader(bytes.NewReader(buf.Bytes()))
+	for {
+		_, err := r.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		manual++
+	}
+	require.Equal(t, manual, count)
+	require.Equal(t, 1000, count)
+
+	// skipping the records one by one
+	r = NewReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, r.Skip())
+	rec, err := r.ReadRecord()
+	require.NoError(t, err)
+	require.Len(t, rec, 1)
+
+	// truncated streams are reported
+	_, err = CountRecords(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func BenchmarkSkip(b *testing.B) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for i := 0; i < 100; i++ {
+		_, err := w.Write(make([]byte, 64*1024))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	data := buf.Bytes()
+
+	b.Run("read", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r := NewReader(bytes.NewReader(data))
+			for {
+				_, err := r.ReadRecord()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("skip", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := CountRecords(bytes.NewReader(data))
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestWriteString(t *testing.T) {
+	records := []string{"first", "", "a somewhat longer third record"}
+
+	for _, opts := range [][]Option{
+		nil,
+		{WithChecksum(), WithStreamGuard()},
+		{WithMerkleChain()},
+		{WithCodec(GzipCodec{})},
+	} {
+		var fromBytes, fromString bytes.Buffer
+		wb := NewWriter(&fromBytes, opts...)
+		ws := NewWriter(&fromString, opts...)
+
+		for _, s := range records {
+			nb, err := wb.Write([]byte(s))
+			require.NoError(t, err)
+			ns, err := ws.WriteString(s)
+			require.NoError(t, err)
+			require.Equal(t, nb, ns)
+		}
+		require.Equal(t, fromBytes.Bytes(), fromString.Bytes())
+	}
+}
+
+func BenchmarkWriteString(b *testing.B) {
+	s := strings.Repeat("x", 100)
+
+	b.Run("WriteString", func(b *testing.B) {
+		w := NewWriter(io.Discard)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := w.WriteString(s)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Write", func(b *testing.B) {
+		w := NewWriter(io.Discard)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := w.Write([]byte(s))
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestReadInto(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, s := range []string{"small", "exactly8", "a record that does not fit"} {
+		_, err := w.WriteString(s)
+		require.NoError(t, err)
+	}
+
+	r := NewReader(&buf)
+	dst := make([]byte, 0, 8)
+
+	// fits: the buffer is reused
+	got, err := r.ReadInto(dst)
+	require.NoError(t, err)
+	require.Equal(t, "small", string(got))
+	require.Equal(t, unsafe.SliceData(dst[:1]), unsafe.SliceData(got))
+
+	// exact fit: still reused
+	got, err = r.ReadInto(dst)
+	require.NoError(t, err)
+	require.Equal(t, "exactly8", string(got))
+	require.Equal(t, unsafe.SliceData(dst[:1]), unsafe.SliceData(got))
+
+	// too small: a larger buffer is allocated instead of skipping
+	got, err = r.ReadInto(dst)
+	require.NoError(t, err)
+	require.Equal(t, "a record that does not fit", string(got))
+	require.Zero(t, r.Stats().RecordsSkipped)
+
+	_, err = r.ReadInto(dst)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestReadIntoCodec(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithCodec(GzipCodec{}))
+	_, err := w.WriteString(strings.Repeat("compressible ", 20))
+	require.NoError(t, err)
+
+	r := NewReader(&buf, WithCodec(GzipCodec{}))
+	got, err := r.ReadInto(make([]byte, 0, 4))
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("compressible ", 20), string(got))
+}
+
+func TestTruncatedLengthPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	_, err := w.WriteString("first")
+	require.NoError(t, err)
+	_, err = w.WriteString("second")
+	require.NoError(t, err)
+	data := buf.Bytes()
+	second := 4 + len("first")
+
+	// ending at a record boundary is a clean EOF
+	r := NewReader(bytes.NewReader(data[:second]))
+	_, err = r.ReadRecord()
+	require.NoError(t, err)
+	_, err = r.ReadRecord()
+	require.Equal(t, io.EOF, err)
+
+	// ending within the length prefix is not
+	for n := 1; n < 4; n++ {
+		r := NewReader(bytes.NewReader(data[:second+n]))
+		_, err = r.ReadRecord()
+		require.NoError(t, err)
+		_, err = r.ReadRecord()
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		require.NotErrorIs(t, err, io.EOF)
+		require.Contains(t, err.Error(), fmt.Sprintf("after %d of 4", n))
+	}
+
+	// nor is ending right after the guard byte
+	buf.Reset()
+	w = NewWriter(&buf, WithStreamGuard())
+	_, err = w.WriteString("first")
+	require.NoError(t, err)
+
+	r = NewReader(bytes.NewReader(buf.Bytes()[:1]), WithStreamGuard())
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	r = NewReader(bytes.NewReader(nil), WithStreamGuard())
+	_, err = r.ReadRecord()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestNext(t *testing.T) {
+	sizes := []int{5, 0, 100, 5000}
+
+	for _, opts := range [][]Option{
+		nil,
+		{WithReadBuffer(1024)},
+		{WithReadBuffer(1024), WithChecksum()},
+		{WithCodec(GzipCodec{})},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, opts...)
+		for i, n := range sizes {
+			_, err := w.Write(bytes.Repeat([]byte{byte(i)}, n))
+			require.NoError(t, err)
+		}
+
+		r := NewReader(&buf, opts...)
+		for i, n := range sizes {
+			rec, err := r.Next()
+			require.NoError(t, err)
+			require.NotNil(t, rec)
+			require.Equal(t, bytes.Repeat([]byte{byte(i)}, n), rec)
+		}
+		_, err := r.Next()
+		require.ErrorIs(t, err, io.EOF)
+		require.EqualValues(t, len(sizes), r.Stats().RecordsRead)
+	}
+}
+
+func TestNextTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := NewWriter(&buf).WriteString("truncated")
+	require.NoError(t, err)
+
+	r := NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-1]), WithReadBuffer(64))
+	_, err = r.Next()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func BenchmarkNext(b *testing.B) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for i := 0; i < 1000; i++ {
+		_, err := w.Write(make([]byte, 4096))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	data := buf.Bytes()
+
+	b.Run("Next", func(b *testing.B) {
+		src := bytes.NewReader(data)
+		r := NewReader(src, WithReadBuffer(256*1024))
+		b.ReportAllocs()
+		b.SetBytes(4096)
+		for i := 0; i < b.N; i++ {
+			_, err := r.Next()
+			if err == io.EOF {
+				src.Reset(data)
+				r.Reset(src)
+				continue
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Read", func(b *testing.B) {
+		src := bytes.NewReader(data)
+		r := NewReader(src, WithReadBuffer(256*1024))
+		p := make([]byte, 4096)
+		b.ReportAllocs()
+		b.SetBytes(4096)
+		for i := 0; i < b.N; i++ {
+			_, err := r.Read(p)
+			if err == io.EOF {
+				src.Reset(data)
+				r.Reset(src)
+				continue
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// sizeRecorder records the size of every write.
+type sizeRecorder struct {
+	bytes.Buffer
+	sizes []int
+}
+
+func (s *sizeRecorder) Write(p []byte) (int, error) {
+	s.sizes = append(s.sizes, len(p))
+	return s.Buffer.Write(p)
+}
+
+func TestNewWriterSize(t *testing.T) {
+	dst := &sizeRecorder{}
+	w := NewWriterSize(dst, 64, WithChecksum())
+
+	small := []byte("small")
+	large := bytes.Repeat([]byte("large"), 200)
+	for _, p := range [][]byte{small, small, large, small} {
+		_, err := w.Write(p)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Flush())
+
+	// the small records are buffered and the large one goes straight
+	// through once the buffer has been filled up and flushed
+	require.Len(t, dst.sizes, 3)
+	require.Greater(t, dst.sizes[1], 64)
+
+	r := NewReader(&dst.Buffer, WithChecksum())
+	for _, p := range [][]byte{small, small, large, small} {
+		rec, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.Equal(t, p, rec)
+	}
+}
+
+func BenchmarkNewWriterSize(b *testing.B) {
+	for _, recordSize := range []int{16, 4096} {
+		for _, bufferSize := range []int{0, 512, 4096, 65536} {
+			b.Run(fmt.Sprintf("record=%d/buffer=%d", recordSize, bufferSize), func(b *testing.B) {
+				counter := &writeCounter{writer: io.Discard}
+				w := NewWriterSize(counter, bufferSize)
+				p := make([]byte, recordSize)
+
+				b.SetBytes(int64(recordSize))
+				for i := 0; i < b.N; i++ {
+					_, err := w.Write(p)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				err := w.Flush()
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.ReportMetric(float64(counter.writes)/float64(b.N), "writes/record")
+			})
+		}
+	}
+}
+
+func TestEOFContract(t *testing.T) {
+	readers := map[string]func(r *Reader) error{
+		"Read": func(r *Reader) error {
+			_, err := r.Read(make([]byte, 100))
+			return err
+		},
+		"ReadRecord": func(r *Reader) error {
+			_, err := r.ReadRecord()
+			return err
+		},
+		"Next": func(r *Reader) error {
+			_, err := r.Next()
+			return err
+		},
+		"Skip": func(r *Reader) error {
+			return r.Skip()
+		},
+	}
+
+	for name, read := range readers {
+		for _, opts := range [][]Option{
+			nil,
+			{WithReadBuffer(16)},
+			{WithVarintLength()},
+			{WithPrevFrameCRC()},
+		} {
+			for records := 0; records < 3; records++ {
+				var buf bytes.Buffer
+				w := NewWriter(&buf, opts...)
+				for i := 0; i < records; i++ {
+					_, err := w.Write([]byte("record"))
+					require.NoError(t, err)
+				}
+
+				for _, tail := range [][]byte{
+					nil,
+					{0x09},
+					{0x09, 0x00},
+					{0x09, 0x00, 0x00},
+					{0x09, 0x00, 0x00, 0x00, 'x'},
+				} {
+					data := append(bytes.Clone(buf.Bytes()), tail...)
+					r := NewReader(bytes.NewReader(data), opts...)
+					for i := 0; i < records; i++ {
+						require.NoError(t, read(r), name)
+					}
+
+					err := read(r)
+					if len(tail) == 0 {
+						require.Equal(t, io.EOF, err, name)
+						continue
+					}
+					require.ErrorIs(t, err, io.ErrUnexpectedEOF, "%s with %d byte tail", name, len(tail))
+
+					var fe *FramingError
+					require.ErrorAs(t, err, &fe, name)
+					require.EqualValues(t, records, fe.Index, name)
+					require.EqualValues(t, buf.Len(), fe.Offset, name)
+				}
+			}
+		}
+	}
+}
+
+func TestEOFZeroTail(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := NewWriter(&buf).Write([]byte("record"))
+	require.NoError(t, err)
+
+	// four zero bytes are a complete empty record, not garbage
+	buf.Write([]byte{0, 0, 0, 0})
+
+	r := NewReader(&buf)
+	rec, err := r.ReadRecord()
+	require.NoError(t, err)
+	require.Equal(t, "record", string(rec))
+
+	rec, err = r.ReadRecord()
+	require.NoError(t, err)
+	require.Empty(t, rec)
+
+	_, err = r.ReadRecord()
+	require.Equal(t, io.EOF, err)
+}
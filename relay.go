@@ -0,0 +1,33 @@
+package recio
+
+import (
+	"io"
+	"net"
+)
+
+// relayMaxRecordSize is the largest payload Relay holds in memory unless
+// WithMaxRecordSize says otherwise.
+const relayMaxRecordSize = 64 << 20
+
+// Relay copies complete records from src to dst without decoding them,
+// preserving the framing, which opts describe, exactly. Every frame is
+// reassembled in memory, however it is split up on the wire, and forwarded
+// with a single write once it has been read in full and has passed
+// verification, so a read error never leaves part of a frame on dst.
+//
+// Frames are held in memory one at a time, and records larger than 64MiB
+// fail with ErrRecordTooLarge unless WithMaxRecordSize sets another limit.
+// Relay returns the number of records relayed once src reaches EOF at a
+// record boundary, after forwarding the padding and footers that follow the
+// last record as WriteTo does. EOF in the middle of a frame is reported as
+// io.ErrUnexpectedEOF. With WithFooterChecksum or WithCountFooter the
+// reader holds back the size of the footers, so the last records to arrive
+// are only forwarded once more data follows or src ends.
+func Relay(dst, src net.Conn, opts ...Option) (int64, error) {
+	opts = append([]Option{WithMaxRecordSize(relayMaxRecordSize)}, opts...)
+	records, _, err := NewReader(src, opts...).copyRecords(dst, -1)
+	if err == io.EOF {
+		err = nil
+	}
+	return int64(records), err
+}
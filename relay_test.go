@@ -0,0 +1,142 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelay(t *testing.T) {
+	numRecords := 200
+
+	stream := bytes.NewBuffer([]byte{})
+	w := NewWriter(stream)
+	for i := 0; i < numRecords; i++ {
+		payload := make([]byte, rand.Intn(10000))
+		rand.Read(payload)
+		_, err := w.Write(payload)
+		require.NoError(t, err)
+	}
+	source := stream.Bytes()
+
+	srcClient, srcServer := net.Pipe()
+	dstServer, dstClient := net.Pipe()
+
+	// feed the source in small, arbitrarily sized chunks so frames are split
+	go func() {
+		data := source
+		for len(data) > 0 {
+			n := 1 + rand.Intn(1500)
+			if n > len(data) {
+				n = len(data)
+			}
+			srcClient.Write(data[:n])
+			data = data[n:]
+		}
+		srcClient.Close()
+	}()
+
+	type result struct {
+		records int64
+		err     error
+	}
+	done := make(chan result)
+	go func() {
+		n, err := Relay(dstServer, srcServer)
+		dstServer.Close()
+		done <- result{n, err}
+	}()
+
+	relayed, err := io.ReadAll(dstClient)
+	require.NoError(t, err)
+
+	res := <-done
+	require.NoError(t, res.err)
+	require.Equal(t, int64(numRecords), res.records)
+	require.Equal(t, source, relayed)
+}
+
+// relay relays source, written to a pipe in one go and then closed, and
+// returns what arrived at the other end.
+func relay(t *testing.T, source []byte, opts ...Option) ([]byte, int64, error) {
+	srcClient, srcServer := net.Pipe()
+	dstServer, dstClient := net.Pipe()
+
+	go func() {
+		srcClient.Write(source)
+		srcClient.Close()
+	}()
+
+	type result struct {
+		records int64
+		err     error
+	}
+	done := make(chan result)
+	go func() {
+		n, err := Relay(dstServer, srcServer, opts...)
+		srcServer.Close()
+		dstServer.Close()
+		done <- result{n, err}
+	}()
+
+	relayed, err := io.ReadAll(dstClient)
+	require.NoError(t, err)
+	res := <-done
+	return relayed, res.records, res.err
+}
+
+func TestRelayOptions(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithVarintLength(), WithChecksum(), WithHeader()},
+		{WithFooterChecksum()},
+		{WithCountFooter(), WithFooterChecksum(), WithAlignment(8)},
+	} {
+		stream := bytes.NewBuffer([]byte{})
+		w := NewWriter(stream, opts...)
+		for _, p := range []string{"first", "", "third"} {
+			_, err := w.Write([]byte(p))
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+
+		relayed, n, err := relay(t, stream.Bytes(), opts...)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, n)
+		require.Equal(t, stream.Bytes(), relayed)
+
+		// the receiver gets the footers as well
+		r := NewReader(bytes.NewReader(relayed), opts...)
+		for {
+			_, err := r.ReadRecord()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestRelayPartialFrame(t *testing.T) {
+	stream := bytes.NewBuffer([]byte{})
+	w := NewWriter(stream)
+	for _, p := range []string{"first", "second"} {
+		_, err := w.Write([]byte(p))
+		require.NoError(t, err)
+	}
+
+	// the second frame is cut short and none of it is forwarded
+	relayed, n, err := relay(t, stream.Bytes()[:stream.Len()-2])
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.EqualValues(t, 1, n)
+	require.Equal(t, stream.Bytes()[:4+len("first")], relayed)
+
+	// nor is a frame over the size limit
+	relayed, n, err = relay(t, stream.Bytes(), WithMaxRecordSize(5))
+	require.ErrorIs(t, err, ErrRecordTooLarge)
+	require.EqualValues(t, 1, n)
+	require.Equal(t, stream.Bytes()[:4+len("first")], relayed)
+}
@@ -0,0 +1,98 @@
+package recio
+
+import (
+	"io"
+	"time"
+)
+
+type truncater interface {
+	Truncate(size int64) error
+}
+
+// WithWriteRetry makes the writer retry writing a frame to the underlying
+// writer up to attempts times in total when the write fails with an error
+// for which isRetryable returns true. Before retry number n (counting from
+// 1) the writer sleeps for backoff(n).
+//
+// A retry must never leave part of a frame behind. If the failed write did
+// not write anything the frame is simply written again. If it wrote part of
+// the frame, the writer can only recover when the underlying writer is an
+// io.Seeker with a Truncate method, such as *os.File: it truncates the
+// partial frame away and seeks back to where the frame started. On any other
+// writer a partially written frame is not retried and the error is returned.
+//
+// A nil isRetryable retries every error. ConstantBackoff makes the writer
+// sleep for the same time before every retry.
+func WithWriteRetry(attempts int, backoff func(attempt int) time.Duration, isRetryable func(error) bool) Option {
+	return func(o *options) {
+		o.retryAttempts = attempts
+		o.retryBackoff = backoff
+		o.retryable = isRetryable
+	}
+}
+
+// ConstantBackoff returns a backoff function for WithWriteRetry that waits d
+// before every retry.
+func ConstantBackoff(d time.Duration) func(attempt int) time.Duration {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// writeFrame writes a complete frame to the underlying writer, throttled as
+// configured by WithRateLimit and retrying as configured by WithWriteRetry.
+// A failed write that left part of the frame behind is rolled back if the
+// underlying writer allows it, see rollback. On error it returns how many
+// bytes of the frame were left behind in the underlying writer.
+func (w *Writer) writeFrame(frame []byte) (int, error) {
+	if w.limiter != nil {
+		err := w.throttle(len(frame))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		n, err := w.writer.Write(frame)
+		if err == nil {
+			return n, nil
+		}
+		if n > 0 && w.rollback(n) {
+			n = 0
+		}
+
+		// a partial frame that can't be rolled back can't be retried either
+		if n > 0 || attempt >= w.opts.retryAttempts || (w.opts.retryable != nil && !w.opts.retryable(err)) {
+			return n, err
+		}
+
+		if w.opts.retryBackoff != nil {
+			time.Sleep(w.opts.retryBackoff(attempt))
+		}
+	}
+}
+
+// rollback removes the n bytes that a failed write left at the end of the
+// underlying writer, so that the stream only ever grows by whole frames.
+// This is possible when the underlying writer is an io.Seeker with a
+// Truncate method, such as *os.File. It reports whether the bytes were
+// removed.
+func (w *Writer) rollback(n int) bool {
+	t, canTruncate := w.writer.(truncater)
+	seeker, canSeek := w.writer.(io.Seeker)
+	if !canTruncate || !canSeek {
+		return false
+	}
+
+	pos, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false
+	}
+
+	start := pos - int64(n)
+	if start < 0 || t.Truncate(start) != nil {
+		return false
+	}
+	_, err = seeker.Seek(start, io.SeekStart)
+	return err == nil
+}
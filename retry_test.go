@@ -0,0 +1,194 @@
+package recio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errTransient = errors.New("transient error")
+
+func isTransient(err error) bool {
+	return errors.Is(err, errTransient)
+}
+
+func noBackoff(int) time.Duration {
+	return 0
+}
+
+// flakyWriter fails the first failures writes, after writing partial bytes
+// of each.
+type flakyWriter struct {
+	io.Writer
+	failures int
+	partial  int
+}
+
+func (f *flakyWriter) Write(p []byte) (int, error) {
+	if f.failures > 0 {
+		f.failures--
+		n, _ := f.Writer.Write(p[:f.partial])
+		return n, errTransient
+	}
+	return f.Writer.Write(p)
+}
+
+func TestWriteRetry(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	flaky := &flakyWriter{Writer: buf, failures: 2}
+
+	w := NewWriter(flaky, WithWriteRetry(3, noBackoff, isTransient))
+	n, err := w.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+
+	r := NewReader(bytes.NewReader(buf.Bytes()))
+	readBuffer := make([]byte, 100)
+	n, err = r.Read(readBuffer)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(readBuffer[:n]))
+	_, err = r.Read(readBuffer)
+	require.ErrorIs(t, err, io.EOF)
+
+	// running out of attempts returns the error
+	flaky = &flakyWriter{Writer: io.Discard, failures: 3}
+	w = NewWriter(flaky, WithWriteRetry(3, noBackoff, isTransient))
+	_, err = w.Write([]byte("hello"))
+	require.ErrorIs(t, err, errTransient)
+}
+
+func TestWriteRetryConstantBackoff(t *testing.T) {
+	var want bytes.Buffer
+	_, err := NewWriter(&want).Write([]byte("hello"))
+	require.NoError(t, err)
+
+	// every error is retried and the record lands exactly once
+	var buf bytes.Buffer
+	flaky := &flakyWriter{Writer: &buf, failures: 3}
+	w := NewWriter(flaky, WithWriteRetry(4, ConstantBackoff(time.Millisecond), nil))
+
+	start := time.Now()
+	_, err = w.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), 3*time.Millisecond)
+	require.Equal(t, want.Bytes(), buf.Bytes())
+	require.EqualValues(t, 1, w.Stats().RecordsWritten)
+}
+
+func TestWriteRetryPartialFrame(t *testing.T) {
+	// a partial write to a non-seekable writer can't be retried
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(&flakyWriter{Writer: buf, failures: 1, partial: 3}, WithWriteRetry(3, noBackoff, isTransient))
+	_, err := w.Write([]byte("hello"))
+	require.ErrorIs(t, err, errTransient)
+}
+
+type flakyFile struct {
+	*os.File
+	failures int
+}
+
+func (f *flakyFile) Write(p []byte) (int, error) {
+	if f.failures > 0 {
+		f.failures--
+		n, _ := f.File.Write(p[:2])
+		return n, errTransient
+	}
+	return f.File.Write(p)
+}
+
+func TestWriteRetryTruncatesPartialFrame(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "retry.seq")
+	f, err := os.Create(filename)
+	require.NoError(t, err)
+
+	flaky := &flakyFile{File: f}
+	w := NewWriter(flaky, WithWriteRetry(3, noBackoff, isTransient))
+	_, err = w.Write([]byte("first"))
+	require.NoError(t, err)
+
+	flaky.failures = 2
+	_, err = w.Write([]byte("second"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Len(t, data, 4+len("first")+4+len("second"))
+
+	r := NewReader(bytes.NewReader(data))
+	readBuffer := make([]byte, 100)
+	n, err := r.Read(readBuffer)
+	require.NoError(t, err)
+	require.Equal(t, "first", string(readBuffer[:n]))
+	n, err = r.Read(readBuffer)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(readBuffer[:n]))
+}
+
+// limitedFile fails writes once limit bytes have been written, after
+// writing as much as fits.
+type limitedFile struct {
+	*os.File
+	limit int
+}
+
+func (f *limitedFile) Write(p []byte) (int, error) {
+	if len(p) <= f.limit {
+		f.limit -= len(p)
+		return f.File.Write(p)
+	}
+
+	n, _ := f.File.Write(p[:f.limit])
+	f.limit -= n
+	return n, errInjected
+}
+
+func TestWriteRollsBackPartialFrame(t *testing.T) {
+	for _, cut := range []int{1, 3, 4, 5, 4 + len("record 0") - 1} {
+		filename := filepath.Join(t.TempDir(), "rollback.seq")
+		f, err := os.Create(filename)
+		require.NoError(t, err)
+
+		frame := 4 + len("record 0")
+		lf := &limitedFile{File: f, limit: 2*frame + cut}
+		w := NewWriter(lf)
+		for i := 0; i < 2; i++ {
+			_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+			require.NoError(t, err)
+		}
+
+		// the frame that does not fit leaves nothing behind, not even the
+		// length prefix
+		_, err = w.Write([]byte("record 2"))
+		require.ErrorIs(t, err, errInjected)
+		info, err := f.Stat()
+		require.NoError(t, err)
+		require.EqualValues(t, 2*frame, info.Size())
+
+		// once the writer recovers, the stream carries on cleanly
+		lf.limit = 1 << 20
+		_, err = w.Write([]byte("record 3"))
+		require.NoError(t, err)
+
+		// so does a batch that fails part way through
+		lf.limit = frame + cut
+		_, err = w.WriteBatch([][]byte{[]byte("record 4"), []byte("record 5")})
+		require.ErrorIs(t, err, errInjected)
+		require.NoError(t, f.Close())
+
+		data, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		records, consumed, err := Decode(data)
+		require.NoError(t, err)
+		require.Equal(t, len(data), consumed)
+		require.Equal(t, [][]byte{[]byte("record 0"), []byte("record 1"), []byte("record 3")}, records)
+	}
+}
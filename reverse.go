@@ -0,0 +1,40 @@
+package recio
+
+import (
+	"io"
+)
+
+// ReverseReader reads records from the last to the first, given the
+// offsets of the records as recorded by a Writer using WithIndex, which
+// makes it possible to read the most recent records of a stream without
+// scanning it from the start.
+type ReverseReader struct {
+	records *SeekableReader
+	next    int
+}
+
+// NewReverseReader returns a ReverseReader that reads the records found at
+// the given offsets in ra, starting with the last. The same restrictions on
+// options apply as for NewSeekableReader.
+func NewReverseReader(ra io.ReaderAt, offsets []int64, opts ...Option) *ReverseReader {
+	return &ReverseReader{
+		records: NewSeekableReader(ra, offsets, opts...),
+		next:    len(offsets) - 1,
+	}
+}
+
+// Prev returns the record before the one returned by the previous call,
+// starting with the last record, and io.EOF once the first record has been
+// returned.
+func (r *ReverseReader) Prev() ([]byte, error) {
+	if r.next < 0 {
+		return nil, io.EOF
+	}
+
+	rec, err := r.records.ReadAt(r.next)
+	if err != nil {
+		return nil, err
+	}
+	r.next--
+	return rec, nil
+}
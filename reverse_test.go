@@ -0,0 +1,58 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseReader(t *testing.T) {
+	opts := []Option{WithHeader(), WithStreamGuard(), WithChecksum()}
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "reverse.seq"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	w := NewWriter(f, append(opts, WithIndex())...)
+	for i := 0; i < 50; i++ {
+		_, err := w.WriteString(fmt.Sprintf("record %d", i))
+		require.NoError(t, err)
+	}
+
+	var forward []string
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	for rec, err := range NewReader(f, opts...).Records() {
+		require.NoError(t, err)
+		forward = append(forward, string(rec))
+	}
+	require.Len(t, forward, 50)
+
+	var backward []string
+	r := NewReverseReader(f, w.Offsets(), opts...)
+	for {
+		rec, err := r.Prev()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		backward = append(backward, string(rec))
+	}
+
+	slices.Reverse(backward)
+	require.Equal(t, forward, backward)
+
+	_, err = r.Prev()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestReverseReaderEmpty(t *testing.T) {
+	_, err := NewReverseReader(bytes.NewReader(nil), nil).Prev()
+	require.ErrorIs(t, err, io.EOF)
+}
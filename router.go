@@ -0,0 +1,79 @@
+package recio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrUnknownTag = errors.New("no handler for type tag")
+
+// UnknownTagPolicy is what a Router does with a record whose type tag has no
+// handler.
+type UnknownTagPolicy int
+
+const (
+	// SkipUnknownTags drops the record and carries on with the next one.
+	SkipUnknownTags UnknownTagPolicy = iota
+
+	// RejectUnknownTags stops Run with an error wrapping ErrUnknownTag.
+	RejectUnknownTags
+)
+
+// Router reads records tagged with WriteTyped and hands each of them to the
+// handler registered for its type tag, for protocols that interleave
+// several kinds of messages in one stream.
+type Router struct {
+	reader   *Reader
+	handlers map[uint8]func([]byte) error
+	unknown  UnknownTagPolicy
+}
+
+// NewRouter returns a Router reading records from r. WithTypeTag is implied.
+func NewRouter(r io.Reader, opts ...Option) *Router {
+	return &Router{
+		reader:   NewReader(r, append(opts[:len(opts):len(opts)], WithTypeTag())...),
+		handlers: make(map[uint8]func([]byte) error),
+	}
+}
+
+// Handle registers fn as the handler for records tagged with tag, replacing
+// any handler registered for it before. The record passed to fn is newly
+// allocated and may be kept.
+func (rt *Router) Handle(tag uint8, fn func([]byte) error) {
+	rt.handlers[tag] = fn
+}
+
+// SetUnknownTagPolicy sets what Run does with records whose tag has no
+// handler. The default is SkipUnknownTags.
+func (rt *Router) SetUnknownTagPolicy(p UnknownTagPolicy) {
+	rt.unknown = p
+}
+
+// Run reads records and dispatches them until the end of the stream, and
+// then returns nil. The first error reading a record or returned by a
+// handler stops it and is returned, the latter as it is.
+func (rt *Router) Run() error {
+	for {
+		tag, rec, err := rt.reader.ReadTyped()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		fn, ok := rt.handlers[tag]
+		if !ok {
+			if rt.unknown == RejectUnknownTags {
+				return fmt.Errorf("%w: %d", ErrUnknownTag, tag)
+			}
+			continue
+		}
+
+		err = fn(rec)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package recio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithTypeTag())
+	for _, rec := range []struct {
+		tag byte
+		p   string
+	}{
+		{1, "one"},
+		{2, "two"},
+		{3, "three"},
+		{1, "uno"},
+		{2, "dos"},
+	} {
+		_, err := w.WriteTyped(rec.tag, []byte(rec.p))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	stream := buf.Bytes()
+
+	var ones, twos []string
+	router := func(opts ...Option) *Router {
+		ones, twos = nil, nil
+		rt := NewRouter(bytes.NewReader(stream), opts...)
+		rt.Handle(1, func(p []byte) error {
+			ones = append(ones, string(p))
+			return nil
+		})
+		rt.Handle(2, func(p []byte) error {
+			twos = append(twos, string(p))
+			return nil
+		})
+		return rt
+	}
+
+	// records tagged 3 are dropped by default
+	require.NoError(t, router().Run())
+	require.Equal(t, []string{"one", "uno"}, ones)
+	require.Equal(t, []string{"two", "dos"}, twos)
+
+	rt := router(WithTypeTag())
+	rt.SetUnknownTagPolicy(RejectUnknownTags)
+	err := rt.Run()
+	require.ErrorIs(t, err, ErrUnknownTag)
+	require.ErrorContains(t, err, "3")
+	require.Equal(t, []string{"one"}, ones)
+	require.Equal(t, []string{"two"}, twos)
+
+	// handler errors are returned as they are
+	errStop := errors.New("stop")
+	rt = router()
+	rt.Handle(2, func([]byte) error { return errStop })
+	require.Equal(t, errStop, rt.Run())
+	require.Equal(t, []string{"one"}, ones)
+}
@@ -0,0 +1,53 @@
+package recio
+
+import (
+	"errors"
+	"io"
+)
+
+// Issue is a problem Scan found in a stream. Offset is where the damaged
+// record starts and RecordIndex its number, counting from 0, and Reason is
+// the cause, such as ErrChecksumMismatch, ErrRecordTooLarge or
+// io.ErrUnexpectedEOF.
+type Issue struct {
+	Offset      int64
+	RecordIndex int64
+	Reason      error
+}
+
+// Scan reads all of r and returns the records that are intact along with
+// the problems found on the way, for tools that salvage what they can from
+// a damaged stream and report the rest. opts are the options the stream was
+// written with.
+//
+// With WithSyncMarkers, Scan carries on past a damaged record from the next
+// sync marker, as WithSkipCorrupt does, so every problem is listed. Without
+// them there is no telling where the next record starts and the first
+// problem ends the scan. Either way problems with the records are reported
+// as issues and err is nil; it is only set by errors that don't belong to a
+// record, in which case good and issues hold what was found before it.
+func Scan(r io.Reader, opts ...Option) (good [][]byte, issues []Issue, err error) {
+	report := func(err error) bool {
+		var fe *FramingError
+		if !errors.As(err, &fe) {
+			return false
+		}
+		issues = append(issues, Issue{Offset: fe.Offset, RecordIndex: fe.Index, Reason: fe.Err})
+		return true
+	}
+
+	reader := NewReader(r, append(opts[:len(opts):len(opts)], WithSkipCorrupt(func(_ int64, err error) { report(err) }))...)
+	for {
+		rec, err := reader.ReadRecord()
+		if err == io.EOF {
+			return good, issues, nil
+		}
+		if err != nil {
+			if report(err) {
+				return good, issues, nil
+			}
+			return good, issues, err
+		}
+		good = append(good, rec)
+	}
+}
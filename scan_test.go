@@ -0,0 +1,75 @@
+package recio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScan(t *testing.T) {
+	opts := []Option{WithSyncMarkers(), WithChecksum(), WithMaxRecordSize(64)}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, append(opts, WithIndex())...)
+	for i := 0; i < 8; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	offsets := w.Offsets()
+	stream := buf.Bytes()
+
+	// damage the payload of record 1, give record 4 an implausible length
+	// and cut record 7 short
+	prefix := offsets[4] + int64(len(syncMarker))
+	stream[offsets[1]+int64(len(syncMarker))+4] ^= 0xff
+	binary.LittleEndian.PutUint32(stream[prefix:], 1<<31|0x00ffffff)
+	stream = stream[:len(stream)-3]
+
+	// the caller's options are left alone even if they have room to spare
+	spare := append(make([]Option, 0, len(opts)+1), opts...)
+	good, issues, err := Scan(bytes.NewReader(stream), spare...)
+	require.NoError(t, err)
+	require.Nil(t, spare[:cap(spare)][len(opts)])
+
+	var got []string
+	for _, rec := range good {
+		got = append(got, string(rec))
+	}
+	require.Equal(t, []string{"record 0", "record 2", "record 3", "record 5", "record 6"}, got)
+
+	require.Len(t, issues, 3)
+	for i, want := range []struct {
+		index  int64
+		reason error
+	}{
+		{1, ErrChecksumMismatch},
+		{4, ErrRecordTooLarge},
+		{7, io.ErrUnexpectedEOF},
+	} {
+		require.Equal(t, offsets[want.index], issues[i].Offset)
+		require.Equal(t, want.index, issues[i].RecordIndex)
+		require.ErrorIs(t, issues[i].Reason, want.reason)
+	}
+
+	// without sync markers the first problem ends the scan
+	buf.Reset()
+	w = NewWriter(&buf, WithChecksum())
+	for i := 0; i < 3; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+	stream = buf.Bytes()
+	stream[FrameSize(8, WithChecksum())+4] ^= 0xff
+
+	good, issues, err = Scan(bytes.NewReader(stream), WithChecksum())
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("record 0")}, good)
+	require.Len(t, issues, 1)
+	require.EqualValues(t, 1, issues[0].RecordIndex)
+	require.ErrorIs(t, issues[0].Reason, ErrChecksumMismatch)
+}
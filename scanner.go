@@ -0,0 +1,52 @@
+package recio
+
+import "io"
+
+// Scanner reads records one at a time, in the manner of bufio.Scanner.
+// Unlike wrapping a Reader in a bufio.Reader, which coalesces reads and loses
+// the record boundaries, every successful call to Scan yields exactly one
+// record.
+type Scanner struct {
+	reader *Reader
+	record []byte
+	err    error
+}
+
+// NewScanner returns a Scanner reading records from r.
+func NewScanner(r io.Reader, opts ...Option) *Scanner {
+	return &Scanner{
+		reader: NewReader(r, opts...),
+	}
+}
+
+// Scan advances the Scanner to the next record, which is then available
+// through Bytes. It returns false when the end of the stream is reached or
+// an error occurs, after which Err returns the error.
+func (s *Scanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+
+	s.record, s.err = s.reader.readBuffered()
+	if s.err != nil {
+		s.record = nil
+		return false
+	}
+	return true
+}
+
+// Bytes returns the record found by the most recent call to Scan. The
+// underlying array is reused and may be overwritten by the next call to
+// Scan.
+func (s *Scanner) Bytes() []byte {
+	return s.record
+}
+
+// Err returns the first error encountered by the Scanner, except io.EOF,
+// which is reported as nil.
+func (s *Scanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
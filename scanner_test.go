@@ -0,0 +1,50 @@
+package recio
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner(t *testing.T) {
+	// records both smaller and larger than the default bufio buffer
+	records := [][]byte{
+		[]byte("short"),
+		bytes.Repeat([]byte{'a'}, 3*4096),
+		{},
+		bytes.Repeat([]byte{'b'}, 10),
+		bytes.Repeat([]byte{'c'}, 100000),
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for _, rec := range records {
+		_, err := w.Write(rec)
+		require.NoError(t, err)
+	}
+
+	s := NewScanner(bufio.NewReader(bytes.NewReader(buf.Bytes())))
+	var got [][]byte
+	for s.Scan() {
+		got = append(got, append([]byte{}, s.Bytes()...))
+	}
+	require.NoError(t, s.Err())
+	require.Equal(t, records, got)
+
+	// Scan keeps returning false
+	require.False(t, s.Scan())
+}
+
+func TestScannerError(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	_, err := NewWriter(buf).Write([]byte("truncated"))
+	require.NoError(t, err)
+
+	s := NewScanner(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	require.False(t, s.Scan())
+	require.ErrorIs(t, s.Err(), io.ErrUnexpectedEOF)
+	require.Nil(t, s.Bytes())
+}
@@ -0,0 +1,57 @@
+package recio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+var ErrRecordIndexOutOfRange = errors.New("record index out of range")
+
+// SeekableReader reads records at random from an io.ReaderAt, given the
+// offsets of the records, as recorded by a Writer using WithIndex. It is
+// safe for concurrent use as long as ra is, which io.ReaderAt requires.
+type SeekableReader struct {
+	ra      io.ReaderAt
+	offsets []int64
+	opts    []Option
+}
+
+// NewSeekableReader returns a SeekableReader reading the records found at
+// the given offsets from ra. The options must match the ones the records
+// were written with. Options that chain records to the ones before them,
+// WithMerkleChain and WithPrevFrameCRC, can't be verified when reading
+// records at random and must not be used.
+func NewSeekableReader(ra io.ReaderAt, offsets []int64, opts ...Option) *SeekableReader {
+	return &SeekableReader{
+		ra:      ra,
+		offsets: offsets,
+		opts:    opts,
+	}
+}
+
+// Len returns the number of records.
+func (s *SeekableReader) Len() int {
+	return len(s.offsets)
+}
+
+// ReadAt returns the record with the given index, counting from zero. An
+// index outside the offsets the SeekableReader was created with returns
+// ErrRecordIndexOutOfRange.
+func (s *SeekableReader) ReadAt(recordIndex int) ([]byte, error) {
+	if recordIndex < 0 || recordIndex >= len(s.offsets) {
+		return nil, fmt.Errorf("%w: %d, have %d records", ErrRecordIndexOutOfRange, recordIndex, len(s.offsets))
+	}
+
+	offset := s.offsets[recordIndex]
+	r := NewReader(io.NewSectionReader(s.ra, offset, math.MaxInt64-offset), s.opts...)
+
+	// pick up the state of the stream at the record
+	r.count.n = offset
+	r.headerDone = true
+	r.guard = uint8(recordIndex)
+	r.index = int64(recordIndex)
+
+	return r.readRecord()
+}
@@ -0,0 +1,73 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeekableReader(t *testing.T) {
+	opts := []Option{WithHeader(), WithStreamGuard(), WithChecksum()}
+
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, append(opts, WithIndex())...)
+	for i := 0; i < 100; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	s := NewSeekableReader(bytes.NewReader(buf.Bytes()), w.Offsets(), opts...)
+	require.Equal(t, 100, s.Len())
+
+	for _, i := range []int{99, 0, 42, 17, 17, 1} {
+		rec, err := s.ReadAt(i)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("record %d", i), string(rec))
+	}
+
+	_, err := s.ReadAt(100)
+	require.ErrorIs(t, err, ErrRecordIndexOutOfRange)
+	_, err = s.ReadAt(-1)
+	require.ErrorIs(t, err, ErrRecordIndexOutOfRange)
+}
+
+func TestSeekableReaderConcurrent(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithIndex())
+	for i := 0; i < 100; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	s := NewSeekableReader(bytes.NewReader(buf.Bytes()), w.Offsets())
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				n := (i*7 + g) % 100
+				rec, err := s.ReadAt(n)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if string(rec) != fmt.Sprintf("record %d", n) {
+					errs <- fmt.Errorf("record %d: got %q", n, rec)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,51 @@
+package recio
+
+import (
+	"errors"
+	"os"
+)
+
+// WithSkipCorrupt makes the reader skip records that fail with a
+// FramingError, such as a missing sync marker, a length beyond the limit
+// set by WithMaxRecordSize or a checksum mismatch, rather than returning
+// the error. onSkip is called with the offset of the record and the error,
+// and reading carries on with the next record after calling Resync. If no
+// record follows, the read returns io.EOF.
+//
+// This needs WithSyncMarkers, without which there is nothing to
+// resynchronize to, and errors are returned as usual. Records that are too
+// large for the caller's buffer and read timeouts are not corruption and
+// are returned as well. The rest of a record handed out by NextReader is
+// not skipped either. Options that chain records together make every
+// record after a corrupt one fail, as described at Resync, and with
+// WithChunking the chunks that follow a corrupt one are read as a record
+// of their own.
+func WithSkipCorrupt(onSkip func(offset int64, err error)) Option {
+	return func(o *options) {
+		o.onSkip = onSkip
+	}
+}
+
+// skipCorrupt reports whether err is a corrupt record that WithSkipCorrupt
+// skips, in which case the reader has moved on to the next sync marker and
+// the read should be tried again. Otherwise it returns the error for the
+// read, which is that of Resync if it found no marker.
+func (r *Reader) skipCorrupt(err error) (bool, error) {
+	if err == nil || r.opts.onSkip == nil || !r.opts.syncMarkers {
+		return false, err
+	}
+
+	var fe *FramingError
+	if !errors.As(err, &fe) || errors.Is(err, ErrTargetBufferTooSmall) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return false, err
+	}
+	r.opts.onSkip(fe.Offset, err)
+
+	r.releaseReserved()
+	r.continued = false
+	_, err = r.Resync()
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
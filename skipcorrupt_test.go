@@ -0,0 +1,150 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// corruptStream returns five records framed with opts and the offset of
+// the third, whose frame corrupt has damaged.
+func corruptStream(t *testing.T, opts []Option, corrupt func(frame []byte)) ([]byte, int64) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, opts...)
+	var offset int64
+	for i := 0; i < 5; i++ {
+		if i == 2 {
+			offset = int64(buf.Len())
+		}
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	stream := buf.Bytes()
+	corrupt(stream[offset : offset+int64(len(syncMarker)+4+len("record 2"))])
+	return stream, offset
+}
+
+func TestSkipCorrupt(t *testing.T) {
+	read := map[string]func(r *Reader) ([]byte, error){
+		"ReadRecord": (*Reader).ReadRecord,
+		"Next":       (*Reader).Next,
+		"Read": func(r *Reader) ([]byte, error) {
+			p := make([]byte, 64)
+			n, err := r.Read(p)
+			return p[:n], err
+		},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		opts    []Option
+		corrupt func(frame []byte)
+	}{
+		{
+			name: "length",
+			opts: []Option{WithSyncMarkers(), WithMaxRecordSize(64)},
+			corrupt: func(frame []byte) {
+				copy(frame[len(syncMarker):], []byte{0xff, 0xff, 0xff, 0x00})
+			},
+		},
+		{
+			name:    "marker",
+			opts:    []Option{WithSyncMarkers(), WithReadBuffer(32)},
+			corrupt: func(frame []byte) { frame[0] ^= 0xff },
+		},
+		{
+			name:    "checksum",
+			opts:    []Option{WithSyncMarkers(), WithChecksum(), WithReadBuffer(32)},
+			corrupt: func(frame []byte) { frame[len(syncMarker)+4] ^= 0xff },
+		},
+	} {
+		for method, fn := range read {
+			t.Run(tc.name+"/"+method, func(t *testing.T) {
+				stream, offset := corruptStream(t, tc.opts, tc.corrupt)
+
+				var skipped []int64
+				r := NewReader(bytes.NewReader(stream), append(tc.opts, WithSkipCorrupt(func(off int64, err error) {
+					require.Error(t, err)
+					skipped = append(skipped, off)
+				}))...)
+
+				var got []string
+				for {
+					rec, err := fn(r)
+					if err == io.EOF {
+						break
+					}
+					require.NoError(t, err)
+					got = append(got, string(rec))
+				}
+				require.Equal(t, []string{"record 0", "record 1", "record 3", "record 4"}, got)
+				require.Equal(t, []int64{offset}, skipped)
+			})
+		}
+	}
+}
+
+func TestSkipCorruptSkip(t *testing.T) {
+	opts := []Option{WithSyncMarkers(), WithMaxRecordSize(64)}
+	stream, _ := corruptStream(t, opts, func(frame []byte) { frame[1] = 0 })
+
+	calls := 0
+	r := NewReader(bytes.NewReader(stream), append(opts, WithSkipCorrupt(func(int64, error) { calls++ }))...)
+	skips := 0
+	for r.Skip() == nil {
+		skips++
+	}
+	require.Equal(t, 4, skips)
+	require.Equal(t, 1, calls)
+}
+
+func TestSkipCorruptTail(t *testing.T) {
+	opts := []Option{WithSyncMarkers()}
+	stream, _ := corruptStream(t, opts, func([]byte) {})
+
+	// a record cut short at the end is reported and ends the stream
+	calls := 0
+	r := NewReader(bytes.NewReader(stream[:len(stream)-3]), append(opts, WithSkipCorrupt(func(_ int64, err error) {
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		calls++
+	}))...)
+	n := 0
+	for {
+		_, err := r.ReadRecord()
+		if err != nil {
+			require.ErrorIs(t, err, io.EOF)
+			break
+		}
+		n++
+	}
+	require.Equal(t, 4, n)
+	require.Equal(t, 1, calls)
+}
+
+func TestSkipCorruptNeedsSyncMarkers(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithChecksum())
+	_, err := w.Write([]byte("record"))
+	require.NoError(t, err)
+	buf.Bytes()[5] ^= 0xff
+
+	calls := 0
+	r := NewReader(&buf, WithChecksum(), WithSkipCorrupt(func(int64, error) { calls++ }))
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+	require.Zero(t, calls)
+
+	// nor are records that do not fit the caller's buffer skipped
+	buf.Reset()
+	w = NewWriter(&buf, WithSyncMarkers())
+	_, err = w.Write([]byte("record"))
+	require.NoError(t, err)
+	r = NewReader(&buf, WithSyncMarkers(), WithOversizePolicy(OversizeError), WithSkipCorrupt(func(int64, error) { calls++ }))
+	_, err = r.Read(make([]byte, 2))
+	require.ErrorIs(t, err, ErrTargetBufferTooSmall)
+	require.Zero(t, calls)
+}
@@ -0,0 +1,27 @@
+package recio
+
+import "io"
+
+// IsSorted reads the remaining records and reports whether they are sorted
+// according to less, meaning that no record is less than the one before it.
+// If the stream is not sorted, IsSorted stops at the first record that is
+// out of order and returns its index, counted from the first record read by
+// this call. For a sorted stream the index is -1.
+func (r *Reader) IsSorted(less func(a, b []byte) bool) (bool, int64, error) {
+	var prev []byte
+
+	for index := int64(0); ; index++ {
+		rec, err := r.readBuffered()
+		if err == io.EOF {
+			return true, -1, nil
+		}
+		if err != nil {
+			return false, -1, err
+		}
+
+		if index > 0 && less(rec, prev) {
+			return false, index, nil
+		}
+		prev = append(prev[:0], rec...)
+	}
+}
@@ -0,0 +1,28 @@
+package recio
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSorted(t *testing.T) {
+	less := func(a, b []byte) bool {
+		return bytes.Compare(a, b) < 0
+	}
+
+	sorted, index, err := recordStream(t, "a", "b", "b", "c", "d").IsSorted(less)
+	require.NoError(t, err)
+	require.True(t, sorted)
+	require.Equal(t, int64(-1), index)
+
+	sorted, index, err = recordStream(t, "a", "b", "d", "c", "e").IsSorted(less)
+	require.NoError(t, err)
+	require.False(t, sorted)
+	require.Equal(t, int64(3), index)
+
+	sorted, _, err = recordStream(t).IsSorted(less)
+	require.NoError(t, err)
+	require.True(t, sorted)
+}
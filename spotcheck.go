@@ -0,0 +1,58 @@
+package recio
+
+import (
+	"io"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// SpotCheck verifies sampleN records of a stream written with WithChecksum,
+// picked at random from index, which holds the offsets of the records as
+// returned by Writer.Offsets or ScanOffsets. Checking a sample is much
+// faster than verifying a large file in full, at the price of only finding
+// damage in the records sampled. It returns the first failure found, as a
+// FramingError that gives the record's number in index and its offset.
+//
+// The records are picked using rng, or a randomly seeded source if rng is
+// nil; passing a source with a fixed seed makes the check repeatable. A
+// sampleN larger than the index checks every record. opts are the options
+// the stream was written with, WithChecksum implied. Options that verify a
+// record against the ones before it, WithMerkleChain and WithPrevFrameCRC,
+// can't be used, and with WithStreamGuard the index must not have been
+// written with WithChunking.
+func SpotCheck(ra io.ReaderAt, index []int64, sampleN int, rng *rand.Rand, opts ...Option) error {
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	if sampleN > len(index) {
+		sampleN = len(index)
+	}
+
+	// footers belong to the stream as a whole and reading single records
+	// doesn't get to them
+	opts = append(opts[:len(opts):len(opts)], WithChecksum(), func(o *options) {
+		o.footerChecksum = false
+		o.countFooter = false
+		o.trailerAware = false
+	})
+	r := NewReader(io.NewSectionReader(ra, 0, math.MaxInt64), opts...)
+
+	for _, i := range rng.Perm(len(index))[:sampleN] {
+		_, err := r.Seek(index[i], io.SeekStart)
+		if err != nil {
+			return err
+		}
+		r.index = int64(i)
+		r.guard = uint8(i)
+
+		err = r.Skip()
+		if err == io.EOF {
+			err = r.framingError(io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
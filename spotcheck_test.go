@@ -0,0 +1,53 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpotCheck(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithChecksum()},
+		{WithChecksum(), WithHeader(), WithStreamGuard(), WithSyncMarkers()},
+		{WithChecksum(), WithAlignment(8), WithCountFooter()},
+	} {
+		buf := bytes.NewBuffer([]byte{})
+		w := NewWriter(buf, append(opts, WithIndex())...)
+		for i := 0; i < 20; i++ {
+			_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+		index := w.Offsets()
+
+		data := buf.Bytes()
+		require.NoError(t, SpotCheck(bytes.NewReader(data), index, len(index), nil, opts...))
+
+		// damage the payload of record 7
+		data = append([]byte{}, data...)
+		data[index[7]+int64(FrameSize(0, opts...))] ^= 0xff
+
+		err := SpotCheck(bytes.NewReader(data), index, len(index), nil, opts...)
+		require.ErrorIs(t, err, ErrChecksumMismatch)
+		var fe *FramingError
+		require.ErrorAs(t, err, &fe)
+		require.EqualValues(t, 7, fe.Index)
+		require.Equal(t, index[7], fe.Offset)
+
+		// a sample finds the damage if, and only if, it includes record 7
+		for seed := int64(1); seed <= 10; seed++ {
+			sample := rand.New(rand.NewSource(seed)).Perm(len(index))[:5]
+			err := SpotCheck(bytes.NewReader(data), index, 5, rand.New(rand.NewSource(seed)), opts...)
+			if slices.Contains(sample, 7) {
+				require.ErrorIs(t, err, ErrChecksumMismatch, "seed %d", seed)
+			} else {
+				require.NoError(t, err, "seed %d", seed)
+			}
+		}
+	}
+}
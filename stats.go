@@ -0,0 +1,29 @@
+package recio
+
+// Stats counts the records and payload bytes that have passed through a
+// Writer or a Reader. Byte counts don't include the framing. A Writer only
+// fills in the fields about writing and a Reader the fields about reading.
+type Stats struct {
+	RecordsWritten int64
+	BytesWritten   int64
+
+	// RecordsRead and BytesRead count the records that have been returned
+	// to the caller, with BytesRead counting the payload as it is stored,
+	// so before decoding with WithCodec.
+	RecordsRead int64
+	BytesRead   int64
+
+	// RecordsSkipped counts records that have been skipped, such as
+	// records that did not fit the buffer given to Read.
+	RecordsSkipped int64
+}
+
+// Stats returns the counts of what has been written so far.
+func (w *Writer) Stats() Stats {
+	return w.stats
+}
+
+// Stats returns the counts of what has been read so far.
+func (r *Reader) Stats() Stats {
+	return r.stats
+}
@@ -0,0 +1,37 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithStreamGuard())
+	for _, rec := range []string{"one", "two", "a record too large for the buffer", "", "three"} {
+		_, err := w.Write([]byte(rec))
+		require.NoError(t, err)
+	}
+	require.Equal(t, Stats{RecordsWritten: 5, BytesWritten: 3 + 3 + 33 + 5}, w.Stats())
+
+	r := NewReader(bytes.NewReader(buf.Bytes()), WithStreamGuard())
+	p := make([]byte, 10)
+	_, err := r.Read(p)
+	require.NoError(t, err)
+	_, err = r.ReadRecord()
+	require.NoError(t, err)
+	_, err = r.Read(p)
+	require.ErrorIs(t, err, ErrTargetBufferTooSmall)
+	require.NoError(t, r.Skip())
+	rr, err := r.NextReader()
+	require.NoError(t, err)
+	_, err = io.ReadAll(rr)
+	require.NoError(t, err)
+	_, err = r.Read(p)
+	require.Equal(t, io.EOF, err)
+
+	require.Equal(t, Stats{RecordsRead: 3, BytesRead: 3 + 3 + 5, RecordsSkipped: 2}, r.Stats())
+}
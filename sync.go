@@ -0,0 +1,78 @@
+package recio
+
+import (
+	"io"
+	"sync"
+)
+
+// SyncReader is a record reader that is safe for concurrent use. Each record
+// is delivered to exactly one caller.
+type SyncReader struct {
+	mu     sync.Mutex
+	reader *Reader
+}
+
+// NewSyncReader returns a SyncReader reading records from r.
+func NewSyncReader(r io.Reader, opts ...Option) *SyncReader {
+	return &SyncReader{
+		reader: NewReader(r, opts...),
+	}
+}
+
+// Read reads the next record into p. See Reader.Read.
+func (s *SyncReader) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.reader.Read(p)
+}
+
+// Next returns the next record. The returned slice is owned by the caller and
+// is not shared with other goroutines.
+func (s *SyncReader) Next() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.reader.readRecord()
+}
+
+// SyncWriter is a record writer that is safe for concurrent use. Every record
+// is written as a whole, without being interleaved with records written by
+// other goroutines.
+type SyncWriter struct {
+	mu     sync.Mutex
+	writer *Writer
+}
+
+// NewSyncWriter returns a SyncWriter writing records to w.
+func NewSyncWriter(w io.Writer, opts ...Option) *SyncWriter {
+	return &SyncWriter{
+		writer: NewWriter(w, opts...),
+	}
+}
+
+// Write writes p as a single record. See Writer.Write.
+func (s *SyncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.writer.Write(p)
+}
+
+// Flush writes any buffered records to the underlying writer. See
+// Writer.Flush.
+func (s *SyncWriter) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.writer.Flush()
+}
+
+// Close flushes any buffered records and closes the underlying writer. See
+// Writer.Close.
+func (s *SyncWriter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.writer.Close()
+}
@@ -0,0 +1,106 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncReader(t *testing.T) {
+	numRecords := 1000
+	numWorkers := 8
+
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf)
+	for i := 0; i < numRecords; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+	}
+
+	r := NewSyncReader(bytes.NewReader(buf.Bytes()))
+
+	var wg sync.WaitGroup
+	results := make([][][]byte, numWorkers)
+	errs := make([]error, numWorkers)
+
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for {
+				rec, err := r.Next()
+				if err == io.EOF {
+					return
+				}
+				if err != nil {
+					errs[worker] = err
+					return
+				}
+				results[worker] = append(results[worker], rec)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]int)
+	for i := 0; i < numWorkers; i++ {
+		require.NoError(t, errs[i])
+		for _, rec := range results[i] {
+			seen[string(rec)]++
+		}
+	}
+
+	require.Len(t, seen, numRecords)
+	for i := 0; i < numRecords; i++ {
+		require.Equal(t, 1, seen[fmt.Sprintf("record %d", i)])
+	}
+}
+
+func TestSyncWriter(t *testing.T) {
+	numRecords := 1000
+	numWorkers := 8
+
+	// write through a small buffer so that frames are split across many
+	// writes to the underlying writer
+	buf := bytes.NewBuffer([]byte{})
+	w := NewSyncWriter(buf, WithWriteBuffer(16), WithStreamGuard(), WithChecksum())
+
+	var wg sync.WaitGroup
+	errs := make([]error, numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < numRecords; j++ {
+				_, err := w.Write([]byte(fmt.Sprintf("worker %d record %d", worker, j)))
+				if err != nil {
+					errs[worker] = err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, w.Close())
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	seen := make(map[string]int)
+	s := NewScanner(bytes.NewReader(buf.Bytes()), WithStreamGuard(), WithChecksum())
+	for s.Scan() {
+		seen[string(s.Bytes())]++
+	}
+	require.NoError(t, s.Err())
+
+	require.Len(t, seen, numRecords*numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		for j := 0; j < numRecords; j++ {
+			require.Equal(t, 1, seen[fmt.Sprintf("worker %d record %d", i, j)])
+		}
+	}
+}
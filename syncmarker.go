@@ -0,0 +1,108 @@
+package recio
+
+import (
+	"errors"
+	"io"
+)
+
+// syncMarker precedes every record when WithSyncMarkers is in use.
+var syncMarker = [...]byte{0x8f, 0x3c, 0xe1, 0x5b}
+
+var ErrMissingSyncMarker = errors.New("record does not start with a sync marker")
+
+// WithSyncMarkers writes a fixed 4 byte marker in front of every record and
+// makes the reader check it, returning ErrMissingSyncMarker if it is not
+// there. After a read fails because the stream is corrupt, Resync skips
+// ahead to the next marker so that the records after the corruption can
+// still be read. Without markers there is no way to tell a length prefix
+// from payload bytes, so a corrupt stream can't be recovered.
+//
+// The markers cost 4 bytes per record. Using WithMaxRecordSize as well is
+// recommended, since otherwise a corrupt length can make the reader consume
+// the rest of the stream before the error is noticed. Writer and reader must
+// both use it.
+func WithSyncMarkers() Option {
+	return func(o *options) {
+		o.syncMarkers = true
+	}
+}
+
+// readSyncMarker checks the sync marker in front of a record, unless Resync
+// has already consumed it. With WithSkipGaps it skips padding in front of
+// the marker first.
+func (r *Reader) readSyncMarker() error {
+	if r.markerSeen {
+		r.markerSeen = false
+		return nil
+	}
+
+	var marker [len(syncMarker)]byte
+	rest := marker[:]
+	if r.opts.skipGaps {
+		b, err := r.skipGap()
+		if err != nil {
+			return err
+		}
+
+		// the frame starts after the padding
+		marker[0] = b
+		rest = marker[1:]
+		r.recordOffset = r.count.n - 1
+		if r.opts.prevFrameCRC {
+			r.frameCRC.Reset()
+			r.frameCRC.Write(marker[:1])
+		}
+	}
+
+	_, err := io.ReadFull(r.reader, rest)
+	if err == io.EOF && r.opts.skipGaps {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+
+	if marker != syncMarker {
+		return ErrMissingSyncMarker
+	}
+	return nil
+}
+
+// Resync skips ahead to the next sync marker after a read has failed, so
+// that reading can continue with the record that follows it. It returns the
+// number of bytes skipped, and io.EOF if the stream ends without another
+// marker. The reader must have been created using WithSyncMarkers.
+//
+// A marker may occur in a payload by chance, in which case reading the
+// record that seems to follow it fails and Resync has to be called again.
+// Options that chain records together, WithStreamGuard, WithMerkleChain and
+// WithPrevFrameCRC, fail after a resync. The record that failed is counted,
+// so the Index of later FramingErrors stays right unless markers were
+// damaged or found in a payload.
+func (r *Reader) Resync() (int64, error) {
+	if !r.opts.syncMarkers {
+		return 0, ErrMissingSyncMarker
+	}
+
+	r.current = nil
+	r.hasPending = false
+	r.markerSeen = false
+
+	var window [len(syncMarker)]byte
+	var read int64
+	for {
+		b, err := r.readByte()
+		if err != nil {
+			return read, err
+		}
+		read++
+
+		copy(window[:], window[1:])
+		window[len(window)-1] = b
+		if read >= int64(len(window)) && window == syncMarker {
+			r.markerSeen = true
+			r.index++
+			return read - int64(len(window)), nil
+		}
+	}
+}
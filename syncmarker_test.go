@@ -0,0 +1,101 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// salvage reads every record it can from r, resynchronizing after errors.
+func salvage(t *testing.T, r *Reader) []string {
+	var records []string
+	for {
+		rec, err := r.ReadRecord()
+		if err == io.EOF {
+			return records
+		}
+		if err != nil {
+			_, err := r.Resync()
+			if err == io.EOF {
+				return records
+			}
+			require.NoError(t, err)
+			continue
+		}
+		records = append(records, string(rec))
+	}
+}
+
+func TestResync(t *testing.T) {
+	opts := []Option{WithSyncMarkers(), WithChecksum(), WithMaxRecordSize(1000)}
+
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, append(opts, WithIndex())...)
+	var expected []string
+	for i := 0; i < 20; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+		expected = append(expected, fmt.Sprintf("record %d", i))
+	}
+	offsets := w.Offsets()
+
+	// intact streams read as usual
+	require.Equal(t, expected, salvage(t, NewReader(bytes.NewReader(buf.Bytes()), opts...)))
+
+	// corrupt the length prefix of record 5, the marker of record 10 and the
+	// payload of record 15
+	data := append([]byte{}, buf.Bytes()...)
+	data[offsets[5]+int64(len(syncMarker))+2] = 0x7f
+	data[offsets[10]] ^= 0xff
+	data[offsets[15]+int64(len(syncMarker))+4] ^= 0xff
+
+	var want []string
+	for i, rec := range expected {
+		if i != 5 && i != 10 && i != 15 {
+			want = append(want, rec)
+		}
+	}
+	require.Equal(t, want, salvage(t, NewReader(bytes.NewReader(data), opts...)))
+
+	// the records that failed are counted
+	var failed []int64
+	r := NewReader(bytes.NewReader(data), opts...)
+	for {
+		_, err := r.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var fe *FramingError
+			require.ErrorAs(t, err, &fe)
+			require.Equal(t, offsets[fe.Index], fe.Offset)
+			failed = append(failed, fe.Index)
+
+			_, err = r.Resync()
+			require.NoError(t, err)
+		}
+	}
+	require.Equal(t, []int64{5, 10, 15}, failed)
+}
+
+func TestResyncErrors(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithSyncMarkers())
+	_, err := w.Write([]byte("record"))
+	require.NoError(t, err)
+
+	r := NewReader(bytes.NewReader(buf.Bytes()[1:]), WithSyncMarkers())
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, ErrMissingSyncMarker)
+
+	// no marker after the corruption
+	n, err := r.Resync()
+	require.Equal(t, io.EOF, err)
+	require.Equal(t, int64(buf.Len()-1-len(syncMarker)), n)
+
+	_, err = NewReader(bytes.NewReader(buf.Bytes())).Resync()
+	require.ErrorIs(t, err, ErrMissingSyncMarker)
+}
@@ -0,0 +1,56 @@
+package recio
+
+import (
+	"io"
+)
+
+// frameTee collects the bytes of the frame being read so they can be
+// written to raw once the frame is complete.
+type frameTee struct {
+	r     io.Reader
+	raw   io.Writer
+	frame []byte
+}
+
+// NewTeeReader returns a Reader that reads records from r like NewReader,
+// and also writes the complete frame of every record it reads to raw, byte
+// for byte, so that raw receives a valid stream of the records consumed.
+// The stream header is mirrored as well. A frame is only written to raw
+// once it has been read in full and has passed verification, so truncated
+// or corrupt records are left out. An error writing to raw is returned from
+// the read that completed the frame.
+func NewTeeReader(r io.Reader, raw io.Writer, opts ...Option) *Reader {
+	reader := NewReader(r, opts...)
+	reader.tee = &frameTee{
+		r:   reader.count.r,
+		raw: raw,
+	}
+	reader.count.r = reader.tee
+	return reader
+}
+
+func (t *frameTee) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	t.frame = append(t.frame, p[:n]...)
+	return n, err
+}
+
+// begin starts a new frame, dropping whatever was read since the last
+// complete frame except for a sync marker consumed by Resync.
+func (t *frameTee) begin(markerSeen bool) {
+	keep := 0
+	if markerSeen && len(t.frame) >= len(syncMarker) {
+		keep = len(syncMarker)
+	}
+	t.frame = append(t.frame[:0], t.frame[len(t.frame)-keep:]...)
+}
+
+// flush writes the collected bytes to raw.
+func (t *frameTee) flush() error {
+	if len(t.frame) == 0 {
+		return nil
+	}
+	_, err := t.raw.Write(t.frame)
+	t.frame = t.frame[:0]
+	return err
+}
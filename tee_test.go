@@ -0,0 +1,64 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeeReader(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithHeader(), WithChecksum(), WithStreamGuard()},
+		{WithReadBuffer(16), WithVarintLength()},
+	} {
+		var stream bytes.Buffer
+		w := NewWriter(&stream, opts...)
+		for i := 0; i < 10; i++ {
+			_, err := w.WriteString(fmt.Sprintf("record %d", i))
+			require.NoError(t, err)
+		}
+
+		var mirror bytes.Buffer
+		r := NewTeeReader(bytes.NewReader(stream.Bytes()), &mirror, opts...)
+		for i := 0; i < 10; i++ {
+			rec, err := r.ReadRecord()
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("record %d", i), string(rec))
+		}
+		_, err := r.ReadRecord()
+		require.ErrorIs(t, err, io.EOF)
+
+		require.Equal(t, stream.Bytes(), mirror.Bytes())
+	}
+}
+
+func TestTeeReaderTruncated(t *testing.T) {
+	var stream bytes.Buffer
+	w := NewWriter(&stream)
+	for i := 0; i < 3; i++ {
+		_, err := w.WriteString(fmt.Sprintf("record %d", i))
+		require.NoError(t, err)
+	}
+	data := stream.Bytes()
+
+	var mirror bytes.Buffer
+	r := NewTeeReader(bytes.NewReader(data[:len(data)-2]), &mirror)
+	for i := 0; i < 2; i++ {
+		_, err := r.ReadRecord()
+		require.NoError(t, err)
+	}
+	_, err := r.ReadRecord()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// the incomplete frame is not mirrored, so the mirror is a valid stream
+	frameSize := 4 + len("record 0")
+	require.Equal(t, data[:2*frameSize], mirror.Bytes())
+
+	count, err := Validate(bytes.NewReader(mirror.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
@@ -0,0 +1,128 @@
+package recio
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// timestampSize is the size of an entry in a timestamp index.
+const timestampSize = 8
+
+// WithTimestampIndex makes the writer record the time every record is
+// written in a separate index stream. The index holds one little endian
+// int64 of Unix nanoseconds per record, so the timestamp of record n is
+// found at offset 8*n. Use FindByTimeRange to look up records by time.
+func WithTimestampIndex(index io.Writer) Option {
+	return func(o *options) {
+		o.timestampIndex = index
+	}
+}
+
+// writeTimestamp appends the current time to the timestamp index.
+func (w *Writer) writeTimestamp() error {
+	var entry [timestampSize]byte
+	binary.LittleEndian.PutUint64(entry[:], uint64(w.now().UnixNano()))
+
+	_, err := w.opts.timestampIndex.Write(entry[:])
+	return err
+}
+
+// FindByTimeRange searches a timestamp index written by WithTimestampIndex
+// and returns the range of record ordinals [start, end) whose timestamps
+// fall within [from, to]. If no records fall within the range start equals
+// end. Timestamps in the index must be in non-decreasing order, which holds
+// as long as the clock is not set back while writing.
+func FindByTimeRange(index io.ReaderAt, from, to time.Time) (int64, int64, error) {
+	count, err := indexEntries(index)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	start, err := searchIndex(index, count, func(ts int64) bool {
+		return ts >= from.UnixNano()
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end, err := searchIndex(index, count, func(ts int64) bool {
+		return ts > to.UnixNano()
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if end < start {
+		end = start
+	}
+	return start, end, nil
+}
+
+// indexEntries returns the number of complete entries in a timestamp index.
+func indexEntries(index io.ReaderAt) (int64, error) {
+	if s, ok := index.(interface{ Size() int64 }); ok {
+		return s.Size() / timestampSize, nil
+	}
+
+	// find an upper bound by doubling, then binary search for the end
+	present := func(n int64) (bool, error) {
+		var entry [timestampSize]byte
+		_, err := index.ReadAt(entry[:], n*timestampSize)
+		if err == io.EOF {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	hi := int64(1)
+	for {
+		ok, err := present(hi - 1)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			break
+		}
+		hi *= 2
+	}
+
+	lo := hi / 2
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		ok, err := present(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
+
+// searchIndex returns the smallest ordinal in [0, count) whose timestamp
+// satisfies f, or count if there is none. f must be false for a prefix of
+// the index and true for the rest.
+func searchIndex(index io.ReaderAt, count int64, f func(ts int64) bool) (int64, error) {
+	var entry [timestampSize]byte
+
+	lo, hi := int64(0), count
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		_, err := index.ReadAt(entry[:], mid*timestampSize)
+		if err != nil {
+			return 0, err
+		}
+
+		if f(int64(binary.LittleEndian.Uint64(entry[:]))) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo, nil
+}
@@ -0,0 +1,71 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readerAtOnly hides the Size method of the wrapped reader.
+type readerAtOnly struct {
+	io.ReaderAt
+}
+
+func TestFindByTimeRange(t *testing.T) {
+	data := bytes.NewBuffer([]byte{})
+	index := bytes.NewBuffer([]byte{})
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := base
+
+	w := NewWriter(data, WithTimestampIndex(index))
+	w.now = func() time.Time { return clock }
+
+	// one record per minute
+	for i := 0; i < 100; i++ {
+		_, err := w.Write([]byte(fmt.Sprintf("record %d", i)))
+		require.NoError(t, err)
+		clock = clock.Add(time.Minute)
+	}
+	require.Equal(t, 100*timestampSize, index.Len())
+
+	for _, ra := range []io.ReaderAt{bytes.NewReader(index.Bytes()), readerAtOnly{bytes.NewReader(index.Bytes())}} {
+		start, end, err := FindByTimeRange(ra, base.Add(10*time.Minute), base.Add(19*time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, int64(10), start)
+		require.Equal(t, int64(20), end)
+
+		// bounds between timestamps
+		start, end, err = FindByTimeRange(ra, base.Add(90*time.Second), base.Add(210*time.Second))
+		require.NoError(t, err)
+		require.Equal(t, int64(2), start)
+		require.Equal(t, int64(4), end)
+
+		// range past the end
+		start, end, err = FindByTimeRange(ra, base.Add(time.Hour*10), base.Add(time.Hour*11))
+		require.NoError(t, err)
+		require.Equal(t, int64(100), start)
+		require.Equal(t, int64(100), end)
+
+		// range before the start
+		start, end, err = FindByTimeRange(ra, base.Add(-time.Hour), base.Add(-time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, int64(0), start)
+		require.Equal(t, int64(0), end)
+	}
+
+	// the ordinals map onto the records in the data stream
+	r := NewReader(bytes.NewReader(data.Bytes()))
+	readBuffer := make([]byte, 100)
+	for i := 0; i < 10; i++ {
+		_, err := r.Read(readBuffer)
+		require.NoError(t, err)
+	}
+	n, err := r.Read(readBuffer)
+	require.NoError(t, err)
+	require.Equal(t, "record 10", string(readBuffer[:n]))
+}
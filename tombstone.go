@@ -0,0 +1,96 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+)
+
+// TombstoneTag is the type tag of the tombstones written by WriteTombstone.
+// It is reserved and must not be used for other records.
+const TombstoneTag byte = 0xff
+
+// WriteTombstone writes a tombstone marking the records with the given key
+// that come before it as deleted, for streams used as an append-only log.
+// The tombstone is a record tagged with TombstoneTag holding the key, so
+// the writer must have been created with WithTypeTag, otherwise
+// ErrNoTypeTag is returned. What the key of a record is, is up to the
+// caller; see ApplyTombstones and CompactTombstones.
+func (w *Writer) WriteTombstone(key []byte) error {
+	_, err := w.WriteTyped(TombstoneTag, key)
+	return err
+}
+
+// taggedRecord is a record read from a stream using WithTypeTag.
+type taggedRecord struct {
+	tag byte
+	rec []byte
+}
+
+// liveRecords reads the remaining records from src and returns the ones
+// that have not been deleted by a later tombstone.
+func liveRecords(src *Reader, key func(rec []byte) []byte) ([]taggedRecord, error) {
+	var records []taggedRecord
+	for {
+		tag, rec, err := src.ReadTyped()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if tag != TombstoneTag {
+			records = append(records, taggedRecord{tag, rec})
+			continue
+		}
+
+		// drop the earlier records with the key
+		live := records[:0]
+		for _, r := range records {
+			if !bytes.Equal(key(r.rec), rec) {
+				live = append(live, r)
+			}
+		}
+		records = live
+	}
+	return records, nil
+}
+
+// ApplyTombstones reads the remaining records from src, which must have
+// been created with WithTypeTag, and returns the ones that are still live,
+// in order. A record is dropped if a tombstone for its key, as returned by
+// key, follows it in the stream. Tombstones themselves are not returned.
+// The live records are held in memory.
+func ApplyTombstones(src *Reader, key func(rec []byte) []byte) ([][]byte, error) {
+	records, err := liveRecords(src, key)
+	if err != nil {
+		return nil, err
+	}
+
+	live := make([][]byte, len(records))
+	for i, r := range records {
+		live[i] = r.rec
+	}
+	return live, nil
+}
+
+// CompactTombstones copies the records from src that are still live, as
+// determined by ApplyTombstones, to dst along with their type tags, leaving
+// out the deleted records and the tombstones. Both must have been created
+// with WithTypeTag. It returns the number of records written to dst.
+func CompactTombstones(dst *Writer, src *Reader, key func(rec []byte) []byte) (int64, error) {
+	records, err := liveRecords(src, key)
+	if err != nil {
+		return 0, err
+	}
+
+	var kept int64
+	for _, r := range records {
+		_, err := dst.WriteTyped(r.tag, r.rec)
+		if err != nil {
+			return kept, err
+		}
+		kept++
+	}
+	return kept, nil
+}
@@ -0,0 +1,66 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordKey returns the part of a "key=value" record before the '='.
+func recordKey(rec []byte) []byte {
+	key, _, _ := bytes.Cut(rec, []byte("="))
+	return key
+}
+
+func writeTombstoneLog(t *testing.T) []byte {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithTypeTag())
+	for _, op := range []string{"a=1", "b=1", "-a", "c=1", "b=2", "a=2", "-c"} {
+		var err error
+		if key, ok := strings.CutPrefix(op, "-"); ok {
+			err = w.WriteTombstone([]byte(key))
+		} else {
+			_, err = w.WriteTyped(1, []byte(op))
+		}
+		require.NoError(t, err)
+	}
+	return buf.Bytes()
+}
+
+func TestApplyTombstones(t *testing.T) {
+	r := NewReader(bytes.NewReader(writeTombstoneLog(t)), WithTypeTag())
+	live, err := ApplyTombstones(r, recordKey)
+	require.NoError(t, err)
+
+	// a=1 and c=1 are deleted, a=2 was written after a's tombstone
+	require.Equal(t, [][]byte{[]byte("b=1"), []byte("b=2"), []byte("a=2")}, live)
+}
+
+func TestCompactTombstones(t *testing.T) {
+	var compacted bytes.Buffer
+	src := NewReader(bytes.NewReader(writeTombstoneLog(t)), WithTypeTag())
+	kept, err := CompactTombstones(NewWriter(&compacted, WithTypeTag()), src, recordKey)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, kept)
+
+	r := NewReader(&compacted, WithTypeTag())
+	var records []string
+	for {
+		tag, rec, err := r.ReadTyped()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.EqualValues(t, 1, tag)
+		records = append(records, string(rec))
+	}
+	require.Equal(t, []string{"b=1", "b=2", "a=2"}, records)
+}
+
+func TestWriteTombstoneRequiresTypeTag(t *testing.T) {
+	err := NewWriter(io.Discard).WriteTombstone([]byte("a"))
+	require.ErrorIs(t, err, ErrNoTypeTag)
+}
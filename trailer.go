@@ -0,0 +1,28 @@
+package recio
+
+// WithTrailerAware makes the reader recognize the footers written by
+// WithCountFooter and WithFooterChecksum at the end of a stream, whether or
+// not the reader was told to expect them, and return io.EOF there rather
+// than read them as records. A checksum footer that is found is checked as
+// with WithFooterChecksum, and the counts of a count footer are returned by
+// Trailer. Footers the reader is given options for must still be there.
+//
+// The reader holds back the size of both footers until the end of the
+// stream, so records are returned that much later from streams that are
+// still being written.
+func WithTrailerAware() Option {
+	return func(o *options) {
+		o.trailerAware = true
+	}
+}
+
+// Trailer returns the counts of the footer written by WithCountFooter, once
+// the reader has reached the end of the stream. ok is false until then, and
+// if the stream has no count footer or the reader was created without
+// WithTrailerAware or WithCountFooter.
+func (r *Reader) Trailer() (footer CountFooter, ok bool) {
+	if r.footer == nil {
+		return CountFooter{}, false
+	}
+	return r.footer.trailer, r.footer.hasTrailer
+}
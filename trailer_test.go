@@ -0,0 +1,95 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrailerAware(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithCountFooter()},
+		{WithFooterChecksum()},
+		{WithCountFooter(), WithFooterChecksum(), WithChecksum()},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, opts...)
+		var want []string
+		payload := 0
+		for i := 0; i < 10; i++ {
+			rec := fmt.Sprintf("record %d", i)
+			want = append(want, rec)
+			payload += len(rec)
+			_, err := w.WriteString(rec)
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+
+		var readOpts []Option
+		o := newOptions(opts)
+		if o.checksum {
+			readOpts = append(readOpts, WithChecksum())
+		}
+
+		// with or without the footer options, and with sources that return
+		// data along with io.EOF
+		for _, r := range []*Reader{
+			NewReader(bytes.NewReader(buf.Bytes()), append(readOpts, WithTrailerAware())...),
+			NewReader(iotest.DataErrReader(bytes.NewReader(buf.Bytes())), append(readOpts, WithTrailerAware())...),
+			NewReader(iotest.DataErrReader(bytes.NewReader(buf.Bytes())), append(opts, WithTrailerAware())...),
+		} {
+			_, ok := r.Trailer()
+			require.False(t, ok)
+
+			var got []string
+			for {
+				rec, err := r.ReadRecord()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				got = append(got, string(rec))
+			}
+			require.Equal(t, want, got)
+
+			footer, ok := r.Trailer()
+			require.Equal(t, o.countFooter, ok)
+			if ok {
+				require.Equal(t, CountFooter{Records: 10, PayloadBytes: int64(payload)}, footer)
+			}
+		}
+	}
+}
+
+func TestTrailerAwareMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithCountFooter(), WithFooterChecksum())
+	_, err := w.WriteString("record")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	buf.Bytes()[5]++
+
+	r := NewReader(&buf, WithTrailerAware())
+	_, err = r.ReadRecord()
+	require.NoError(t, err)
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, ErrFooterMismatch)
+}
+
+func TestTrailerAwareRequired(t *testing.T) {
+	// footers the reader is given options for are still required
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithCountFooter())
+	_, err := w.WriteString("record")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r := NewReader(&buf, WithTrailerAware(), WithFooterChecksum())
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, ErrMissingFooter)
+}
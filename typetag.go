@@ -0,0 +1,69 @@
+package recio
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	ErrNoTypeTag      = errors.New("type tags require WithTypeTag")
+	ErrMissingTypeTag = errors.New("record is too short to hold a type tag")
+)
+
+// WithTypeTag stores a one byte type tag with every record, so that several
+// kinds of records can be interleaved in one stream and told apart on
+// reading. Records are tagged with WriteTyped; plain writes are tagged 0
+// and TombstoneTag is reserved for WriteTombstone.
+// The reader strips the tag from every record, so Read and the other read
+// methods return the payload alone, and ReadTyped returns the tag as well.
+// Writer and reader must both use it; with WithHeader, readers detect a
+// mismatch.
+func WithTypeTag() Option {
+	return func(o *options) {
+		o.typeTag = true
+	}
+}
+
+// WriteTyped writes p as a record tagged with tag. The writer must have
+// been created with WithTypeTag, otherwise ErrNoTypeTag is returned.
+func (w *Writer) WriteTyped(tag byte, p []byte) (int, error) {
+	if !w.opts.typeTag {
+		return 0, ErrNoTypeTag
+	}
+
+	w.tag = tag
+	defer func() { w.tag = 0 }()
+	return w.Write(p)
+}
+
+// ReadTyped reads the next record into a newly allocated slice, like
+// ReadRecord, and returns it along with its type tag. The reader must have
+// been created with WithTypeTag, otherwise ErrNoTypeTag is returned.
+func (r *Reader) ReadTyped() (byte, []byte, error) {
+	if !r.opts.typeTag {
+		return 0, nil, ErrNoTypeTag
+	}
+
+	rec, err := r.readRecord()
+	if err != nil {
+		return 0, nil, err
+	}
+	return r.tag, rec, nil
+}
+
+// readTypeTag reads the type tag of the current record.
+func (r *Reader) readTypeTag(length uint64) (uint64, error) {
+	if length < 1 {
+		return 0, ErrMissingTypeTag
+	}
+
+	tag, err := r.readByte()
+	if err == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return 0, err
+	}
+	r.tag = tag
+	return length - 1, nil
+}
@@ -0,0 +1,87 @@
+package recio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	tagMetric = 1
+	tagLog    = 2
+)
+
+func TestTypeTag(t *testing.T) {
+	for _, tc := range []struct {
+		opts []Option
+		// prefix is what the reader returns in front of the payload
+		prefix string
+	}{
+		{[]Option{WithTypeTag()}, ""},
+		{[]Option{WithTypeTag(), WithHeader(), WithChecksum(), WithMerkleChain(), WithPrevFrameCRC()}, ""},
+		{[]Option{WithTypeTag(), WithSchemaVersion(4)}, "\x04"},
+	} {
+		opts := tc.opts
+		var buf bytes.Buffer
+		w := NewWriter(&buf, opts...)
+		for i := 0; i < 6; i++ {
+			var err error
+			if i%2 == 0 {
+				_, err = w.WriteTyped(tagMetric, []byte(fmt.Sprintf("metric %d", i)))
+			} else {
+				_, err = w.WriteTyped(tagLog, []byte(fmt.Sprintf("log %d", i)))
+			}
+			require.NoError(t, err)
+		}
+		_, err := w.WriteString("untagged")
+		require.NoError(t, err)
+
+		var metrics, logs []string
+		r := NewReader(bytes.NewReader(buf.Bytes()), opts...)
+		for {
+			tag, rec, err := r.ReadTyped()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+
+			switch tag {
+			case tagMetric:
+				metrics = append(metrics, string(rec))
+			case tagLog:
+				logs = append(logs, string(rec))
+			default:
+				require.Zero(t, tag)
+				require.Equal(t, tc.prefix+"untagged", string(rec))
+			}
+		}
+
+		p := tc.prefix
+		require.Equal(t, []string{p + "metric 0", p + "metric 2", p + "metric 4"}, metrics)
+		require.Equal(t, []string{p + "log 1", p + "log 3", p + "log 5"}, logs)
+
+		// plain reads return the payload without the tag
+		r = NewReader(bytes.NewReader(buf.Bytes()), opts...)
+		rec, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.Equal(t, p+"metric 0", string(rec))
+	}
+}
+
+func TestTypeTagMismatch(t *testing.T) {
+	_, err := NewWriter(io.Discard).WriteTyped(tagLog, []byte("x"))
+	require.ErrorIs(t, err, ErrNoTypeTag)
+
+	_, _, err = NewReader(bytes.NewReader(nil)).ReadTyped()
+	require.ErrorIs(t, err, ErrNoTypeTag)
+
+	var buf bytes.Buffer
+	_, err = NewWriter(&buf, WithTypeTag(), WithHeader()).WriteTyped(tagLog, []byte("x"))
+	require.NoError(t, err)
+
+	_, err = NewReader(&buf, WithHeader()).ReadRecord()
+	require.ErrorIs(t, err, ErrFramingMismatch)
+}
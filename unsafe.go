@@ -0,0 +1,34 @@
+package recio
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// UnsafeWriter writes frames whose declared length does not have to match
+// the size of the body. It exists to produce malformed streams for testing
+// how readers deal with corruption and should not be used to write data.
+type UnsafeWriter struct {
+	writer io.Writer
+	prefix [4]byte
+}
+
+// NewUnsafeWriter returns an UnsafeWriter that writes raw frames to w.
+func NewUnsafeWriter(w io.Writer) *UnsafeWriter {
+	return &UnsafeWriter{
+		writer: w,
+	}
+}
+
+// WriteRaw writes declaredLen as the length prefix followed by body,
+// regardless of how long body actually is.
+func (w *UnsafeWriter) WriteRaw(declaredLen uint32, body []byte) error {
+	binary.LittleEndian.PutUint32(w.prefix[:], declaredLen)
+	_, err := w.writer.Write(w.prefix[:])
+	if err != nil {
+		return err
+	}
+
+	_, err = w.writer.Write(body)
+	return err
+}
@@ -0,0 +1,40 @@
+package recio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsafeWriteRaw(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+
+	w := NewUnsafeWriter(buf)
+	require.NoError(t, w.WriteRaw(100, []byte("short body")))
+
+	data := buf.Bytes()
+	require.Len(t, data, 4+len("short body"))
+	require.Equal(t, uint32(100), binary.LittleEndian.Uint32(data))
+	require.Equal(t, "short body", string(data[4:]))
+
+	// the declared length runs past the end of the stream
+	r := NewReader(bytes.NewReader(data))
+	_, err := r.Read(make([]byte, 512))
+	require.Error(t, err)
+}
+
+func BenchmarkUnsafeWriteRaw(b *testing.B) {
+	w := NewUnsafeWriter(io.Discard)
+	body := []byte("this is a test")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		err := w.WriteRaw(uint32(len(body)), body)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package recio
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	ErrLengthChanged = errors.New("updated record must have the same length as the original")
+	ErrNotUpdatable  = errors.New("writer does not support updating records in place")
+)
+
+// UpdateRecordAt overwrites the payload of the record whose frame starts at
+// offset with p. The underlying writer must implement both io.ReaderAt and
+// io.WriterAt, as *os.File does, otherwise ErrNotUpdatable is returned.
+//
+// Records are updated strictly in place: p must be exactly as long as the
+// payload it replaces, or ErrLengthChanged is returned and the stream is
+// left untouched. Records of a different size have to be written as new
+// records instead. Since updating a record would break the chain of
+// records, streams written with WithMerkleChain or WithPrevFrameCRC can't
+// be updated, and neither can writers using WithAppendOnly,
+// WithExtendedHeader, WithChecksum, WithVarintLength, WithCodec or WithAEAD.
+func (w *Writer) UpdateRecordAt(offset int64, p []byte) error {
+	ra, canRead := w.writer.(io.ReaderAt)
+	wa, canWrite := w.writer.(io.WriterAt)
+	if !canRead || !canWrite || w.opts.merkleChain || w.opts.prevFrameCRC || w.opts.appendOnly || w.opts.extendedHeader || w.opts.checksum || w.opts.varintLength || w.opts.readsWhole() {
+		return ErrNotUpdatable
+	}
+
+	if w.opts.syncMarkers {
+		offset += int64(len(syncMarker))
+	}
+	if w.opts.streamGuard {
+		offset++
+	}
+
+	prefix := make([]byte, w.opts.lengthSize)
+	_, err := ra.ReadAt(prefix, offset)
+	if err != nil {
+		return err
+	}
+	offset += int64(len(prefix))
+
+	stored := decodeLength(w.opts.byteOrder, prefix)
+
+	if w.opts.typeTag {
+		offset++
+	}
+	if w.opts.hasSchemaVersion {
+		offset++
+	}
+
+	if stored != w.opts.frameLength(len(p)) {
+		return ErrLengthChanged
+	}
+
+	_, err = wa.WriteAt(p, offset)
+	return err
+}
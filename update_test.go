@@ -0,0 +1,53 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateRecordAt(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "update.seq"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	w := NewWriter(f, WithSchemaVersion(1))
+
+	var offsets []int64
+	offset := int64(0)
+	for _, rec := range []string{"first", "second", "third"} {
+		offsets = append(offsets, offset)
+		_, err := w.Write([]byte(rec))
+		require.NoError(t, err)
+		offset += int64(4 + 1 + len(rec))
+	}
+
+	require.NoError(t, w.UpdateRecordAt(offsets[1], []byte("SECOND")))
+	require.ErrorIs(t, w.UpdateRecordAt(offsets[2], []byte("3rd")), ErrLengthChanged)
+
+	// writing continues at the end of the stream
+	_, err = w.Write([]byte("fourth"))
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+
+	var records []string
+	r := NewReader(bytes.NewReader(data))
+	for {
+		_, payload, err := r.ReadVersioned()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		records = append(records, string(payload))
+	}
+	require.Equal(t, []string{"first", "SECOND", "third", "fourth"}, records)
+
+	// writers that can't seek do not support updates
+	require.ErrorIs(t, NewWriter(bytes.NewBuffer([]byte{})).UpdateRecordAt(0, nil), ErrNotUpdatable)
+}
@@ -0,0 +1,52 @@
+package recio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ValidationError is returned by Validate for the first record that is not
+// well formed. Offset is where the record starts in the stream and Record
+// its number, counting from 0.
+type ValidationError struct {
+	Record int
+	Offset int64
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("record %d at offset %d: %v", e.Record, e.Offset, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Validate walks every record in r, checking the framing without reading
+// payloads into memory, and returns the number of records. The stream must
+// have been written with the same options. Use WithMaxRecordSize to reject
+// records with implausible lengths. The first problem found is returned as
+// a *ValidationError, which wraps io.ErrUnexpectedEOF for a stream that ends
+// within a record. WithProgressCallback reports the progress of the walk.
+func Validate(r io.Reader, opts ...Option) (int, error) {
+	reader := NewReader(r, opts...)
+	count := 0
+	for {
+		err := reader.Skip()
+		if err == io.EOF {
+			reader.reportProgress(true)
+			return count, nil
+		}
+		if err != nil {
+			// report the cause once, rather than the position twice
+			var fe *FramingError
+			if errors.As(err, &fe) {
+				err = fe.Err
+			}
+			return count, &ValidationError{Record: count, Offset: reader.recordOffset, Err: err}
+		}
+		count++
+		reader.reportProgress(false)
+	}
+}
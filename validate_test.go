@@ -0,0 +1,81 @@
+package recio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func validateStream(t *testing.T, opts ...Option) []byte {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, opts...)
+	for i := 0; i < 10; i++ {
+		_, err := w.WriteString(fmt.Sprintf("record %d", i))
+		require.NoError(t, err)
+	}
+	return buf.Bytes()
+}
+
+func TestValidate(t *testing.T) {
+	data := validateStream(t)
+
+	count, err := Validate(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, 10, count)
+
+	count, err = Validate(bytes.NewReader(data), WithMaxRecordSize(1024))
+	require.NoError(t, err)
+	require.Equal(t, 10, count)
+
+	count, err = Validate(bytes.NewReader(nil))
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+func TestValidateTruncated(t *testing.T) {
+	data := validateStream(t)
+	frameSize := 4 + len("record 0")
+
+	// truncated payload
+	count, err := Validate(bytes.NewReader(data[:len(data)-3]))
+	require.Equal(t, 9, count)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	require.Equal(t, 9, verr.Record)
+	require.EqualValues(t, 9*frameSize, verr.Offset)
+	require.Contains(t, err.Error(), "payload")
+
+	// truncated length prefix
+	count, err = Validate(bytes.NewReader(data[:5*frameSize+2]))
+	require.Equal(t, 5, count)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.True(t, errors.As(err, &verr))
+	require.EqualValues(t, 5*frameSize, verr.Offset)
+	require.Contains(t, err.Error(), "length prefix")
+}
+
+func TestValidateAbsurdLength(t *testing.T) {
+	data := validateStream(t)
+	frameSize := 4 + len("record 0")
+
+	corrupt := append([]byte{}, data...)
+	copy(corrupt[3*frameSize:], []byte{0xff, 0xff, 0xff, 0x7f})
+
+	count, err := Validate(bytes.NewReader(corrupt), WithMaxRecordSize(1<<20))
+	require.Equal(t, 3, count)
+	require.ErrorIs(t, err, ErrRecordTooLarge)
+
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	require.EqualValues(t, 3*frameSize, verr.Offset)
+
+	// without a maximum the length runs past the end of the stream
+	_, err = Validate(bytes.NewReader(corrupt))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,65 @@
+package recio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// WithVarintLength encodes the length prefix as a uvarint, as produced by
+// binary.AppendUvarint, instead of a fixed size uint32. Records shorter than
+// 128 bytes then cost a single byte of framing. The prefix is read one byte
+// at a time, so readers of unbuffered streams should use WithReadBuffer as
+// well. Writer and reader must both use it. Varint lengths are limited to
+// 32 bits, of which the highest marks checksummed records, even with
+// WithLength64, so records must be shorter than 2GiB.
+func WithVarintLength() Option {
+	return func(o *options) {
+		o.varintLength = true
+	}
+}
+
+// readVarintLength reads a uvarint length prefix.
+func (r *Reader) readVarintLength() (uint32, error) {
+	length, err := r.readUvarint(!r.midFrame())
+	if err != nil {
+		return 0, err
+	}
+	if length > math.MaxUint32 {
+		return 0, fmt.Errorf("%w: length %d does not fit in 32 bits", ErrMalformedHeader, length)
+	}
+	return uint32(length), nil
+}
+
+// readUvarint reads a uvarint from the stream. io.EOF is only returned when
+// first is set and the stream ends before the first byte.
+func (r *Reader) readUvarint(first bool) (uint64, error) {
+	var x uint64
+	var s uint
+	for i := 0; i < binary.MaxVarintLen64; i++ {
+		b, err := r.readByte()
+		if err == io.EOF && (i > 0 || !first) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if b < 0x80 {
+			if i == binary.MaxVarintLen64-1 && b > 1 {
+				break
+			}
+			return x | uint64(b)<<s, nil
+		}
+		x |= uint64(b&0x7f) << s
+		s += 7
+	}
+	return 0, fmt.Errorf("%w: varint overflows 64 bits", ErrMalformedHeader)
+}
+
+// readByte reads a single byte from the stream.
+func (r *Reader) readByte() (byte, error) {
+	_, err := io.ReadFull(r.reader, r.oneByte[:])
+	return r.oneByte[0], err
+}
@@ -0,0 +1,101 @@
+package recio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarintLength(t *testing.T) {
+	sizes := []int{0, 1, 127, 128, 16383, 16384, 100000}
+
+	buf := bytes.NewBuffer([]byte{})
+	w := NewWriter(buf, WithVarintLength())
+	for _, size := range sizes {
+		_, err := w.Write(bytes.Repeat([]byte{'x'}, size))
+		require.NoError(t, err)
+	}
+
+	// the prefix of the first records are 1, 1, 1 and 2 bytes
+	require.Equal(t, []byte{0x00, 0x01, 'x', 0x7f}, buf.Bytes()[:4])
+	require.Equal(t, []byte{0x80, 0x01}, buf.Bytes()[4+127:4+127+2])
+
+	// read one byte at a time to exercise varints split across reads
+	r := NewReader(iotest.OneByteReader(bytes.NewReader(buf.Bytes())), WithVarintLength())
+	p := make([]byte, 100000)
+	for _, size := range sizes {
+		n, err := r.Read(p)
+		require.NoError(t, err)
+		require.Equal(t, size, n)
+	}
+	_, err := r.Read(p)
+	require.Equal(t, io.EOF, err)
+}
+
+func TestVarintLengthFiveBytes(t *testing.T) {
+	// lengths from 1<<28 need five bytes
+	prefix := binary.AppendUvarint(nil, 1<<28)
+	require.Len(t, prefix, 5)
+
+	r := NewReader(bytes.NewReader(prefix), WithVarintLength())
+	length, err := r.PeekLength()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1<<28), length)
+
+	// lengths over 32 bits are rejected
+	r = NewReader(bytes.NewReader(binary.AppendUvarint(nil, math.MaxUint32+1)), WithVarintLength())
+	_, err = r.NextReader()
+	require.ErrorIs(t, err, ErrMalformedHeader)
+
+	// a truncated varint is not a clean EOF
+	r = NewReader(bytes.NewReader(prefix[:3]), WithVarintLength())
+	_, err = r.NextReader()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func BenchmarkVarintLength(b *testing.B) {
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{"fixed", nil},
+		{"varint", []Option{WithVarintLength()}},
+	} {
+		for _, size := range []int{16, 100, 1000} {
+			b.Run(fmt.Sprintf("%s/%d", bench.name, size), func(b *testing.B) {
+				buf := bytes.NewBuffer([]byte{})
+				w := NewWriter(buf, bench.opts...)
+				p := make([]byte, size)
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					_, err := w.Write(p)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(buf.Len())/float64(b.N), "bytes/record")
+			})
+		}
+	}
+}
+
+func TestVarintLengthLimit(t *testing.T) {
+	if strconv.IntSize < 64 {
+		t.Skip("needs 64 bit lengths")
+	}
+
+	// varint lengths stay 32 bits wide with WithLength64, so the writer
+	// refuses what the reader could not read
+	size := int64(1) << 31
+	w := NewWriter(io.Discard, WithVarintLength(), WithLength64(), WithChecksum())
+	_, err := w.WriteLen(int(size), eofReader{})
+	require.ErrorIs(t, err, ErrRecordTooLarge)
+}
@@ -0,0 +1,136 @@
+package recio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+var ErrNegativeLength = errors.New("record length is negative")
+
+// WriteLen writes a record of length bytes read from src, streaming them to
+// the underlying writer rather than holding the record in memory. It
+// returns the number of payload bytes written and fails with an error
+// wrapping io.ErrUnexpectedEOF if src ends before length bytes.
+//
+// Options that need the whole record before it can be framed, WithCodec,
+// WithAEAD, WithChunking, WithExtendedHeader and WithCrashRing, make
+// WriteLen read the record into memory and write it with Write, so a short
+// src writes nothing. Otherwise the frame is written as src is read. If it
+// fails part way through, the partial frame is truncated away as with Write;
+// if the underlying writer can't be truncated, every later write fails too,
+// since the stream no longer ends at a record boundary.
+func (w *Writer) WriteLen(length int, src io.Reader) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if length < 0 {
+		return 0, fmt.Errorf("%w: %d", ErrNegativeLength, length)
+	}
+
+	if w.opts.transformsPayload() || w.opts.chunkSize > 0 || w.opts.extendedHeader || w.ring != nil {
+		p := make([]byte, length)
+		n, err := io.ReadFull(src, p)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return 0, fmt.Errorf("%w: src ends after %d of %d bytes", io.ErrUnexpectedEOF, n, length)
+		}
+		if err != nil {
+			return 0, err
+		}
+		return w.Write(p)
+	}
+
+	if w.opts.frameLength(length) > w.opts.maxLength() {
+		return 0, w.observeError(fmt.Errorf("%w: %d byte payload does not fit in the length prefix", ErrRecordTooLarge, length))
+	}
+
+	out := &streamWriter{w: w}
+	err := w.streamFrame(out, length, src)
+	if err != nil {
+		// leave no partial frame behind, or refuse to write after it
+		if out.n > 0 && !w.rollback(out.n) {
+			w.err = err
+		}
+		return 0, w.flushOnError(err)
+	}
+	return length, w.frameWritten(length, out.n)
+}
+
+// streamFrame writes the frame for the length byte payload read from src to
+// out, and updates the per-record writer state once it has been written.
+func (w *Writer) streamFrame(out *streamWriter, length int, src io.Reader) error {
+	var bodyStart int
+	w.frame, bodyStart = w.appendFrameHead(w.frame[:0], length)
+
+	// everything the checksums and the chain hash cover is written to them
+	// along the way
+	var sums []io.Writer
+	frameCRC := crc32.New(castagnoli)
+	if w.opts.prevFrameCRC {
+		frameCRC.Write(w.frame)
+		sums = append(sums, frameCRC)
+	}
+	checksum := crc32.New(castagnoli)
+	if w.opts.checksum {
+		checksum.Write(w.frame[bodyStart:])
+		sums = append(sums, checksum)
+	}
+	if w.opts.merkleChain {
+		w.beginChainHash()
+		sums = append(sums, w.hasher)
+	}
+
+	_, err := out.Write(w.frame)
+	if err != nil {
+		return err
+	}
+
+	n, err := io.CopyN(out, io.TeeReader(src, io.MultiWriter(sums...)), int64(length))
+	if err == io.EOF {
+		return fmt.Errorf("%w: src ends after %d of %d bytes", io.ErrUnexpectedEOF, n, length)
+	}
+	if err != nil {
+		return err
+	}
+
+	var chain [merkleHashSize]byte
+	w.frame = w.frame[:0]
+	if w.opts.merkleChain {
+		w.hasher.Sum(chain[:0])
+		w.frame = append(w.frame, chain[:]...)
+		checksum.Write(chain[:])
+		frameCRC.Write(chain[:])
+	}
+	if w.opts.checksum {
+		w.frame = binary.LittleEndian.AppendUint32(w.frame, checksum.Sum32())
+		frameCRC.Write(w.frame[len(w.frame)-checksumSize:])
+	}
+	w.frame = w.appendPadding(w.frame, w.offset+int64(out.n))
+
+	_, err = out.Write(w.frame)
+	if err != nil {
+		return err
+	}
+
+	w.guard++
+	w.chain = chain
+	if w.opts.prevFrameCRC {
+		w.prevCRC = frameCRC.Sum32()
+	}
+	return nil
+}
+
+// streamWriter writes the parts of a frame that is streamed and counts how
+// many bytes of it have been written.
+type streamWriter struct {
+	w *Writer
+	n int
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	n, err := s.w.writeFrame(p)
+	s.n += n
+	return n, err
+}
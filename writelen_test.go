@@ -0,0 +1,109 @@
+package recio
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLen(t *testing.T) {
+	records := []string{"first", "", "a third record"}
+
+	for _, opts := range [][]Option{
+		nil,
+		{WithChecksum(), WithSyncMarkers()},
+		{WithMerkleChain(), WithSchemaVersion(3)},
+		{WithPrevFrameCRC(), WithStreamGuard()},
+		{WithAlignment(8), WithVarintLength()},
+		{WithTypeTag(), WithIndex()},
+		{WithCodec(GzipCodec{})},
+		{WithChunking(4)},
+	} {
+		// streamed records make the same stream as written ones
+		var want, got bytes.Buffer
+		ww := NewWriter(&want, opts...)
+		w := NewWriter(&got, opts...)
+		for _, rec := range records {
+			_, err := ww.WriteString(rec)
+			require.NoError(t, err)
+
+			n, err := w.WriteLen(len(rec), strings.NewReader(rec))
+			require.NoError(t, err)
+			require.Equal(t, len(rec), n)
+		}
+		require.Equal(t, want.Bytes(), got.Bytes())
+		require.Equal(t, ww.Stats(), w.Stats())
+		require.Equal(t, ww.Offsets(), w.Offsets())
+
+		// src may hold more than the record
+		_, err := w.WriteLen(3, bytes.NewReader([]byte("abcdef")))
+		require.NoError(t, err)
+
+		r := NewReader(&got, opts...)
+		for _, want := range append(records, "abc") {
+			rec, err := r.ReadRecord()
+			require.NoError(t, err)
+
+			// records are returned along with their schema version
+			if newOptions(opts).hasSchemaVersion {
+				rec = rec[1:]
+			}
+			require.Equal(t, want, string(rec))
+		}
+		_, err = r.ReadRecord()
+		require.ErrorIs(t, err, io.EOF)
+	}
+}
+
+func TestWriteLenShort(t *testing.T) {
+	// a partial frame that can't be truncated away stops the writer
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	_, err := w.WriteLen(10, strings.NewReader("short"))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	_, err = w.WriteString("next")
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// unless nothing has been written
+	buf.Reset()
+	w = NewWriter(&buf, WithCodec(GzipCodec{}))
+	_, err = w.WriteLen(10, strings.NewReader("short"))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Zero(t, buf.Len())
+	_, err = w.WriteString("next")
+	require.NoError(t, err)
+
+	// or it can be truncated away
+	f, err := os.Create(filepath.Join(t.TempDir(), "data.seq"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	w = NewWriter(f, WithChecksum())
+	_, err = w.WriteString("first")
+	require.NoError(t, err)
+	_, err = w.WriteLen(10, strings.NewReader("short"))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	_, err = w.WriteString("next")
+	require.NoError(t, err)
+
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	r := NewReader(f, WithChecksum())
+	for _, want := range []string{"first", "next"} {
+		rec, err := r.ReadRecord()
+		require.NoError(t, err)
+		require.Equal(t, want, string(rec))
+	}
+	_, err = r.ReadRecord()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestWriteLenNegative(t *testing.T) {
+	_, err := NewWriter(io.Discard).WriteLen(-1, strings.NewReader(""))
+	require.ErrorIs(t, err, ErrNegativeLength)
+}